@@ -1,9 +1,15 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"math/big"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
@@ -12,14 +18,34 @@ import (
 	"github.com/web3-smart-wallet/smart-contract-cli/lib/pages/password"
 	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
 	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/views"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// remoteSignerTimeout bounds connecting to SIGNER_URL at startup
+const remoteSignerTimeout = 10 * time.Second
+
+// registryClaimTimeout bounds looking up old registry entries on the starting network
+const registryClaimTimeout = 30 * time.Second
+
 // Create a local type that embeds the imported type
 type LocalModel struct {
 	types.AppModel
 	types.State
+
+	// nftService owns the RPC connection and is closed when the program exits
+	nftService      *services.NftService
+	contractService *services.ContractCompiler
+}
+
+// registryClaimedMsg reports the chain the node of network is on and the migration of
+// contracts saved before chain IDs were recorded on it
+type registryClaimedMsg struct {
+	network string
+	chainID *big.Int
+	claimed int
+	err     error
 }
 
 func initialModel() LocalModel {
@@ -30,28 +56,86 @@ func initialModel() LocalModel {
 	}
 
 	// Get configuration from environment variables
-	rpcURL := os.Getenv("RPC_URL")
+	networks, network, err := loadNetwork()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rpcURL := network.RPCURL
+	signerURL := os.Getenv("SIGNER_URL")
+	keystorePath := os.Getenv("KEYSTORE_PATH")
 	privateKey := os.Getenv("PRIVATE_KEY")
 	userPassword := os.Getenv("PASSWORD")
 
-	if rpcURL == "" || privateKey == "" {
-		fmt.Println("RPC_URL or PRIVATE_KEY is not set, please set it in the .env file")
+	// The keystore is unlocked with the password typed on the password page,
+	// PRIVATE_KEY and PASSWORD are only needed without one. A remote signer holds
+	// the key itself, PASSWORD still guards the app.
+	if signerURL != "" {
+		if userPassword == "" {
+			fmt.Println("PASSWORD is not set, please set it in the .env file")
+			os.Exit(1)
+		}
+	} else if keystorePath == "" {
+		if privateKey == "" {
+			fmt.Println("SIGNER_URL, KEYSTORE_PATH or PRIVATE_KEY is not set, please set it in the .env file")
+			os.Exit(1)
+		}
+		if userPassword == "" {
+			fmt.Println("PASSWORD is not set, please set it in the .env file")
+			os.Exit(1)
+		}
+	} else if _, err := os.Stat(keystorePath); err != nil {
+		fmt.Printf("KEYSTORE_PATH is invalid: %v\n", err)
 		os.Exit(1)
 	}
 
-	if userPassword == "" {
-		fmt.Println("PASSWORD is not set, please set it in the .env file")
+	// Create shared services
+	var nftService *services.NftService
+	var passwordService *password.Service
+	if signerURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteSignerTimeout)
+		signer, err := services.NewRemoteSigner(ctx, signerURL, os.Getenv("SIGNER_ADDRESS"))
+		cancel()
+		if err != nil {
+			fmt.Printf("SIGNER_URL is invalid: %v\n", err)
+			os.Exit(1)
+		}
+		logger.Log("INFO", fmt.Sprintf("已连接远程签名器，地址: %s", signer.Address().Hex()))
+		nftService = services.NewNftServiceWithSigner(rpcURL, signer)
+		passwordService = password.NewService(userPassword)
+	} else if keystorePath != "" {
+		nftService = services.NewNftServiceWithSigner(rpcURL, nil)
+		passwordService = password.NewUnlockService(func(passphrase string) error {
+			signer, err := services.UnlockKeystore(keystorePath, passphrase)
+			if errors.Is(err, services.ErrWrongPassphrase) {
+				return fmt.Errorf(constant.WrongPasswordError)
+			} else if err != nil {
+				return err
+			}
+			nftService.SetSigner(signer)
+			logger.Log("INFO", fmt.Sprintf("已解锁 keystore，地址: %s", signer.Address().Hex()))
+			return nil
+		})
+	} else {
+		nftService = services.NewNftService(rpcURL, privateKey)
+		passwordService = password.NewService(userPassword)
+	}
+	if err := configureService(nftService, network); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
-
-	// Create shared services
-	nftService := services.NewNftService(rpcURL, privateKey)
-	passwordService := password.NewService(userPassword)
+	// every transaction is tracked so it can be sped up or cancelled, on any network
+	nftService.SetTxTracker(services.NewTxTracker(services.DefaultTxTrackerPath))
+	setActiveNetwork(network)
 	contractService := services.NewContractCompiler("./artifacts")
 
 	// Create shared models
 	airdropModel := models.NewAirdropModel()
 	deployContractModel := models.NewDeployContractModel()
+	inspectModel := models.NewInspectModel()
+	contractCallModel := models.NewContractCallModel()
+	contractAdminModel := models.NewContractAdminModel()
+	pendingTxModel := models.NewPendingTxModel()
 
 	// Create controllers
 	passwordController := controllers.NewPasswordController(passwordService)
@@ -63,7 +147,34 @@ func initialModel() LocalModel {
 	airdropController := controllers.NewAirdropController(airdropModel, nftService)
 	uploadController := controllers.NewUploadController()
 	confirmController := controllers.NewConfirmController(nftService)
-	checkController := controllers.NewCheckTotalController(nftService, contractService)
+	checkController := controllers.NewCheckTotalController(nftService, contractService, services.DefaultSupplyCacheDir)
+	resumeAirdropController := controllers.NewResumeAirdropController(nftService, services.DefaultJournalPath)
+	inspectController := controllers.NewInspectController(nftService, contractService, inspectModel)
+	contractCallController := controllers.NewContractCallController(nftService, contractService, contractCallModel)
+	importContractController := controllers.NewImportContractController(nftService, contractService)
+	contractAdminController := controllers.NewContractAdminController(nftService, contractService, contractAdminModel)
+	pendingTxController := controllers.NewPendingTxController(nftService, services.DefaultTxTrackerPath, pendingTxModel)
+	var profiles []services.NetworkProfile
+	if networks != nil {
+		profiles = networks.Networks
+	}
+	networkController := controllers.NewNetworkController(nftService, contractService, profiles, networksPath(), func(profile services.NetworkProfile) error {
+		if err := configureService(nftService, profile); err != nil {
+			return err
+		}
+		setActiveNetwork(profile)
+		return nil
+	})
+
+	// Offer to resume an airdrop an earlier run did not finish right after login
+	unfinished, err := resumeAirdropController.FindUnfinished()
+	if err != nil {
+		fmt.Printf("Failed to read airdrop journal: %v\n", err)
+		os.Exit(1)
+	}
+	if unfinished {
+		passwordController.SetNextPage(constant.ResumeAirdropPage)
+	}
 
 	// 添加合约选择控制器
 
@@ -85,18 +196,39 @@ func initialModel() LocalModel {
 				constant.UpLoadPage:         uploadController,
 				constant.ConfirmPage:        confirmController,
 				constant.CheckTotalPage:     checkController,
+				constant.ResumeAirdropPage:  resumeAirdropController,
+				constant.InspectPage:        inspectController,
+				constant.ContractCallPage:   contractCallController,
+				constant.NetworkPage:        networkController,
+				constant.ImportContractPage: importContractController,
+				constant.ContractAdminPage:  contractAdminController,
+				constant.PendingTxPage:      pendingTxController,
 			},
 		},
 		State: types.State{
 			UploadWalletAddresses: []string{},
 			SelectedContract:      "", // 添加选中的合约地址
 		},
+		nftService:      nftService,
+		contractService: contractService,
 	}
 }
 
 // Define methods on the local type
 func (m LocalModel) Init() tea.Cmd {
-	return nil
+	// Ask the node for its chain and claim old registry entries for it in the background,
+	// the node may be down and nothing waits for it
+	network := types.GlobalState.Network
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), registryClaimTimeout)
+		defer cancel()
+		chainID, err := m.nftService.VerifyChain(ctx)
+		if err != nil {
+			return registryClaimedMsg{network: network, err: err}
+		}
+		claimed, err := m.contractService.ClaimUnassignedContracts(ctx, m.nftService, network)
+		return registryClaimedMsg{network: network, chainID: chainID, claimed: claimed, err: err}
+	}
 }
 
 func (m LocalModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -104,11 +236,26 @@ func (m LocalModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case types.ErrorMsg:
 		m.AppModel.ErrorMessage = msg.Err.Error()
 		m.AppModel.Logger.Log("ERROR", msg.Err.Error())
+		if msg.Result != nil {
+			m.AppModel.Logger.LogResult(*msg.Result)
+		}
 		return m, nil
 
 	case types.SuccessMsg:
 		m.AppModel.SuccessMessage = msg.Message
 		m.AppModel.Logger.Log("INFO", msg.Message)
+		if msg.Result != nil {
+			m.AppModel.Logger.LogResult(*msg.Result)
+		}
+		return m, nil
+
+	case registryClaimedMsg:
+		setReportedChain(msg.network, msg.chainID)
+		if msg.err != nil {
+			m.AppModel.Logger.Log("ERROR", fmt.Sprintf("旧合约记录迁移失败: %v", msg.err))
+		} else if msg.claimed > 0 {
+			m.AppModel.Logger.Log("INFO", fmt.Sprintf("%d 个旧合约记录已归入当前网络 %s", msg.claimed, types.GlobalState.Network))
+		}
 		return m, nil
 
 	case types.ChangePageMsg:
@@ -138,13 +285,22 @@ func (m LocalModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		return m, cmd
-	}
 
-	return m, nil
+	default:
+		// Results of background work (deployments, airdrops) go to the current page
+		controller := m.AppModel.Controllers[m.AppModel.CurrentPage]
+		result, cmd := controller.Update(m.AppModel, msg)
+		if result != nil {
+			m.AppModel = result.(types.AppModel)
+		}
+
+		return m, cmd
+	}
 }
 
 func (m LocalModel) View() string {
 	var s strings.Builder
+	s.WriteString(views.NetworkHeader())
 
 	if m.AppModel.ErrorMessage != "" {
 		s.WriteString(fmt.Sprintf("\n%s%s\n\n", constant.ErrorPrefix, m.AppModel.ErrorMessage))
@@ -164,10 +320,131 @@ func (m LocalModel) View() string {
 	return s.String()
 }
 
+// networksPath returns the networks file named by NETWORKS_FILE, networks.json by default
+func networksPath() string {
+	if path := os.Getenv("NETWORKS_FILE"); path != "" {
+		return path
+	}
+	return services.DefaultNetworksPath
+}
+
+// loadNetwork picks the profile named by NETWORK, or the default one, from the networks
+// file. Without a networks file RPC_URL is used, pinned to CHAIN_ID when it is set.
+func loadNetwork() (*services.NetworksConfig, services.NetworkProfile, error) {
+	networks, err := services.LoadNetworks(networksPath())
+	if err != nil {
+		return nil, services.NetworkProfile{}, err
+	}
+	if networks != nil {
+		network, err := networks.Profile(os.Getenv("NETWORK"))
+		return networks, network, err
+	}
+
+	if name := os.Getenv("NETWORK"); name != "" {
+		return nil, services.NetworkProfile{}, fmt.Errorf("NETWORK is set to %s but %s does not exist", name, networksPath())
+	}
+	network := services.NetworkProfile{Name: "RPC_URL", RPCURL: os.Getenv("RPC_URL")}
+	if network.RPCURL == "" {
+		return nil, network, fmt.Errorf("RPC_URL is not set, please set it in the .env file or add %s", networksPath())
+	}
+	if value := os.Getenv("CHAIN_ID"); value != "" {
+		network.ChainID, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, network, fmt.Errorf("CHAIN_ID is invalid: %v", err)
+		}
+	}
+	return nil, network, nil
+}
+
+// setActiveNetwork shows network as the active one on every page
+func setActiveNetwork(network services.NetworkProfile) {
+	types.GlobalState.Network = network.Name
+	types.GlobalState.ChainID = network.ChainID
+	types.GlobalState.Mainnet = network.Mainnet
+	types.GlobalState.Explorer = network.Explorer
+}
+
+// setReportedChain records the chain the node of network reported, so pages filter on it
+// when the profile does not pin a chain ID. A network switched away from since is ignored.
+func setReportedChain(network string, chainID *big.Int) {
+	if chainID != nil && types.GlobalState.Network == network {
+		types.GlobalState.ChainID = chainID.Uint64()
+	}
+}
+
+// configureService points service at the RPC endpoint and chain of network and applies
+// its fee caps, the fee caps, gas limit multiplier and RPC timeouts from the environment
+// take precedence. It runs again on every network switch.
+func configureService(service *services.NftService, network services.NetworkProfile) error {
+	policy, err := network.FeePolicy()
+	if err != nil {
+		return err
+	}
+
+	// Optional fee caps in gwei, unset means no cap
+	maxFeePerGas, err := services.ParseGwei(os.Getenv("MAX_FEE_PER_GAS_GWEI"))
+	if err != nil {
+		return fmt.Errorf("MAX_FEE_PER_GAS_GWEI is invalid: %v", err)
+	}
+	maxPriorityFeePerGas, err := services.ParseGwei(os.Getenv("MAX_PRIORITY_FEE_PER_GAS_GWEI"))
+	if err != nil {
+		return fmt.Errorf("MAX_PRIORITY_FEE_PER_GAS_GWEI is invalid: %v", err)
+	}
+
+	// Optional safety multiplier applied to gas estimates
+	gasMultiplier := services.DefaultGasLimitMultiplier
+	if value := os.Getenv("GAS_LIMIT_MULTIPLIER"); value != "" {
+		gasMultiplier, err = strconv.ParseFloat(value, 64)
+		if err != nil || gasMultiplier < 1 {
+			return fmt.Errorf("GAS_LIMIT_MULTIPLIER must be a number of at least 1")
+		}
+	}
+
+	// Optional RPC timeouts such as "10s" or "5m", unset keeps the defaults
+	timeouts := services.Timeouts{}
+	for name, target := range map[string]*time.Duration{
+		"RPC_DIAL_TIMEOUT": &timeouts.Dial,
+		"RPC_SEND_TIMEOUT": &timeouts.Send,
+		"SIGN_TIMEOUT":     &timeouts.Sign,
+		"TX_MINE_TIMEOUT":  &timeouts.Mine,
+	} {
+		if value := os.Getenv(name); value != "" {
+			*target, err = time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s is invalid: %v", name, err)
+			}
+		}
+	}
+
+	if maxFeePerGas != nil {
+		policy.MaxFeePerGas = maxFeePerGas
+	}
+	if maxPriorityFeePerGas != nil {
+		policy.MaxPriorityFeePerGas = maxPriorityFeePerGas
+	}
+
+	service.SetNetwork(network.RPCURL, network.ChainID)
+	service.SetTimeouts(timeouts)
+	service.SetFeePolicy(policy)
+	service.SetGasLimitMultiplier(gasMultiplier)
+	return nil
+}
+
 // Run the application
 func Run() {
 	_ = godotenv.Load() // ignore error since it's not required
 
+	// scripting subcommands run instead of the TUI, without one the TUI starts as before
+	if len(os.Args) > 1 {
+		if command, ok := cliCommands[os.Args[1]]; ok {
+			os.Exit(runCLICommand(command, os.Args[2:]))
+		}
+	}
+
+	dryRun := flag.Bool("dry-run", false, "simulate every transaction with eth_call instead of signing and sending it")
+	flag.Parse()
+	types.GlobalState.DryRun = *dryRun
+
 	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
 
 	m, err := p.Run()
@@ -176,10 +453,13 @@ func Run() {
 		os.Exit(1)
 	}
 
-	// Close the logger when the program exits
+	// Close the logger and the RPC connection when the program exits
 	if model, ok := m.(LocalModel); ok {
 		if err := model.Logger.Close(); err != nil {
 			fmt.Printf("Error closing logger: %v\n", err)
 		}
+		if err := model.nftService.Close(); err != nil {
+			fmt.Printf("Error closing RPC connection: %v\n", err)
+		}
 	}
 }
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -8,6 +9,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/exp/teatest"
 	"github.com/stretchr/testify/suite"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
 )
 
 type AppTestSuite struct {
@@ -53,3 +55,34 @@ func TestAppFlow(t *testing.T) {
 		teatest.WithDuration(time.Second*3))
 
 }
+
+func TestResumePromptAfterLogin(t *testing.T) {
+	t.Setenv("RPC_URL", "http://localhost:8545")
+	t.Setenv("PRIVATE_KEY", "0xtest1234567890")
+	t.Setenv("PASSWORD", "123")
+
+	// an airdrop journal left behind by an interrupted run
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	journal := services.NewAirdropJournal(services.DefaultJournalPath, "0x5FbDB2315678afecb367f032d93F642f64180aa3", "1", "", [][]string{
+		{"0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65"},
+	})
+	if err := journal.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	tm := teatest.NewTestModel(t, initialModel())
+	tm.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("123")})
+	tm.Send(tea.KeyMsg{Type: tea.KeyEnter})
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return strings.Contains(string(bts), "发现未完成的空投")
+	}, teatest.WithCheckInterval(time.Millisecond*100),
+		teatest.WithDuration(time.Second*3))
+}
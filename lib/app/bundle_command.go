@@ -0,0 +1,274 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// stringList collects every value of a flag that may be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// onlineService creates a service for the active network without a signer, prepare and
+// broadcast never sign
+func onlineService() (*services.NftService, services.NetworkProfile, error) {
+	_, network, err := loadNetwork()
+	if err != nil {
+		return nil, network, err
+	}
+	service := services.NewNftServiceWithSigner(network.RPCURL, nil)
+	if err := configureService(service, network); err != nil {
+		return nil, network, err
+	}
+	service.SetTxTracker(services.NewTxTracker(services.DefaultTxTrackerPath))
+	return service, network, nil
+}
+
+// parseBundleFlags parses the flags of a bundle step followed by the bundle file and
+// returns the file
+func parseBundleFlags(flags *flag.FlagSet, args []string, out *commandOutput) (string, error) {
+	out.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return "", flag.ErrHelp
+	}
+	if err := out.validate(); err != nil {
+		return "", err
+	}
+	if flags.NArg() != 1 {
+		return "", usageError("expected the bundle file")
+	}
+	return flags.Arg(0), nil
+}
+
+// prepareDeployBundle writes an unsigned bundle deploying the contract of an artifact file
+func prepareDeployBundle(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("bundle prepare deploy", flag.ContinueOnError)
+	from := flags.String("from", "", "address of the key that will sign the bundle")
+	artifactPath := flags.String("artifact", "", "contract JSON file with contractName, bytecode and abi")
+	var constructorArgs stringList
+	flags.Var(&constructorArgs, "arg", "constructor argument, repeated in order")
+	value := flags.String("value", "", "wei sent to a payable constructor")
+	path := flags.String("out", "deploy.bundle.json", "file the bundle is written to")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+	if *from == "" || *artifactPath == "" {
+		return usageError("--from and --artifact are required")
+	}
+
+	artifact, err := services.LoadAvailableContract(*artifactPath)
+	if err != nil {
+		return err
+	}
+	constructor, err := services.ConstructorOf(artifact.ABI)
+	if err != nil {
+		return err
+	}
+	parsedArgs, err := services.ParseABIArguments(constructor, constructorArgs)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	params := services.DeployContractParams{
+		Bytecode:        artifact.Bytecode,
+		ConstructorABI:  artifact.ABI,
+		ConstructorArgs: parsedArgs,
+	}
+	if *value != "" {
+		wei, ok := new(big.Int).SetString(*value, 10)
+		if !ok || wei.Sign() < 0 {
+			return usageError("invalid --value %q, expected wei", *value)
+		}
+		params.Value = wei
+	}
+
+	service, network, err := onlineService()
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	out.setNetwork(network)
+
+	bundle, err := service.PrepareDeploy(ctx, *path, *from, params, services.DeployedContract{
+		ContractName:    artifact.ContractName,
+		TokenURI:        services.ConstructorURI(constructor, constructorArgs),
+		ConstructorArgs: constructorArgs,
+	})
+	if err != nil {
+		return err
+	}
+	printBundle(out, bundle)
+	out.Printf("Wrote %s, sign it with: smart-contract-cli bundle sign %s\n", *path, *path)
+	out.Result(services.NewBundleResult(services.OperationBundlePrepare, *path, bundle, nil))
+	return nil
+}
+
+// prepareAirdropBundle writes an unsigned bundle with the setURI and mint batches of an airdrop
+func prepareAirdropBundle(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("bundle prepare airdrop", flag.ContinueOnError)
+	from := flags.String("from", "", "address of the key that will sign the bundle")
+	contract := flags.String("contract", "", "address of the NFT contract")
+	nftID := flags.String("nft-id", "", "token ID to mint")
+	addressFile := flags.String("addresses", "", "file with one recipient address per line")
+	uri := flags.String("uri", "", "token URI set before minting, empty keeps the current one")
+	path := flags.String("out", "airdrop.bundle.json", "file the bundle is written to")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+	if *from == "" || *contract == "" || *nftID == "" || *addressFile == "" {
+		return usageError("--from, --contract, --nft-id and --addresses are required")
+	}
+
+	addresses, err := services.ReadAddressFile(*addressFile)
+	if err != nil {
+		return err
+	}
+
+	service, network, err := onlineService()
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	out.setNetwork(network)
+
+	bundle, err := service.PrepareAirdrop(ctx, *path, *from, *contract, addresses, *nftID, *uri)
+	if err != nil {
+		return err
+	}
+	printBundle(out, bundle)
+	out.Printf("Wrote %s, sign it with: smart-contract-cli bundle sign %s\n", *path, *path)
+	out.Result(services.NewBundleResult(services.OperationBundlePrepare, *path, bundle, nil))
+	return nil
+}
+
+// signBundle shows the transactions of a bundle and signs them without touching the network
+func signBundle(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("bundle sign", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "sign without asking for confirmation")
+	path, err := parseBundleFlags(flags, args, out)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := services.LoadTxBundle(path)
+	if err != nil {
+		return err
+	}
+	printBundle(out, bundle)
+
+	stdin := bufio.NewReader(os.Stdin)
+	if !*yes {
+		out.Prompt("Sign these transactions? [y/N]: ")
+		answer, _ := stdin.ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return errNotConfirmed
+		}
+	}
+
+	signer, err := loadSigner(ctx, stdin)
+	if err != nil {
+		return err
+	}
+	if closer, ok := signer.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if err := services.SignBundle(ctx, bundle, signer); err != nil {
+		return err
+	}
+	out.Printf("Signed %s, broadcast it with: smart-contract-cli bundle broadcast %s\n", path, path)
+	out.Result(services.NewBundleResult(services.OperationBundleSign, path, bundle, nil))
+	return nil
+}
+
+// loadSigner returns the signer configured in the environment with the same precedence as
+// the TUI: SIGNER_URL, then KEYSTORE_PATH unlocked with a prompted passphrase, then PRIVATE_KEY
+func loadSigner(ctx context.Context, stdin *bufio.Reader) (services.Signer, error) {
+	if signerURL := os.Getenv("SIGNER_URL"); signerURL != "" {
+		dialCtx, cancel := context.WithTimeout(ctx, remoteSignerTimeout)
+		defer cancel()
+		return services.NewRemoteSigner(dialCtx, signerURL, os.Getenv("SIGNER_ADDRESS"))
+	}
+	if keystorePath := os.Getenv("KEYSTORE_PATH"); keystorePath != "" {
+		passphrase, err := readSecret(stdin, "Keystore passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		return services.UnlockKeystore(keystorePath, passphrase)
+	}
+	if privateKey := os.Getenv("PRIVATE_KEY"); privateKey != "" {
+		return services.NewPrivateKeySigner(privateKey)
+	}
+	return nil, fmt.Errorf("SIGNER_URL, KEYSTORE_PATH or PRIVATE_KEY is not set, please set it in the .env file")
+}
+
+// broadcastBundle sends a signed bundle and records a mined deployment like the TUI does
+func broadcastBundle(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("bundle broadcast", flag.ContinueOnError)
+	path, err := parseBundleFlags(flags, args, out)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := services.LoadTxBundle(path)
+	if err != nil {
+		return err
+	}
+
+	service, network, err := onlineService()
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	out.setNetwork(network)
+
+	contractCompiler := services.NewContractCompiler("./artifacts")
+	err = service.BroadcastBundle(ctx, bundle, func(tx services.BundleTx) {
+		if tx.State != services.BatchMined {
+			out.Printf("%s: %s\n", tx.Label, tx.Error)
+			return
+		}
+		printTx(out, network, fmt.Sprintf("%s: mined in block %d", tx.Label, tx.BlockNumber), tx.TxHash)
+		if tx.ContractAddress == "" {
+			return
+		}
+		out.Printf("Contract deployed at %s\n", tx.ContractAddress)
+		contract := bundle.DeployedContract(tx, network.Name)
+		if err := contractCompiler.SaveDeployedContract(contract); err != nil {
+			out.Printf("Failed to record the contract: %v\n", err)
+			return
+		}
+		out.Result(services.NewDeployResult(contract))
+	})
+	// 部分交易失败时也输出已上链的交易
+	out.Result(services.NewBundleResult(services.OperationBundleBroadcast, path, bundle, err))
+	return err
+}
+
+// printBundle lists the transactions of a bundle for review before signing
+func printBundle(out *commandOutput, bundle *services.TxBundle) {
+	out.Printf("%s bundle on chain %s from %s\n", bundle.Kind, bundle.ChainID, bundle.From)
+	for i, btx := range bundle.Transactions {
+		tx := btx.Tx
+		to := "new contract"
+		if tx.To() != nil {
+			to = tx.To().Hex()
+		}
+		out.Printf("  %d. %-20s nonce %d  to %s  value %s wei  gas %d  max fee per gas %s wei  [%s]\n",
+			i+1, btx.Label, tx.Nonce(), to, tx.Value(), tx.Gas(), tx.GasFeeCap(), btx.State)
+	}
+}
@@ -0,0 +1,526 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// Exit codes of the scripting subcommands
+const (
+	exitOK = 0
+	// exitFailed is returned when a command ran and failed, transactions may have been sent
+	exitFailed = 1
+	// exitUsage is returned for unknown commands and invalid flags, nothing was done
+	exitUsage = 2
+	// exitNotConfirmed is returned when the confirmation was declined, nothing was sent
+	exitNotConfirmed = 3
+)
+
+const cliUsage = `Usage:
+  smart-contract-cli deploy --artifact FILE [--uri URI] [--arg VALUE]... [--value WEI] [--yes]
+  smart-contract-cli airdrop --contract ADDRESS --token-id ID --recipients FILE [--uri URI] [--yes]
+  smart-contract-cli set-uri --contract ADDRESS --uri URI [--yes]
+  smart-contract-cli inspect --contract ADDRESS [--account ADDRESS --token-ids ID,ID...]
+  smart-contract-cli contracts list [--all]
+  smart-contract-cli bundle prepare deploy --from ADDRESS --artifact FILE [--arg VALUE]... [--value WEI] [--out FILE]
+  smart-contract-cli bundle prepare airdrop --from ADDRESS --contract ADDRESS --nft-id ID --addresses FILE [--uri URI] [--out FILE]
+  smart-contract-cli bundle sign [--yes] FILE
+  smart-contract-cli bundle broadcast FILE
+  smart-contract-cli registry repair
+  smart-contract-cli keystore new|import [--dir keystore]
+  smart-contract-cli serve [--addr 127.0.0.1:8080]
+
+Commands use the network of RPC_URL or NETWORK and sign with SIGNER_URL, KEYSTORE_PATH or
+PRIVATE_KEY like the TUI. Without --yes every transaction is confirmed on stdin first.
+bundle prepare and broadcast need the network and no key, bundle sign needs the key and
+no network.
+Every command takes --output json to print one versioned result object per line.
+Exit codes: 0 done, 1 failed, 2 invalid usage, 3 not confirmed.
+`
+
+// errNotConfirmed is returned when the confirmation prompt was declined
+var errNotConfirmed = errors.New("not confirmed, nothing was sent")
+
+// cliCommand is a scripting subcommand, operation names its results. A command with
+// subcommands is followed by the name of one, run is nil when it does nothing on its own.
+type cliCommand struct {
+	operation   string
+	run         func(ctx context.Context, args []string, out *commandOutput) error
+	subcommands map[string]cliCommand
+}
+
+// cliCommands are the scripting subcommands run instead of the TUI
+var cliCommands = map[string]cliCommand{
+	"deploy":  {operation: services.OperationDeploy, run: deployCommand},
+	"airdrop": {operation: services.OperationAirdrop, run: airdropCommand},
+	"set-uri": {operation: services.OperationSetURI, run: setURICommand},
+	"inspect": {operation: services.OperationInspect, run: inspectCommand},
+	"contracts": {operation: "contracts", subcommands: map[string]cliCommand{
+		"list": {operation: services.OperationContractsList, run: contractsListCommand},
+	}},
+	"bundle": {operation: "bundle", subcommands: map[string]cliCommand{
+		"prepare": {operation: services.OperationBundlePrepare, subcommands: map[string]cliCommand{
+			"deploy":  {operation: services.OperationBundlePrepare, run: prepareDeployBundle},
+			"airdrop": {operation: services.OperationBundlePrepare, run: prepareAirdropBundle},
+		}},
+		"sign":      {operation: services.OperationBundleSign, run: signBundle},
+		"broadcast": {operation: services.OperationBundleBroadcast, run: broadcastBundle},
+	}},
+	"registry": {operation: "registry", subcommands: map[string]cliCommand{
+		"repair": {operation: services.OperationRegistryRepair, run: repairRegistryCommand},
+	}},
+	"keystore": {operation: "keystore", subcommands: map[string]cliCommand{
+		"new":    {operation: services.OperationKeystoreNew, run: newKeystoreCommand},
+		"import": {operation: services.OperationKeystoreImport, run: importKeystoreCommand},
+	}},
+	"serve": {operation: services.OperationServe, run: serveCommand},
+}
+
+// resolveCommand follows args down the subcommands of command and returns the command to
+// run with its remaining arguments
+func resolveCommand(command cliCommand, args []string) (cliCommand, []string, error) {
+	for command.run == nil {
+		if len(args) == 0 {
+			return command, args, usageError("expected %s", strings.Join(slices.Sorted(maps.Keys(command.subcommands)), ", "))
+		}
+		subcommand, ok := command.subcommands[args[0]]
+		if !ok {
+			return command, args, usageError("unknown subcommand %q", args[0])
+		}
+		command, args = subcommand, args[1:]
+	}
+	return command, args, nil
+}
+
+// runCLICommand runs a scripting subcommand and returns the exit code
+func runCLICommand(command cliCommand, args []string) int {
+	// Ctrl+C stops waiting for receipts, the airdrop journal and bundles keep what was sent
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	out := newCommandOutput()
+	command, args, err := resolveCommand(command, args)
+	if err == nil {
+		err = command.run(ctx, args, out)
+	}
+	if err == nil {
+		return exitOK
+	}
+
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		// the flag set already printed the problem and its flags
+		if out.json() {
+			out.Error(command.operation, err)
+		}
+		return exitUsage
+	case errors.Is(err, errUsage):
+		out.Error(command.operation, err)
+		if !out.json() {
+			fmt.Print("\n" + cliUsage)
+		}
+		return exitUsage
+	case errors.Is(err, errNotConfirmed):
+		out.Error(command.operation, err)
+		return exitNotConfirmed
+	default:
+		out.Error(command.operation, err)
+		return exitFailed
+	}
+}
+
+// errUsage wraps invalid arguments so they exit with exitUsage
+var errUsage = errors.New("invalid usage")
+
+// usageError reports invalid arguments
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// parseFlags parses the flags of a subcommand with --output, the flag set prints invalid
+// flags and -h with its flags so both are returned as flag.ErrHelp
+func parseFlags(flags *flag.FlagSet, args []string, out *commandOutput) error {
+	out.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	if err := out.validate(); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usageError("unexpected argument %q", flags.Arg(0))
+	}
+	return nil
+}
+
+// signingService creates a service for the active network signing with the configured key
+func signingService(ctx context.Context, stdin *bufio.Reader) (*services.NftService, services.NetworkProfile, error) {
+	service, network, err := onlineService()
+	if err != nil {
+		return nil, network, err
+	}
+	signer, err := loadSigner(ctx, stdin)
+	if err != nil {
+		service.Close()
+		return nil, network, err
+	}
+	service.SetSigner(signer)
+	return service, network, nil
+}
+
+// confirmSend asks before anything is sent unless yes is set. On a mainnet the network
+// name has to be typed like in the TUI.
+func confirmSend(stdin *bufio.Reader, out *commandOutput, yes bool, network services.NetworkProfile) error {
+	if yes {
+		return nil
+	}
+	if network.Mainnet {
+		out.Prompt("%s is a mainnet, transactions spend real funds. Type the network name to send: ", network.Name)
+		answer, _ := stdin.ReadString('\n')
+		if strings.TrimSpace(answer) != network.Name {
+			return errNotConfirmed
+		}
+		return nil
+	}
+	out.Prompt("Send? [y/N]: ")
+	answer, _ := stdin.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		return errNotConfirmed
+	}
+	return nil
+}
+
+// printTx prints a transaction hash with its explorer link on the network
+func printTx(out *commandOutput, network services.NetworkProfile, label string, txHash string) {
+	if url := network.TxURL(txHash); url != "" {
+		out.Printf("%s: %s  %s\n", label, txHash, url)
+		return
+	}
+	out.Printf("%s: %s\n", label, txHash)
+}
+
+// deployCommand deploys the contract of an artifact and records it like the TUI does
+func deployCommand(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("deploy", flag.ContinueOnError)
+	artifactPath := flags.String("artifact", "", "contract JSON file with contractName, bytecode and abi")
+	uri := flags.String("uri", "", "token URI passed to the constructor's URI parameter")
+	var constructorArgs stringList
+	flags.Var(&constructorArgs, "arg", "constructor argument, repeated in order, replaces --uri and the owner default")
+	value := flags.String("value", "", "wei sent to a payable constructor")
+	yes := flags.Bool("yes", false, "deploy without asking for confirmation")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+	if *artifactPath == "" {
+		return usageError("--artifact is required")
+	}
+
+	artifact, err := services.LoadAvailableContract(*artifactPath)
+	if err != nil {
+		return err
+	}
+	constructor, err := services.ConstructorOf(artifact.ABI)
+	if err != nil {
+		return err
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	service, network, err := signingService(ctx, stdin)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	out.setNetwork(network)
+	deployer, err := service.SignerAddress()
+	if err != nil {
+		return err
+	}
+
+	if len(constructorArgs) == 0 {
+		if constructorArgs, err = services.DefaultConstructorArgs(constructor, *uri, deployer); err != nil {
+			return usageError("%v, use --uri or --arg", err)
+		}
+	}
+	// 发送前先校验参数
+	if _, err := services.ParseABIArguments(constructor, constructorArgs); err != nil {
+		return usageError("%v", err)
+	}
+	var wei *big.Int
+	if *value != "" {
+		var ok bool
+		wei, ok = new(big.Int).SetString(*value, 10)
+		if !ok || wei.Sign() < 0 {
+			return usageError("invalid --value %q, expected wei", *value)
+		}
+	}
+
+	out.Printf("Deploy %s from %s on %s\n", artifact.ContractName, deployer, network.Name)
+	for i, input := range constructor.Inputs {
+		out.Printf("  %s (%s): %s\n", input.Name, input.Type, constructorArgs[i])
+	}
+	if err := confirmSend(stdin, out, *yes, network); err != nil {
+		return err
+	}
+
+	contract, err := services.NewContractCompiler("./artifacts").DeployArtifact(ctx, service, artifact, constructorArgs, wei, network.Name)
+	if err != nil {
+		return err
+	}
+	printTx(out, network, "Transaction", contract.DeployTx)
+	out.Printf("Contract deployed at %s in block %d\n", contract.Address, contract.DeployBlock)
+	out.Result(services.NewDeployResult(contract))
+	return nil
+}
+
+// airdropCommand mints a token to every address of a file, resuming the recorded airdrop
+// when an earlier run of the same airdrop did not finish
+func airdropCommand(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("airdrop", flag.ContinueOnError)
+	contract := flags.String("contract", "", "address of the NFT contract")
+	tokenID := flags.String("token-id", "", "token ID to mint")
+	recipientsFile := flags.String("recipients", "", "file with one recipient address per line")
+	uri := flags.String("uri", "", "token URI set before minting, empty keeps the current one")
+	yes := flags.Bool("yes", false, "send without asking for confirmation")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+	if *contract == "" || *tokenID == "" || *recipientsFile == "" {
+		return usageError("--contract, --token-id and --recipients are required")
+	}
+	if !common.IsHexAddress(*contract) {
+		return usageError("invalid --contract %q", *contract)
+	}
+
+	addresses, err := services.ReadAddressFile(*recipientsFile)
+	if err != nil {
+		return err
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	service, network, err := signingService(ctx, stdin)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	out.setNetwork(network)
+
+	// refuse before asking when the signer cannot mint
+	if err := service.CheckOwner(ctx, *contract); err != nil {
+		return err
+	}
+
+	// 重试同一次空投时从记录继续，避免重复发放
+	journal, resumed, err := service.ResumeOrPlanAirdrop(ctx, services.DefaultJournalPath, *contract, addresses, *tokenID, *uri)
+	if err != nil {
+		return err
+	}
+	if resumed {
+		out.Printf("Resuming the unfinished airdrop recorded in %s\n", services.DefaultJournalPath)
+	}
+
+	out.Printf("Airdrop token %s of %s to %d addresses in %d batches on %s\n", *tokenID, *contract, len(addresses), len(journal.Batches), network.Name)
+	if *uri != "" {
+		out.Printf("  set URI to %s first\n", *uri)
+	}
+	if err := confirmSend(stdin, out, *yes, network); err != nil {
+		return err
+	}
+
+	txHashes, err := service.RunAirdrop(ctx, journal, func(status services.BatchStatus) {
+		out.Result(services.NewBatchResult(journal, status))
+		if status.Err != nil {
+			out.Printf("Batch %d/%d failed: %v\n", status.Index, status.Total, status.Err)
+			return
+		}
+		printTx(out, network, fmt.Sprintf("Batch %d/%d (%d recipients)", status.Index, status.Total, status.Recipients), status.TxHash)
+	})
+	out.Result(services.NewAirdropResult(journal, txHashes, err))
+	return err
+}
+
+// setURICommand sets the metadata URI of a contract
+func setURICommand(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("set-uri", flag.ContinueOnError)
+	contract := flags.String("contract", "", "address of the NFT contract")
+	uri := flags.String("uri", "", "new token URI")
+	yes := flags.Bool("yes", false, "send without asking for confirmation")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+	if *contract == "" || *uri == "" {
+		return usageError("--contract and --uri are required")
+	}
+	if !common.IsHexAddress(*contract) {
+		return usageError("invalid --contract %q", *contract)
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	service, network, err := signingService(ctx, stdin)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	out.setNetwork(network)
+
+	// setURI 只有 owner 能调用，确认前先检查
+	if err := service.CheckOwner(ctx, *contract); err != nil {
+		return err
+	}
+	out.Printf("Set the URI of %s to %s on %s\n", *contract, *uri, network.Name)
+	if err := confirmSend(stdin, out, *yes, network); err != nil {
+		return err
+	}
+	txHash, err := service.CallContractFunction(ctx, services.SetURIParams(*contract, *uri))
+	if err != nil {
+		return err
+	}
+	printTx(out, network, "URI set", txHash)
+	out.Result(services.NewSetURIResult(common.HexToAddress(*contract).Hex(), *uri, txHash))
+	return nil
+}
+
+// inspectCommand prints the owner, batch size and URI of a contract and optionally the
+// balances of an account, nothing is signed
+func inspectCommand(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	contract := flags.String("contract", "", "address of the NFT contract")
+	account := flags.String("account", "", "account whose balances are read")
+	tokenIDs := flags.String("token-ids", "", "comma separated token IDs read for --account")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(*contract) {
+		return usageError("--contract must be an address")
+	}
+	if (*account == "") != (*tokenIDs == "") {
+		return usageError("--account and --token-ids go together")
+	}
+	if *account != "" && !common.IsHexAddress(*account) {
+		return usageError("invalid --account %q", *account)
+	}
+	var ids []*big.Int
+	for _, field := range strings.Split(*tokenIDs, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, ok := new(big.Int).SetString(field, 10)
+		if !ok || id.Sign() < 0 {
+			return usageError("invalid token ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+
+	service, network, err := onlineService()
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	out.setNetwork(network)
+
+	owner, err := service.Owner(ctx, *contract)
+	if err != nil {
+		return err
+	}
+	batchSize, err := service.BatchSize(ctx, *contract)
+	if err != nil {
+		return err
+	}
+	uri, err := service.URI(ctx, *contract, big.NewInt(0))
+	if err != nil {
+		return err
+	}
+	contractAddress := common.HexToAddress(*contract).Hex()
+	out.Printf("Contract:   %s\n", contractAddress)
+	out.Printf("Owner:      %s\n", owner)
+	out.Printf("Batch size: %d\n", batchSize)
+	out.Printf("URI:        %s\n", uri)
+
+	var balances []*big.Int
+	var accountAddress string
+	if len(ids) > 0 {
+		accountAddress = common.HexToAddress(*account).Hex()
+		accounts := make([]string, len(ids))
+		for i := range accounts {
+			accounts[i] = accountAddress
+		}
+		if balances, err = service.BalanceOfBatch(ctx, *contract, accounts, ids); err != nil {
+			return err
+		}
+		out.Printf("Balances of %s:\n", accountAddress)
+		for i, id := range ids {
+			out.Printf("  token %s: %s\n", id, balances[i])
+		}
+	}
+	out.Result(services.NewInspectResult(contractAddress, owner, batchSize, uri, accountAddress, ids, balances))
+	return nil
+}
+
+// contractsListCommand lists the registry, by default only the contracts of the active network
+func contractsListCommand(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("contracts list", flag.ContinueOnError)
+	all := flags.Bool("all", false, "list the contracts of every network")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+
+	var chainID uint64
+	if !*all {
+		_, network, err := loadNetwork()
+		if err != nil {
+			return err
+		}
+		// without a pinned chain ID the node tells which chain is active
+		if network.ChainID == 0 {
+			service, _, err := onlineService()
+			if err != nil {
+				return err
+			}
+			defer service.Close()
+			reported, err := service.VerifyChain(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read the chain ID of the node: %v", err)
+			}
+			network.ChainID = reported.Uint64()
+		}
+		chainID = network.ChainID
+		out.setNetwork(network)
+	}
+	contracts, err := services.NewContractCompiler("./artifacts").GetContractsOnChain(chainID)
+	if err != nil {
+		return err
+	}
+
+	for _, contract := range contracts {
+		chain := "unknown chain"
+		if contract.ChainID != 0 {
+			chain = fmt.Sprintf("chain %d", contract.ChainID)
+			if contract.Network != "" {
+				chain = fmt.Sprintf("%s (chain %d)", contract.Network, contract.ChainID)
+			}
+		}
+		name := contract.ContractName
+		if name == "" {
+			name = "-"
+		}
+		line := fmt.Sprintf("%s  %-12s  %s  %s", contract.Address, name, chain, contract.DeployTime.Format(time.RFC3339))
+		if contract.Imported {
+			line += "  imported"
+		}
+		out.Printf("%s\n", line)
+	}
+	out.Result(services.NewContractsResult(contracts))
+	return nil
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+func TestCommandOutputJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	out := &commandOutput{format: outputJSON, stdout: &stdout, stderr: &stderr}
+
+	out.Printf("text only\n")
+	out.Prompt("Send? [y/N]: ")
+	out.Error(services.OperationSetURI, errNotConfirmed)
+	assert.Equal(t, "Send? [y/N]: ", stderr.String())
+
+	var result services.Result
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+	assert.Equal(t, services.ResultVersion, result.Version)
+	assert.Equal(t, services.OperationSetURI, result.Operation)
+	assert.Equal(t, services.ErrorCodeNotConfirmed, result.Error.Code)
+
+	// a failed result already printed is not repeated for the returned error
+	stdout.Reset()
+	out.setNetwork(services.NetworkProfile{Name: "hardhat", ChainID: 31337})
+	err := &services.TxRevertedError{TxHash: "0xbbb"}
+	out.Result(services.NewErrorResult(services.OperationAirdrop, err))
+	out.Error(services.OperationAirdrop, err)
+	assert.Equal(t, 1, bytes.Count(stdout.Bytes(), []byte("\n")))
+	assert.Contains(t, stdout.String(), `"network":"hardhat","chainId":31337`)
+}
+
+func TestResolveCommand(t *testing.T) {
+	command, args, err := resolveCommand(cliCommands["bundle"], []string{"prepare", "airdrop", "--from", "0x1"})
+	require.NoError(t, err)
+	assert.Equal(t, services.OperationBundlePrepare, command.operation)
+	assert.Equal(t, []string{"--from", "0x1"}, args)
+
+	command, args, err = resolveCommand(cliCommands["registry"], []string{"repair"})
+	require.NoError(t, err)
+	assert.Equal(t, services.OperationRegistryRepair, command.operation)
+	assert.Empty(t, args)
+
+	// a missing or unknown subcommand is invalid usage
+	_, _, err = resolveCommand(cliCommands["keystore"], nil)
+	assert.ErrorIs(t, err, errUsage)
+	assert.ErrorContains(t, err, "expected import, new")
+	_, _, err = resolveCommand(cliCommands["bundle"], []string{"prepare", "mint"})
+	assert.ErrorIs(t, err, errUsage)
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// Output formats of the scripting subcommands
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// commandOutput prints what a command did, as lines of text or, with --output json, as
+// one services.Result object per line. Prompts go to stderr so stdout stays parseable.
+type commandOutput struct {
+	format string
+	// network stamps the results once the command knows it
+	network *services.NetworkProfile
+	// failed is set once a failed result was printed, the error is not printed again
+	failed bool
+
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// newCommandOutput creates a text output on the standard streams
+func newCommandOutput() *commandOutput {
+	return &commandOutput{format: outputText, stdout: os.Stdout, stderr: os.Stderr}
+}
+
+// register adds the --output flag to flags
+func (o *commandOutput) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.format, "output", outputText, "text, or json for one versioned result object per line")
+}
+
+// validate rejects an unknown --output
+func (o *commandOutput) validate() error {
+	if o.format != outputText && o.format != outputJSON {
+		return usageError("unknown --output %q, expected text or json", o.format)
+	}
+	return nil
+}
+
+// json reports whether results are printed as JSON
+func (o *commandOutput) json() bool {
+	return o.format == outputJSON
+}
+
+// setNetwork stamps every following result with network
+func (o *commandOutput) setNetwork(network services.NetworkProfile) {
+	o.network = &network
+}
+
+// Printf prints a line of the text output, nothing in JSON mode
+func (o *commandOutput) Printf(format string, args ...any) {
+	if !o.json() {
+		fmt.Fprintf(o.stdout, format, args...)
+	}
+}
+
+// Prompt asks a question on stderr
+func (o *commandOutput) Prompt(format string, args ...any) {
+	fmt.Fprintf(o.stderr, format, args...)
+}
+
+// Result prints result in JSON mode, nothing in text mode
+func (o *commandOutput) Result(result services.Result) {
+	if o.network != nil {
+		result = result.WithNetwork(o.network.Name, o.network.ChainID)
+	}
+	if !result.OK {
+		o.failed = true
+	}
+	if !o.json() {
+		return
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		fmt.Fprintf(o.stderr, "Error: failed to encode result: %v\n", err)
+		return
+	}
+	fmt.Fprintln(o.stdout, string(data))
+}
+
+// Error reports the error a command of operation returned, unless a failed result already
+// described it
+func (o *commandOutput) Error(operation string, err error) {
+	if !o.json() {
+		fmt.Fprintf(o.stdout, "Error: %v\n", err)
+		return
+	}
+	if o.failed {
+		return
+	}
+	result := services.NewErrorResult(operation, err)
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		result.Error.Code = services.ErrorCodeUsage
+	case errors.Is(err, errNotConfirmed):
+		result.Error.Code = services.ErrorCodeNotConfirmed
+	}
+	o.Result(result)
+}
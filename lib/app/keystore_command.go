@@ -0,0 +1,108 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// defaultKeystoreDir is where keystore files are written unless --dir is given
+const defaultKeystoreDir = "keystore"
+
+// newKeystoreCommand stores a new key encrypted with a prompted passphrase
+func newKeystoreCommand(ctx context.Context, args []string, out *commandOutput) error {
+	return keystoreCommand(args, out, "keystore new", services.OperationKeystoreNew, createKeystore)
+}
+
+// importKeystoreCommand encrypts a prompted private key with a prompted passphrase
+func importKeystoreCommand(ctx context.Context, args []string, out *commandOutput) error {
+	return keystoreCommand(args, out, "keystore import", services.OperationKeystoreImport, importKeystore)
+}
+
+// keystoreCommand writes the keystore file made by create to --dir and prints where it went
+func keystoreCommand(args []string, out *commandOutput, name string, operation string, create func(*bufio.Reader, string) (accounts.Account, error)) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	dir := flags.String("dir", defaultKeystoreDir, "directory the keystore file is written to")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+
+	account, err := create(bufio.NewReader(os.Stdin), *dir)
+	if err != nil {
+		return err
+	}
+	out.Printf("Address: %s\n", account.Address.Hex())
+	out.Printf("Keystore: %s\n", account.URL.Path)
+	out.Printf("Set KEYSTORE_PATH to this file in .env and remove PRIVATE_KEY and PASSWORD\n")
+	out.Result(services.NewKeystoreResult(operation, account.Address.Hex(), account.URL.Path))
+	return nil
+}
+
+// createKeystore asks for a passphrase and stores a new key encrypted with it
+func createKeystore(stdin *bufio.Reader, dir string) (accounts.Account, error) {
+	passphrase, err := readNewPassphrase(stdin)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	return services.CreateKeystore(dir, passphrase)
+}
+
+// importKeystore asks for a private key and a passphrase and stores the key encrypted with it
+func importKeystore(stdin *bufio.Reader, dir string) (accounts.Account, error) {
+	privateKey, err := readSecret(stdin, "Private key: ")
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	passphrase, err := readNewPassphrase(stdin)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	return services.ImportKeystore(dir, privateKey, passphrase)
+}
+
+// readNewPassphrase asks for a passphrase twice and checks both entries match
+func readNewPassphrase(stdin *bufio.Reader) (string, error) {
+	passphrase, err := readSecret(stdin, "Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+	confirm, err := readSecret(stdin, "Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// readSecret prompts on stderr for a line without echoing it on a terminal, piped input
+// is read as is. stdout stays free for the output of the command.
+func readSecret(stdin *bufio.Reader, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if term.IsTerminal(os.Stdin.Fd()) {
+		secret, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(secret)), nil
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
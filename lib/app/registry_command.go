@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"flag"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// repairRegistryCommand restores deployed_contracts.json from its newest readable backup
+func repairRegistryCommand(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("registry repair", flag.ContinueOnError)
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+
+	result, err := services.RepairRegistry(services.DeployedContractsPath)
+	if err != nil {
+		return err
+	}
+	if result.Healthy {
+		out.Printf("%s is readable, nothing to repair\n", services.DeployedContractsPath)
+	} else {
+		out.Printf("Moved the corrupt registry to %s\n", result.CorruptPath)
+		out.Printf("Restored %d contracts from %s\n", result.Contracts, result.Backup)
+	}
+	out.Result(services.NewRepairResult(services.DeployedContractsPath, result))
+	return nil
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/server"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// serveShutdownTimeout bounds waiting for requests in flight when the server stops
+const serveShutdownTimeout = 30 * time.Second
+
+// serveCommand unlocks the signer, checks the chain and serves the API until interrupted
+func serveCommand(ctx context.Context, args []string, out *commandOutput) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:8080", "address to listen on, keep it local or behind a TLS proxy")
+	if err := parseFlags(flags, args, out); err != nil {
+		return err
+	}
+
+	token := os.Getenv("SERVE_TOKEN")
+	if len(token) < 16 {
+		return fmt.Errorf("SERVE_TOKEN must be set to a secret of at least 16 characters")
+	}
+
+	service, network, err := signingService(ctx, bufio.NewReader(os.Stdin))
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	out.setNetwork(network)
+	if _, err := service.VerifyChain(ctx); err != nil {
+		return err
+	}
+	signer, err := service.SignerAddress()
+	if err != nil {
+		return err
+	}
+
+	api := server.New(service, services.NewContractCompiler("./artifacts"), network, token, server.DefaultJournalDir)
+	httpServer := &http.Server{
+		Handler:           api.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	url := "http://" + listener.Addr().String()
+	out.Printf("Serving %s as %s on %s\n", network.Name, signer, url)
+	out.Result(services.NewServeResult(url, signer))
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.Serve(listener)
+	}()
+	select {
+	case err := <-errs:
+		api.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	// 停止接收请求，正在发送的空投由记录文件保留进度
+	out.Printf("Shutting down, unfinished airdrops resume from %s when posted again\n", server.DefaultJournalDir)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	err = httpServer.Shutdown(shutdownCtx)
+	api.Close()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
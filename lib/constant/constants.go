@@ -13,6 +13,13 @@ const (
 	ConfirmPage        Page = "ConfirmPage"
 	CheckTotalPage     Page = "CheckTotalPage"
 	SelectContractPage Page = "SelectContractPage"
+	ResumeAirdropPage  Page = "ResumeAirdropPage"
+	InspectPage        Page = "InspectPage"
+	ContractCallPage   Page = "ContractCallPage"
+	NetworkPage        Page = "NetworkPage"
+	ImportContractPage Page = "ImportContractPage"
+	ContractAdminPage  Page = "ContractAdminPage"
+	PendingTxPage      Page = "PendingTxPage"
 )
 
 // Common constants
@@ -24,14 +31,36 @@ const MaxURLLength = 255  // Maximum URL length
 
 // Menu options
 var (
-	MainMenuChoices   = []string{"Deploy Contract", "AirDrop NFT", "Check Total NFT"}
-	DeployMenuChoices = []string{"Deploy new Contract(ERC1155)", "Check Existing Contracts"}
+	MainMenuChoices        = []string{"Deploy Contract", "AirDrop NFT", "Check Total NFT", "Inspect Contract", "Call Contract Function", "Switch Network", "Contract Admin", "Pending Transactions"}
+	DeployMenuChoices      = []string{"Deploy new Contract(ERC1155)", "Check Existing Contracts", "Import Existing Contract"}
+	AdminActionChoices     = []string{"Transfer Ownership", "Renounce Ownership"}
+	PendingTxActionChoices = []string{"Speed Up", "Cancel"}
 )
 
 // Input modes
 const (
 	NFTInputMode = "nft"
 	URLInputMode = "url"
+
+	// Inspect page
+	ContractSelectMode = "contract"
+	AccountInputMode   = "account"
+	TokenIDsInputMode  = "ids"
+	OperatorInputMode  = "operator"
+
+	// Contract call page
+	FunctionSelectMode = "function"
+	ArgsInputMode      = "args"
+
+	// Contract admin page
+	AdminActionMode     = "action"
+	NewOwnerInputMode   = "new_owner"
+	NewOwnerConfirmMode = "new_owner_confirm"
+	RenounceConfirmMode = "renounce"
+
+	// Pending transactions page
+	TxSelectMode = "tx"
+	TxActionMode = "tx_action"
 )
 
 type KeyboardKey string
@@ -50,6 +79,9 @@ const (
 	KeyDown      KeyboardKey = "down"
 	KeyBackspace KeyboardKey = "backspace"
 	KeyEnter     KeyboardKey = "enter"
+	KeyDiscard   KeyboardKey = "d"
+	KeyDryRun    KeyboardKey = "s"
+	KeyRefresh   KeyboardKey = "r"
 )
 
 // UI Messages
@@ -67,6 +99,13 @@ const (
 	CheckTotalPageTitle       = "查看 NFT 总量页面"
 	DeployContractPageTitle   = "部署新合约"
 	DeployedContractPageTitle = "已部署的合约页面"
+	ResumeAirdropPageTitle    = "发现未完成的空投"
+	InspectPageTitle          = "查询合约"
+	ContractCallPageTitle     = "调用合约函数"
+	NetworkPageTitle          = "切换网络"
+	ImportContractPageTitle   = "导入已有合约"
+	ContractAdminPageTitle    = "合约管理"
+	PendingTxPageTitle        = "待确认交易"
 
 	// Common UI elements
 	MainMenuFooter    = "主菜单."
@@ -83,6 +122,62 @@ const (
 	WrongPasswordError = "密码错误"
 	LoginSuccess       = "登录成功！"
 	NoDeployedContract = "暂无已部署的合约"
+
+	// Airdrop journal
+	UnfinishedAirdropError = "存在未完成的空投（合约 %s），请重启程序继续或放弃该空投"
+	JournalMissingError    = "空投记录 %s 已不存在，可能已在其他地方完成或放弃"
+
+	// Inspect page
+	InvalidAddressError  = "无效的地址: %s"
+	InvalidTokenIDsError = "无效的 NFT 编号: %s"
+
+	// Contract call page
+	NoContractFunctions = "合约 ABI 中没有可调用的函数"
+	InvalidValueError   = "无效的 ETH 数量（wei）: %s"
+
+	// Dry run
+	DryRunBanner   = "【模拟模式】只用 eth_call 预演交易，不会签名或发送"
+	DryRunToggle   = "按 s 切换模拟模式"
+	SimulateFailed = "模拟交易失败"
+
+	// Networks
+	NetworkBanner          = "网络: %s（chain ID %s）"
+	ChainIDUnpinned        = "未固定"
+	UnassignedChain        = "[所在链未知]"
+	MainnetBanner          = "⚠ 主网：交易会花费真实资金"
+	NoNetworksFile         = "未找到网络配置文件 %s，当前使用 RPC_URL"
+	NetworkSwitched        = "已切换到网络 %s（chain ID %s）"
+	NetworkVerifyFailed    = "网络校验失败"
+	MainnetConfirmPrompt   = "网络 %s 是主网，交易会花费真实资金。\n请输入网络名称并按 Enter 确认发送："
+	MainnetConfirmMismatch = "网络名称不匹配，交易未发送"
+
+	// Import contract
+	ImportAddressPrompt = "请输入已部署合约的地址，按 Enter 导入："
+	ImportedMarker      = "[导入]"
+	ImportSuccess       = "已导入合约 %s（%s）"
+	ImportFailed        = "导入合约失败"
+
+	// Contract admin
+	NotOwnerError          = "当前签名账户 %s 不是合约 %s 的 owner（%s），请换用 owner 账户"
+	NewOwnerPrompt         = "请输入新 owner 地址："
+	NewOwnerConfirmPrompt  = "请再次输入新 owner 地址确认："
+	NewOwnerMismatch       = "两次输入的地址不一致，请重新输入"
+	NewOwnerIsCurrentOwner = "新 owner 与当前 owner 相同"
+	RenouncePhrase         = "RENOUNCE OWNERSHIP"
+	RenouncePrompt         = "放弃所有权后任何人都无法再 mint 或修改 URI，且无法撤销。\n请输入 %s 并按 Enter 确认："
+	RenounceMismatch       = "确认短语不匹配，交易未发送"
+	OwnershipTransferred   = "所有权已转移给 %s，交易: %s"
+	OwnershipRenounced     = "已放弃合约所有权，交易: %s"
+
+	// Pending transactions
+	NoPendingTx          = "没有待确认的交易"
+	PendingTxRefreshHint = "按 r 在链上核对交易状态"
+	PendingTxHelp        = "加速：用相同 nonce 重新发送，手续费至少提高 %d%%\n取消：用相同 nonce 向自己发送 0 ETH，原交易将不会执行"
+	PendingTxDryRunError = "模拟模式下不能加速或取消交易"
+	PendingTxSpedUp      = "已加速交易 %s，新交易: %s"
+	PendingTxCancelSent  = "已为交易 %s 发送取消交易: %s"
+	PendingTxRefreshed   = "交易状态已核对，%d 笔待确认"
+	PendingTxHint        = "可在 Pending Transactions 页面加速或取消"
 )
 
 // UI Messages - Additional
@@ -99,4 +194,6 @@ const (
 	BackToPrevious   = "按 ESC 返回上一页"
 	ReturnToNFTInput = "按 ESC 重新输入 NFT 编号"
 	ConfirmAirdrop   = "按 Enter 确认空投"
+	EstimatingGas    = "正在预估 Gas..."
+	CancelInFlight   = "按 ESC 取消，已发送的交易可能仍会上链"
 )
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// airdropBatchMsg reports that one mint batch of the airdrop in flight finished
+type airdropBatchMsg struct {
+	status services.BatchStatus
+	result *services.Result
+}
+
+// airdropResultMsg reports the outcome of the airdrop in flight
+type airdropResultMsg struct {
+	txHashes []string
+	err      error
+	// result describes the airdrop for the log
+	result *services.Result
+}
+
+// runAirdrop runs journal in the background, reporting each batch and finally the result on updates
+func runAirdrop(ctx context.Context, nftService *services.NftService, journal *services.AirdropJournal, updates chan<- tea.Msg) {
+	txHashes, err := nftService.RunAirdrop(ctx, journal, func(status services.BatchStatus) {
+		updates <- airdropBatchMsg{status: status, result: networkResult(services.NewBatchResult(journal, status))}
+	})
+	result := networkResult(services.NewAirdropResult(journal, txHashes, err))
+	if err != nil {
+		err = describeTxError("发送 NFT 失败", err)
+		if len(txHashes) > 0 {
+			err = fmt.Errorf("%v\n已完成的批次交易哈希:\n%s", err, strings.Join(txHashes, "\n"))
+		}
+		updates <- airdropResultMsg{txHashes: txHashes, err: err, result: result}
+		return
+	}
+	updates <- airdropResultMsg{txHashes: txHashes, result: result}
+}
+
+// waitForAirdrop delivers the next progress message of the airdrop in flight
+func waitForAirdrop(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
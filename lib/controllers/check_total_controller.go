@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,27 +15,75 @@ import (
 type CheckTotalController struct {
 	nftService       *services.NftService
 	contractCompiler *services.ContractCompiler
+	// cacheDir holds the per-contract log scan cursors
+	cacheDir string
+
+	// cancel aborts the scan in flight, nil when nothing is being scanned
+	cancel context.CancelFunc
+	// scanErrs holds the error of the last scan per contract address
+	scanErrs map[string]error
+	// reports holds the result of the last scan per contract address, up to the head while
+	// the cache only holds confirmed blocks
+	reports map[string]*services.SupplyReport
 }
 
 // NewCheckTotalController creates a new check total controller
 func NewCheckTotalController(
 	nftService *services.NftService,
 	contractCompiler *services.ContractCompiler,
+	cacheDir string,
 ) *CheckTotalController {
 	return &CheckTotalController{
 		nftService:       nftService,
 		contractCompiler: contractCompiler,
+		cacheDir:         cacheDir,
+		scanErrs:         map[string]error{},
+		reports:          map[string]*services.SupplyReport{},
 	}
 }
 
+// supplyScanMsg reports the outcome of scanning every deployed contract
+type supplyScanMsg struct {
+	reports map[string]*services.SupplyReport
+	errs    map[string]error
+}
+
 // Update handles the check total page updates
 func (c *CheckTotalController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
 
 	switch msg := msg.(type) {
+	case supplyScanMsg:
+		c.cancel = nil
+		c.scanErrs = msg.errs
+		c.reports = msg.reports
+		model.Loading = false
+		return model, nil
+
 	case tea.KeyMsg:
 		key := constant.KeyboardKey(msg.String())
 
+		// 扫描中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
+			}
+			return model, nil
+		}
+
 		switch key {
+		case constant.KeyEnter:
+			contracts, err := c.contractCompiler.GetContractsOnChain(types.GlobalState.ChainID)
+			if err != nil {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf("获取合约信息失败: %v", err)}
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			c.cancel = cancel
+			model.Loading = true
+			return model, c.scan(ctx, contracts)
+
 		case constant.KeyEsc:
 			return model, func() tea.Msg {
 				return types.ChangePageMsg{Page: constant.DeployPage}
@@ -45,13 +94,43 @@ func (c *CheckTotalController) Update(model types.AppModel, msg tea.Msg) (interf
 	return model, nil
 }
 
+// scan refreshes the supply of every contract in the background, the confirmed blocks land in the cache
+func (c *CheckTotalController) scan(ctx context.Context, contracts []services.DeployedContract) tea.Cmd {
+	return func() tea.Msg {
+		reports, errs := map[string]*services.SupplyReport{}, map[string]error{}
+		for _, contract := range contracts {
+			report, err := c.nftService.ScanSupply(ctx, c.cacheDir, contract.Address, contract.DeployBlock)
+			if err != nil {
+				errs[contract.Address] = err
+				continue
+			}
+			reports[contract.Address] = report
+		}
+		return supplyScanMsg{reports: reports, errs: errs}
+	}
+}
+
 // View renders the check total page
 func (c *CheckTotalController) View() string {
-	contracts, err := c.contractCompiler.GetDeployedContracts()
+	contracts, err := c.contractCompiler.GetContractsOnChain(types.GlobalState.ChainID)
 	if err != nil {
 		return fmt.Sprintf("获取合约信息失败: %v\n", err)
 	}
-	return views.CheckTotalView(contracts)
+
+	// 显示本次扫描或缓存中的统计结果，按 Enter 从链上刷新
+	reports := make([]*services.SupplyReport, len(contracts))
+	errs := make([]error, len(contracts))
+	for i, contract := range contracts {
+		if report := c.reports[contract.Address]; report != nil {
+			reports[i] = report
+		} else {
+			reports[i], errs[i] = services.CachedSupply(c.cacheDir, contract.Address)
+		}
+		if scanErr := c.scanErrs[contract.Address]; scanErr != nil {
+			errs[i] = scanErr
+		}
+	}
+	return views.CheckTotalView(contracts, reports, errs, c.cancel != nil)
 }
 
 func (c *CheckTotalController) Name() constant.Page {
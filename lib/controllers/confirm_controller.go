@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
@@ -17,6 +21,45 @@ type ConfirmController struct {
 	walletAddresses []string
 	nftID           string
 	uri             string
+
+	// cancel aborts the airdrop in flight, nil when nothing is being sent
+	cancel context.CancelFunc
+	// updates carries batch progress and the final result of the airdrop in flight
+	updates chan tea.Msg
+	// batches holds the status of every mint batch sent so far
+	batches []services.BatchStatus
+	// simulation holds the report of the last dry run
+	simulation []services.SimulationResult
+	// guard asks for the network name before sending on a mainnet
+	guard mainnetGuard
+
+	// gas estimates shown before anything is signed, refreshed when estimateKey changes
+	estimateKey string
+	estimating  bool
+	setURIGas   uint64
+	mintGas     []uint64
+	estimateErr error
+}
+
+// confirmEstimateTimeout bounds the owner check and gas estimates of the confirm page, so
+// a slow node only delays the estimate and never the page
+const confirmEstimateTimeout = 30 * time.Second
+
+// estimateRequestMsg asks the confirm page to estimate the airdrop it was opened for
+type estimateRequestMsg struct{}
+
+// estimateResultMsg carries the gas estimates of the airdrop inputs named by key
+type estimateResultMsg struct {
+	key       string
+	setURIGas uint64
+	mintGas   []uint64
+	err       error
+}
+
+// simulationResultMsg reports the outcome of a dry run started from the confirm page
+type simulationResultMsg struct {
+	results []services.SimulationResult
+	err     error
 }
 
 // NewConfirmController creates a new confirm controller
@@ -54,35 +97,79 @@ func (c *ConfirmController) Update(model types.AppModel, msg tea.Msg) (interface
 	c.uri = types.GlobalState.TokenURI
 
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		key := constant.KeyboardKey(msg.String())
+	case estimateRequestMsg:
+		return model, c.refreshEstimate()
 
-		switch key {
-		case constant.KeyEnter:
-			// Set the URI first
-			if err := c.nftService.SetURI(c.contractAddress, c.uri); err != nil {
-				return model, func() tea.Msg {
-					return types.ErrorMsg{Err: fmt.Errorf("设置 URI 失败: %v", err)}
-				}
+	case estimateResultMsg:
+		// 输入在预估期间变了，结果已过期
+		if msg.key != c.estimateKey {
+			return model, nil
+		}
+		c.estimating = false
+		c.setURIGas, c.mintGas, c.estimateErr = msg.setURIGas, msg.mintGas, msg.err
+		return model, nil
+
+	case airdropBatchMsg:
+		c.batches = append(c.batches, msg.status)
+		model.Logger.LogResult(*msg.result)
+		if msg.status.Err == nil {
+			model.Logger.Log("INFO", fmt.Sprintf("第 %d/%d 批 NFT 发送成功，交易哈希: %s", msg.status.Index, msg.status.Total, msg.status.TxHash))
+		}
+		return model, waitForAirdrop(c.updates)
+
+	case simulationResultMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: describeTxError(constant.SimulateFailed, msg.err)}
 			}
+		}
+		c.simulation = msg.results
+		return model, nil
 
-			// Send NFTs to addresses
-			txHash, err := c.nftService.MintNFTToAddresses(c.contractAddress, c.walletAddresses, c.nftID)
-			if err != nil {
-				return model, func() tea.Msg {
-					return types.ErrorMsg{Err: fmt.Errorf("发送 NFT 失败: %v", err)}
-				}
+	case airdropResultMsg:
+		c.cancel = nil
+		c.updates = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: msg.err, Result: msg.result}
 			}
+		}
 
-			model.Logger.Log("INFO", fmt.Sprintf("NFT 发送成功，交易哈希: %s", txHash))
+		model.Logger.Log("INFO", fmt.Sprintf("NFT 发送成功，交易哈希: %s", strings.Join(msg.txHashes, ", ")))
 
-			// 添加成功消息
-			successMsg := fmt.Sprintf("NFT 发送成功！交易哈希: %s", txHash)
-			types.GlobalState.SendNFTStat = true
+		// 添加成功消息
+		successMsg := fmt.Sprintf("NFT 发送成功！共 %d 笔交易，交易哈希:\n%s", len(msg.txHashes), views.TxLinks(msg.txHashes))
+		types.GlobalState.SendNFTStat = true
 
-			return model, func() tea.Msg {
-				return types.SuccessMsg{Message: successMsg}
+		return model, func() tea.Msg {
+			return types.SuccessMsg{Message: successMsg, Result: msg.result}
+		}
+
+	case tea.KeyMsg:
+		key := constant.KeyboardKey(msg.String())
+
+		// 发送中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
 			}
+			return model, nil
+		}
+		if c.guard.Active() {
+			return c.guard.Update(model, key)
+		}
+
+		switch key {
+		case constant.KeyDryRun:
+			types.GlobalState.DryRun = !types.GlobalState.DryRun
+			c.simulation = nil
+			return model, nil
+
+		case constant.KeyEnter:
+			return c.guard.Guard(model, types.GlobalState.DryRun, c.start)
 
 		case constant.KeyEsc:
 			return model, func() tea.Msg {
@@ -94,9 +181,98 @@ func (c *ConfirmController) Update(model types.AppModel, msg tea.Msg) (interface
 	return model, nil
 }
 
+// start sends the airdrop in the background, or only simulates it in dry-run mode
+func (c *ConfirmController) start(model types.AppModel) (interface{}, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.batches = nil
+	c.simulation = nil
+	model.Loading = true
+
+	// 模拟模式下只预演交易，不签名也不发送
+	if types.GlobalState.DryRun {
+		contractAddress, addresses, nftID, uri := c.contractAddress, c.walletAddresses, c.nftID, c.uri
+		return model, func() tea.Msg {
+			results, err := c.nftService.SimulateAirdrop(ctx, contractAddress, addresses, nftID, uri)
+			return simulationResultMsg{results: results, err: err}
+		}
+	}
+
+	c.updates = make(chan tea.Msg)
+
+	go c.sendAirdrop(ctx, c.updates, c.contractAddress, c.walletAddresses, c.nftID, c.uri)
+	return model, waitForAirdrop(c.updates)
+}
+
+// sendAirdrop resumes the unfinished journal of the same airdrop or plans a new one,
+// then runs it, reporting each batch and finally the result on updates
+func (c *ConfirmController) sendAirdrop(ctx context.Context, updates chan<- tea.Msg, contractAddress string, addresses []string, nftID string, uri string) {
+	// 重试同一次空投时从记录继续，避免重复发放
+	journal, _, err := c.nftService.ResumeOrPlanAirdrop(ctx, services.DefaultJournalPath, contractAddress, addresses, nftID, uri)
+	if err != nil {
+		result := networkResult(services.NewErrorResult(services.OperationAirdrop, err))
+		var unfinished *services.UnfinishedAirdropError
+		if errors.As(err, &unfinished) {
+			err = fmt.Errorf(constant.UnfinishedAirdropError, unfinished.ContractAddress)
+		} else {
+			err = describeTxError("发送 NFT 失败", err)
+		}
+		updates <- airdropResultMsg{err: err, result: result}
+		return
+	}
+
+	runAirdrop(ctx, c.nftService, journal, updates)
+}
+
 // View renders the confirm page
 func (c *ConfirmController) View() string {
-	return views.ConfirmView(types.GlobalState.UploadWalletAddresses)
+	if c.guard.Active() {
+		return c.guard.View()
+	}
+
+	estimateErr := ""
+	if c.estimateErr != nil {
+		estimateErr = c.estimateErr.Error()
+	}
+	return views.ConfirmView(types.GlobalState.UploadWalletAddresses, c.estimating, c.setURIGas, c.mintGas, estimateErr, c.batches, c.simulation, types.GlobalState.DryRun, c.cancel != nil)
+}
+
+// refreshEstimate estimates the setURI and mint transactions for the current airdrop
+// inputs in the background, only hitting the node again when the inputs changed
+func (c *ConfirmController) refreshEstimate() tea.Cmd {
+	state := types.GlobalState
+	key := strings.Join([]string{
+		state.SelectedContract,
+		state.TokenURI,
+		state.NFTID,
+		strings.Join(state.UploadWalletAddresses, ","),
+	}, "|")
+	if key == c.estimateKey {
+		return nil
+	}
+	c.estimateKey = key
+	c.estimating = true
+	c.setURIGas, c.mintGas, c.estimateErr = 0, nil, nil
+
+	contractAddress, uri, nftID, addresses := state.SelectedContract, state.TokenURI, state.NFTID, state.UploadWalletAddresses
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), confirmEstimateTimeout)
+		defer cancel()
+
+		// setURI 与 mint 只有 owner 能调用，签名账户不是 owner 时直接提示
+		if err := c.nftService.CheckOwner(ctx, contractAddress); err != nil {
+			return estimateResultMsg{key: key, err: describeTxError("无法空投", err)}
+		}
+		setURIGas, err := c.nftService.EstimateSetURI(ctx, contractAddress, uri)
+		if err != nil {
+			return estimateResultMsg{key: key, err: fmt.Errorf("预估 setURI gas 失败: %v", err)}
+		}
+		mintGas, err := c.nftService.EstimateMintNFTToAddresses(ctx, contractAddress, addresses, nftID)
+		if err != nil {
+			return estimateResultMsg{key: key, err: fmt.Errorf("预估 mint gas 失败: %v", err)}
+		}
+		return estimateResultMsg{key: key, setURIGas: setURIGas, mintGas: mintGas}
+	}
 }
 
 func (c *ConfirmController) Name() constant.Page {
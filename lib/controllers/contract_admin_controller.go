@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethereum/go-ethereum/common"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
+)
+
+// ContractAdminController handles the contract admin page, it shows who owns a deployed
+// contract and transfers or renounces its ownership
+type ContractAdminController struct {
+	nftService      *services.NftService
+	contractService *services.ContractCompiler
+	model           *models.ContractAdminModel
+
+	// cancel aborts the transaction in flight, nil when nothing is running
+	cancel context.CancelFunc
+	// guard asks for the network name before sending a transaction on a mainnet
+	guard mainnetGuard
+}
+
+// adminOwnerMsg carries the owner of the selected contract and the active signer
+type adminOwnerMsg struct {
+	owner  string
+	signer string
+	err    error
+}
+
+// adminResultMsg reports the outcome of a transfer or renounce started from the admin page
+type adminResultMsg struct {
+	// newOwner is empty when ownership was renounced
+	newOwner   string
+	txHash     string
+	simulation []services.SimulationResult
+	err        error
+	// result describes the sent transaction for the log, nil in dry-run mode
+	result *services.Result
+}
+
+// NewContractAdminController creates a new contract admin controller
+func NewContractAdminController(nftService *services.NftService, contractService *services.ContractCompiler, model *models.ContractAdminModel) *ContractAdminController {
+	return &ContractAdminController{
+		nftService:      nftService,
+		contractService: contractService,
+		model:           model,
+	}
+}
+
+// Update handles the contract admin page updates
+func (c *ContractAdminController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
+	switch msg := msg.(type) {
+	case adminOwnerMsg:
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf("读取合约 owner 失败: %v", msg.err)}
+			}
+		}
+		c.model.Owner, c.model.Signer = msg.owner, msg.signer
+		return model, nil
+
+	case adminResultMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: msg.err, Result: msg.result}
+			}
+		}
+		c.model.Simulation = msg.simulation
+		if msg.txHash == "" {
+			return model, nil
+		}
+
+		c.model.InputMode = constant.AdminActionMode
+		message := fmt.Sprintf(constant.OwnershipRenounced, views.TxLinks([]string{msg.txHash}))
+		if msg.newOwner != "" {
+			message = fmt.Sprintf(constant.OwnershipTransferred, msg.newOwner, views.TxLinks([]string{msg.txHash}))
+		}
+		model.Loading = true
+		return model, tea.Batch(
+			func() tea.Msg {
+				return types.SuccessMsg{Message: message, Result: msg.result}
+			},
+			c.readOwner(c.model.ContractAddress),
+		)
+
+	case tea.KeyMsg:
+		key := constant.KeyboardKey(msg.String())
+
+		// 发送中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
+			}
+			return model, nil
+		}
+		if c.guard.Active() {
+			return c.guard.Update(model, key)
+		}
+
+		switch c.model.InputMode {
+		case constant.ContractSelectMode:
+			return c.updateContractSelect(model, key)
+		case constant.AdminActionMode:
+			return c.updateActionSelect(model, key)
+		default:
+			return c.updateInput(model, key)
+		}
+	}
+
+	return model, nil
+}
+
+// updateContractSelect moves through the deployed contracts and reads the owner of the chosen one
+func (c *ContractAdminController) updateContractSelect(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	switch key {
+	case constant.KeyUp:
+		if c.model.Cursor > 0 {
+			c.model.Cursor--
+		}
+	case constant.KeyDown:
+		if c.model.Cursor < len(c.model.Contracts)-1 {
+			c.model.Cursor++
+		}
+	case constant.KeyEnter:
+		if len(c.model.Contracts) == 0 {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.NoDeployedContract)}
+			}
+		}
+
+		contractAddress := c.model.Contracts[c.model.Cursor].Address
+		*c.model = models.ContractAdminModel{
+			Contracts:       c.model.Contracts,
+			Cursor:          c.model.Cursor,
+			InputMode:       constant.AdminActionMode,
+			ContractAddress: contractAddress,
+		}
+		model.Loading = true
+		return model, c.readOwner(contractAddress)
+	case constant.KeyEsc:
+		return model, func() tea.Msg {
+			return types.ChangePageMsg{Page: constant.MenuPage}
+		}
+	}
+	return model, nil
+}
+
+// updateActionSelect picks transfer or renounce, both only for the owner
+func (c *ContractAdminController) updateActionSelect(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	switch key {
+	case constant.KeyUp:
+		if c.model.ActionCursor > 0 {
+			c.model.ActionCursor--
+		}
+	case constant.KeyDown:
+		if c.model.ActionCursor < len(constant.AdminActionChoices)-1 {
+			c.model.ActionCursor++
+		}
+	case constant.KeyEnter:
+		// 签名账户不是 owner 时交易只会回滚，提前拒绝
+		if !c.model.IsOwner() {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.NotOwnerError, c.model.Signer, c.model.ContractAddress, c.model.Owner)}
+			}
+		}
+		c.model.NewOwner, c.model.NewOwnerConfirm, c.model.Phrase = "", "", ""
+		c.model.Simulation = nil
+		if c.model.ActionCursor == 0 {
+			c.model.InputMode = constant.NewOwnerInputMode
+		} else {
+			c.model.InputMode = constant.RenounceConfirmMode
+		}
+	case constant.KeyEsc:
+		c.model.InputMode = constant.ContractSelectMode
+	}
+	return model, nil
+}
+
+// updateInput edits the new owner, its confirmation and the renounce phrase
+func (c *ContractAdminController) updateInput(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	field := c.currentField()
+
+	switch key {
+	case constant.KeyBackspace:
+		if len(*field) > 0 {
+			*field = (*field)[:len(*field)-1]
+		}
+	case constant.KeyEsc:
+		if c.model.InputMode == constant.NewOwnerConfirmMode {
+			c.model.InputMode = constant.NewOwnerInputMode
+		} else {
+			c.model.InputMode = constant.AdminActionMode
+		}
+	case constant.KeyEnter:
+		switch c.model.InputMode {
+		case constant.NewOwnerInputMode:
+			if !common.IsHexAddress(c.model.NewOwner) || common.HexToAddress(c.model.NewOwner) == (common.Address{}) {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf(constant.InvalidAddressError, c.model.NewOwner)}
+				}
+			}
+			if strings.EqualFold(common.HexToAddress(c.model.NewOwner).Hex(), c.model.Owner) {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf(constant.NewOwnerIsCurrentOwner)}
+				}
+			}
+			c.model.NewOwnerConfirm = ""
+			c.model.InputMode = constant.NewOwnerConfirmMode
+		case constant.NewOwnerConfirmMode:
+			// 地址需完整输入两次，避免把合约交给输错的地址
+			if !strings.EqualFold(c.model.NewOwnerConfirm, c.model.NewOwner) {
+				c.model.NewOwnerConfirm = ""
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf(constant.NewOwnerMismatch)}
+				}
+			}
+			newOwner := common.HexToAddress(c.model.NewOwner).Hex()
+			params, err := services.TransferOwnershipParams(c.model.ContractAddress, newOwner)
+			if err != nil {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: err}
+				}
+			}
+			return c.guard.Guard(model, types.GlobalState.DryRun, func(model types.AppModel) (interface{}, tea.Cmd) {
+				return c.start(model, params, newOwner)
+			})
+		case constant.RenounceConfirmMode:
+			if c.model.Phrase != constant.RenouncePhrase {
+				c.model.Phrase = ""
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf(constant.RenounceMismatch)}
+				}
+			}
+			params := services.RenounceOwnershipParams(c.model.ContractAddress)
+			return c.guard.Guard(model, types.GlobalState.DryRun, func(model types.AppModel) (interface{}, tea.Cmd) {
+				return c.start(model, params, "")
+			})
+		}
+	default:
+		if len(key) == 1 {
+			*field += string(key)
+		}
+	}
+	return model, nil
+}
+
+// currentField returns the input edited in the current mode
+func (c *ContractAdminController) currentField() *string {
+	switch c.model.InputMode {
+	case constant.NewOwnerConfirmMode:
+		return &c.model.NewOwnerConfirm
+	case constant.RenounceConfirmMode:
+		return &c.model.Phrase
+	default:
+		return &c.model.NewOwner
+	}
+}
+
+// start sends the transfer, or the renounce when newOwner is empty, in the background so it
+// can be cancelled with ESC, or only simulates it in dry-run mode
+func (c *ContractAdminController) start(model types.AppModel, params services.ContractCallParams, newOwner string) (interface{}, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.model.Simulation = nil
+	model.Loading = true
+
+	contractAddress := c.model.ContractAddress
+	if types.GlobalState.DryRun {
+		return model, func() tea.Msg {
+			result, err := c.nftService.SimulateContractCall(ctx, params.FunctionName, params)
+			if err != nil {
+				return adminResultMsg{err: describeTxError(constant.SimulateFailed, err)}
+			}
+			return adminResultMsg{simulation: []services.SimulationResult{result}}
+		}
+	}
+
+	return model, func() tea.Msg {
+		var txHash string
+		var err error
+		operation := services.OperationTransferOwnership
+		if newOwner != "" {
+			txHash, err = c.nftService.TransferOwnership(ctx, contractAddress, newOwner)
+		} else {
+			operation = services.OperationRenounceOwnership
+			txHash, err = c.nftService.RenounceOwnership(ctx, contractAddress)
+		}
+		if err != nil {
+			failed := services.NewErrorResult(operation, err)
+			failed.Contract = contractAddress
+			return adminResultMsg{err: describeTxError("所有权操作失败", err), result: networkResult(failed)}
+		}
+		return adminResultMsg{
+			newOwner: newOwner,
+			txHash:   txHash,
+			result:   networkResult(services.NewOwnershipResult(contractAddress, newOwner, txHash)),
+		}
+	}
+}
+
+// readOwner reads the owner of the contract and the address of the active signer
+func (c *ContractAdminController) readOwner(contractAddress string) tea.Cmd {
+	return func() tea.Msg {
+		signer, err := c.nftService.SignerAddress()
+		if err != nil {
+			return adminOwnerMsg{err: err}
+		}
+		owner, err := c.nftService.Owner(context.Background(), contractAddress)
+		if err != nil {
+			return adminOwnerMsg{err: err}
+		}
+		return adminOwnerMsg{owner: owner, signer: signer}
+	}
+}
+
+// View renders the contract admin page
+func (c *ContractAdminController) View() string {
+	if c.guard.Active() {
+		return c.guard.View()
+	}
+	if c.model.InputMode == constant.ContractSelectMode {
+		// 每次渲染时重新读取已部署的合约
+		if contracts, err := c.contractService.GetContractsOnChain(types.GlobalState.ChainID); err == nil {
+			c.model.Contracts = contracts
+			if len(contracts) > 0 && c.model.Cursor >= len(contracts) {
+				c.model.Cursor = len(contracts) - 1
+			}
+		}
+	}
+	return views.ContractAdminView(c.model, c.cancel != nil)
+}
+
+func (c *ContractAdminController) Name() constant.Page {
+	return constant.ContractAdminPage
+}
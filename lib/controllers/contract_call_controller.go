@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
+)
+
+// ContractCallController handles the call contract function page, it lists every
+// function in the stored ABI of a deployed contract and calls it with typed inputs
+type ContractCallController struct {
+	nftService      *services.NftService
+	contractService *services.ContractCompiler
+	model           *models.ContractCallModel
+
+	// cancel aborts the call in flight, nil when nothing is running
+	cancel context.CancelFunc
+	// guard asks for the network name before sending a transaction on a mainnet
+	guard mainnetGuard
+}
+
+// contractCallResultMsg reports the outcome of a call started from the call contract function page
+type contractCallResultMsg struct {
+	outputs    []string
+	txHash     string
+	simulation []services.SimulationResult
+	err        error
+}
+
+// NewContractCallController creates a new contract call controller
+func NewContractCallController(nftService *services.NftService, contractService *services.ContractCompiler, model *models.ContractCallModel) *ContractCallController {
+	return &ContractCallController{
+		nftService:      nftService,
+		contractService: contractService,
+		model:           model,
+	}
+}
+
+// Update handles the call contract function page updates
+func (c *ContractCallController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
+	switch msg := msg.(type) {
+	case contractCallResultMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: msg.err}
+			}
+		}
+		c.model.Outputs, c.model.TxHash, c.model.Simulation = msg.outputs, msg.txHash, msg.simulation
+		return model, nil
+
+	case tea.KeyMsg:
+		key := constant.KeyboardKey(msg.String())
+
+		// 调用中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
+			}
+			return model, nil
+		}
+		if c.guard.Active() {
+			return c.guard.Update(model, key)
+		}
+
+		switch c.model.InputMode {
+		case constant.ContractSelectMode:
+			return c.updateContractSelect(model, key)
+		case constant.FunctionSelectMode:
+			return c.updateFunctionSelect(model, key)
+		default:
+			return c.updateArgs(model, key)
+		}
+	}
+
+	return model, nil
+}
+
+// updateContractSelect moves through the deployed contracts and lists the functions of the chosen one
+func (c *ContractCallController) updateContractSelect(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	switch key {
+	case constant.KeyUp:
+		if c.model.Cursor > 0 {
+			c.model.Cursor--
+		}
+	case constant.KeyDown:
+		if c.model.Cursor < len(c.model.Contracts)-1 {
+			c.model.Cursor++
+		}
+	case constant.KeyEnter:
+		if len(c.model.Contracts) == 0 {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.NoDeployedContract)}
+			}
+		}
+
+		contract := c.model.Contracts[c.model.Cursor]
+		reads, writes, err := services.ContractFunctions(contract.Abi)
+		if err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: err}
+			}
+		}
+		if len(reads)+len(writes) == 0 {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.NoContractFunctions)}
+			}
+		}
+
+		*c.model = models.ContractCallModel{
+			Contracts:       c.model.Contracts,
+			Cursor:          c.model.Cursor,
+			InputMode:       constant.FunctionSelectMode,
+			ContractAddress: contract.Address,
+			ContractABI:     contract.Abi,
+			Reads:           reads,
+			Writes:          writes,
+		}
+	case constant.KeyEsc:
+		return model, func() tea.Msg {
+			return types.ChangePageMsg{Page: constant.MenuPage}
+		}
+	}
+	return model, nil
+}
+
+// updateFunctionSelect moves through the functions and opens the input form of the chosen one
+func (c *ContractCallController) updateFunctionSelect(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	switch key {
+	case constant.KeyUp:
+		if c.model.FunctionCursor > 0 {
+			c.model.FunctionCursor--
+		}
+	case constant.KeyDown:
+		if c.model.FunctionCursor < len(c.model.Reads)+len(c.model.Writes)-1 {
+			c.model.FunctionCursor++
+		}
+	case constant.KeyEnter:
+		c.model.InputMode = constant.ArgsInputMode
+		c.model.Args = make([]string, len(c.model.SelectedFunction().Inputs))
+		c.model.ArgIndex = 0
+		c.model.Value = ""
+		c.clearResult()
+	case constant.KeyEsc:
+		c.model.InputMode = constant.ContractSelectMode
+	}
+	return model, nil
+}
+
+// updateArgs edits one input per parameter, plus the value for payable functions, and runs the call
+func (c *ContractCallController) updateArgs(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	method := c.model.SelectedFunction()
+	fields := len(c.model.Args)
+	if method.IsPayable() {
+		fields++
+	}
+
+	switch key {
+	case constant.KeyUp:
+		if c.model.ArgIndex > 0 {
+			c.model.ArgIndex--
+		}
+	case constant.KeyDown:
+		if c.model.ArgIndex < fields-1 {
+			c.model.ArgIndex++
+		}
+	case constant.KeyBackspace:
+		if field := c.currentField(); field != nil && len(*field) > 0 {
+			*field = (*field)[:len(*field)-1]
+		}
+	case constant.KeyEnter:
+		// 逐个校验输入，最后一个输入框按 Enter 时发起调用
+		if c.model.ArgIndex < len(c.model.Args) {
+			input := method.Inputs[c.model.ArgIndex]
+			if _, err := services.ParseABIValue(input.Type, c.model.Args[c.model.ArgIndex]); err != nil {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf("%s (%s): %v", input.Name, input.Type, err)}
+				}
+			}
+		}
+		if c.model.ArgIndex < fields-1 {
+			c.model.ArgIndex++
+			return model, nil
+		}
+		return c.call(model, method)
+	case constant.KeyEsc:
+		c.clearResult()
+		c.model.InputMode = constant.FunctionSelectMode
+	default:
+		if field := c.currentField(); field != nil && len(key) == 1 {
+			*field += string(key)
+		}
+	}
+	return model, nil
+}
+
+// currentField returns the input edited at ArgIndex, nil for functions without inputs
+func (c *ContractCallController) currentField() *string {
+	if c.model.ArgIndex < len(c.model.Args) {
+		return &c.model.Args[c.model.ArgIndex]
+	}
+	if c.model.SelectedFunction().IsPayable() {
+		return &c.model.Value
+	}
+	return nil
+}
+
+// clearResult forgets the outcome of the last call
+func (c *ContractCallController) clearResult() {
+	c.model.Outputs, c.model.TxHash, c.model.Simulation = nil, "", nil
+}
+
+// call converts the inputs and runs method in the background: an eth_call for read-only
+// functions, a transaction otherwise, or a simulation of it in dry-run mode. Transactions
+// on a mainnet only start once the network name was typed.
+func (c *ContractCallController) call(model types.AppModel, method abi.Method) (interface{}, tea.Cmd) {
+	args, err := services.ParseABIArguments(method, c.model.Args)
+	if err != nil {
+		return model, func() tea.Msg {
+			return types.ErrorMsg{Err: err}
+		}
+	}
+
+	params := services.ContractCallParams{
+		ContractAddress: c.model.ContractAddress,
+		ContractABI:     c.model.ContractABI,
+		FunctionName:    method.Name,
+		FunctionArgs:    args,
+	}
+	if method.IsPayable() && c.model.Value != "" {
+		value, ok := new(big.Int).SetString(c.model.Value, 10)
+		if !ok || value.Sign() < 0 {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.InvalidValueError, c.model.Value)}
+			}
+		}
+		params.Value = value
+	}
+
+	if c.model.IsRead() {
+		return c.start(model, method, params)
+	}
+	return c.guard.Guard(model, types.GlobalState.DryRun, func(model types.AppModel) (interface{}, tea.Cmd) {
+		return c.start(model, method, params)
+	})
+}
+
+// start runs the call described by params in the background so it can be cancelled with ESC
+func (c *ContractCallController) start(model types.AppModel, method abi.Method, params services.ContractCallParams) (interface{}, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.clearResult()
+	model.Loading = true
+
+	isRead := c.model.IsRead()
+	return model, func() tea.Msg {
+		switch {
+		case isRead:
+			outputs, err := c.nftService.CallView(ctx, params)
+			if err != nil {
+				return contractCallResultMsg{err: fmt.Errorf("调用 %s 失败: %v", method.Sig, err)}
+			}
+			return contractCallResultMsg{outputs: formatOutputs(method, outputs)}
+
+		case types.GlobalState.DryRun:
+			result, err := c.nftService.SimulateContractCall(ctx, method.Sig, params)
+			if err != nil {
+				return contractCallResultMsg{err: describeTxError(constant.SimulateFailed, err)}
+			}
+			return contractCallResultMsg{simulation: []services.SimulationResult{result}}
+
+		default:
+			txHash, err := c.nftService.CallContractFunction(ctx, params)
+			if err != nil {
+				return contractCallResultMsg{err: describeTxError(fmt.Sprintf("调用 %s 失败", method.Sig), err)}
+			}
+			return contractCallResultMsg{txHash: txHash}
+		}
+	}
+}
+
+// formatOutputs renders each decoded output as "name type: value"
+func formatOutputs(method abi.Method, outputs []any) []string {
+	lines := make([]string, len(outputs))
+	for i, output := range outputs {
+		label := method.Outputs[i].Type.String()
+		if name := method.Outputs[i].Name; name != "" {
+			label = name + " " + label
+		}
+		lines[i] = fmt.Sprintf("%s: %s", label, services.FormatABIValue(output))
+	}
+	return lines
+}
+
+// View renders the call contract function page
+func (c *ContractCallController) View() string {
+	if c.guard.Active() {
+		return c.guard.View()
+	}
+	if c.model.InputMode == constant.ContractSelectMode {
+		// 每次渲染时重新读取已部署的合约
+		if contracts, err := c.contractService.GetContractsOnChain(types.GlobalState.ChainID); err == nil {
+			c.model.Contracts = contracts
+			if len(contracts) > 0 && c.model.Cursor >= len(contracts) {
+				c.model.Cursor = len(contracts) - 1
+			}
+		}
+	}
+	return views.ContractCallView(c.model, c.cancel != nil)
+}
+
+func (c *ContractCallController) Name() constant.Page {
+	return constant.ContractCallPage
+}
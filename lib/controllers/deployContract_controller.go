@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	"regexp"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,6 +19,25 @@ type DeployContractController struct {
 	nftService       *services.NftService
 	contractCompiler *services.ContractCompiler
 	model            *models.DeployContractModel
+
+	// cancel aborts the deployment in flight, nil when nothing is being deployed
+	cancel context.CancelFunc
+	// guard asks for the network name before deploying on a mainnet
+	guard mainnetGuard
+}
+
+// deployResultMsg reports the outcome of a deployment started from the deploy contract page
+type deployResultMsg struct {
+	contract services.DeployedContract
+	err      error
+	// result describes a failed deployment for the log
+	result *services.Result
+}
+
+// deploySimulationMsg reports the outcome of a dry-run deployment
+type deploySimulationMsg struct {
+	result services.SimulationResult
+	err    error
 }
 
 // NewDeployContractController creates a new deploy contract controller
@@ -46,9 +67,51 @@ func NewDeployContractController(
 // Update handles the deploy contract page updates
 func (c *DeployContractController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
 	switch msg := msg.(type) {
+	case deploySimulationMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: describeTxError(constant.SimulateFailed, msg.err)}
+			}
+		}
+		c.model.Simulation = []services.SimulationResult{msg.result}
+		return model, nil
+
+	case deployResultMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: msg.err, Result: msg.result}
+			}
+		}
+
+		// 更新全局状态中的SelectedContract，使其他视图可以立即使用新部署的合约
+		// types.GlobalState.SelectedContract = contractAddr
+		types.GlobalState.DeployStat = true
+		types.GlobalState.TokenURI = msg.contract.TokenURI
+
+		// Set success message
+		message := fmt.Sprintf("合约部署成功！地址: %s", msg.contract.Address)
+		return model, func() tea.Msg {
+			return types.SuccessMsg{Message: message, Result: networkResult(services.NewDeployResult(msg.contract))}
+		}
+
 	case tea.KeyMsg:
 		key := constant.KeyboardKey(msg.String())
 
+		// 部署中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
+			}
+			return model, nil
+		}
+		if c.guard.Active() {
+			return c.guard.Update(model, key)
+		}
+
 		switch key {
 		case constant.KeyEsc:
 
@@ -66,86 +129,47 @@ func (c *DeployContractController) Update(model types.AppModel, msg tea.Msg) (in
 		case constant.KeyEnter:
 			if c.model.IsSelectingContract {
 				if c.model.SelectedContract >= 0 && c.model.SelectedContract < len(c.model.AvailableContracts) {
-					// Move to URI input after contract selection
-					c.model.IsSelectingContract = false
-					return model, nil
+					return c.selectContract(model)
 				}
 				return model, nil
 			}
 
-			if len(c.model.URI) == 0 {
-				return model, func() tea.Msg {
-					return types.ErrorMsg{Err: fmt.Errorf(constant.EmptyURLError)}
-				}
-			} else if len(c.model.URI) > constant.MaxURLLength {
-				return model, func() tea.Msg {
-					return types.ErrorMsg{Err: fmt.Errorf(constant.LongURLError)}
+			// 逐个校验构造函数参数，最后一个输入框按 Enter 时部署
+			if c.model.ArgIndex < len(c.model.Args) {
+				if err := c.validateArg(c.model.ArgIndex); err != nil {
+					return model, func() tea.Msg {
+						return types.ErrorMsg{Err: err}
+					}
 				}
 			}
-			matched, _ := regexp.MatchString(constant.URLPattern, c.model.URI)
-			if !matched {
-				return model, func() tea.Msg {
-					return types.ErrorMsg{Err: fmt.Errorf(constant.InvalidURLError)}
-				}
-			}
-
-			selectedContract := c.model.AvailableContracts[c.model.SelectedContract]
-
-			// Create deployment parameters
-			params := services.DeployContractParams{
-				Bytecode:   selectedContract.Bytecode,
-				InitialURI: c.model.URI,
-				GasLimit:   3000000,
-			}
-
-			// Deploy contract
-			contractAddr, err := c.nftService.DeployContractWithABI(params)
-			if err != nil {
-				return model, func() tea.Msg {
-					return types.ErrorMsg{Err: err}
-				}
-			}
-
-			// Save contract info
-			err = c.contractCompiler.SaveDeployedContract(contractAddr, c.model.URI, selectedContract.ABI)
-			if err != nil {
-				model.Logger.Log("ERROR", fmt.Sprintf("保存合约信息失败: %v", err))
-				return model, func() tea.Msg {
-					return types.ErrorMsg{Err: fmt.Errorf("save contract info failed")}
-				}
+			if c.model.ArgIndex < c.model.Fields()-1 {
+				c.model.ArgIndex++
+				return model, nil
 			}
-
-			// 更新全局状态中的SelectedContract，使其他视图可以立即使用新部署的合约
-			// types.GlobalState.SelectedContract = contractAddr
-			types.GlobalState.DeployStat = true
-			types.GlobalState.TokenURI = c.model.URI
-
-			// Set success message
-			model.SuccessMessage = fmt.Sprintf("合约部署成功！地址: %s", contractAddr)
-
-			// return model, func() tea.Msg {
-			// 	// 部署成功后可以直接进入空投页面
-			// 	return types.ChangePageMsg{Page: constant.AirdropPage}
-			// }
+			return c.deploy(model)
 
 		case constant.KeyBackspace:
-			if !c.model.IsSelectingContract && len(c.model.URI) > 0 {
-				c.model.URI = c.model.URI[:len(c.model.URI)-1]
+			if field := c.currentField(); field != nil && len(*field) > 0 {
+				*field = (*field)[:len(*field)-1]
 			}
 
 		case constant.KeyUp:
 			if c.model.IsSelectingContract && c.model.SelectedContract > 0 {
 				c.model.SelectedContract--
+			} else if !c.model.IsSelectingContract && c.model.ArgIndex > 0 {
+				c.model.ArgIndex--
 			}
 
 		case constant.KeyDown:
 			if c.model.IsSelectingContract && c.model.SelectedContract < len(c.model.AvailableContracts)-1 {
 				c.model.SelectedContract++
+			} else if !c.model.IsSelectingContract && c.model.ArgIndex < c.model.Fields()-1 {
+				c.model.ArgIndex++
 			}
 
 		default:
-			if !c.model.IsSelectingContract && len(msg.String()) == 1 {
-				c.model.URI += msg.String()
+			if field := c.currentField(); field != nil && len(msg.String()) == 1 {
+				*field += msg.String()
 			}
 		}
 	}
@@ -153,9 +177,160 @@ func (c *DeployContractController) Update(model types.AppModel, msg tea.Msg) (in
 	return model, nil
 }
 
+// selectContract reads the constructor of the selected artifact and opens its input form
+func (c *DeployContractController) selectContract(model types.AppModel) (interface{}, tea.Cmd) {
+	selectedContract := c.model.AvailableContracts[c.model.SelectedContract]
+	constructor, err := services.ConstructorOf(selectedContract.ABI)
+	if err != nil {
+		return model, func() tea.Msg {
+			return types.ErrorMsg{Err: err}
+		}
+	}
+	deployer, err := c.nftService.SignerAddress()
+	if err != nil {
+		return model, func() tea.Msg {
+			return types.ErrorMsg{Err: fmt.Errorf("读取部署者地址失败: %v", err)}
+		}
+	}
+
+	c.model.IsSelectingContract = false
+	c.model.Constructor = constructor
+	c.model.Args = make([]string, len(constructor.Inputs))
+	c.model.ArgIndex = 0
+	c.model.Value = ""
+	c.model.Deployer = deployer
+	c.model.Simulation = nil
+
+	// 构造函数没有参数时直接部署
+	if c.model.Fields() == 0 {
+		return c.deploy(model)
+	}
+	return model, nil
+}
+
+// currentField returns the input edited at ArgIndex, nil while selecting a contract
+func (c *DeployContractController) currentField() *string {
+	if c.model.IsSelectingContract {
+		return nil
+	}
+	if c.model.ArgIndex < len(c.model.Args) {
+		return &c.model.Args[c.model.ArgIndex]
+	}
+	if c.model.Constructor.IsPayable() {
+		return &c.model.Value
+	}
+	return nil
+}
+
+// validateArg fills in the default of an empty constructor input and checks it parses as its type
+func (c *DeployContractController) validateArg(i int) error {
+	input := c.model.Constructor.Inputs[i]
+	if c.model.Args[i] == "" {
+		c.model.Args[i] = c.model.ArgDefault(i)
+	}
+
+	if i == c.model.URIArgIndex() {
+		uri := c.model.Args[i]
+		if len(uri) == 0 {
+			return fmt.Errorf(constant.EmptyURLError)
+		} else if len(uri) > constant.MaxURLLength {
+			return fmt.Errorf(constant.LongURLError)
+		}
+		if matched, _ := regexp.MatchString(constant.URLPattern, uri); !matched {
+			return fmt.Errorf(constant.InvalidURLError)
+		}
+	}
+
+	if _, err := services.ParseABIValue(input.Type, c.model.Args[i]); err != nil {
+		return fmt.Errorf("%s (%s): %v", input.Name, input.Type, err)
+	}
+	return nil
+}
+
+// deploy encodes the constructor inputs and starts the deployment of the selected contract,
+// on a mainnet once the network name was typed
+func (c *DeployContractController) deploy(model types.AppModel) (interface{}, tea.Cmd) {
+	selectedContract := c.model.AvailableContracts[c.model.SelectedContract]
+
+	for i := range c.model.Args {
+		if err := c.validateArg(i); err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: err}
+			}
+		}
+	}
+	args, err := services.ParseABIArguments(c.model.Constructor, c.model.Args)
+	if err != nil {
+		return model, func() tea.Msg {
+			return types.ErrorMsg{Err: err}
+		}
+	}
+
+	// Create deployment parameters
+	params := services.DeployContractParams{
+		Bytecode:        selectedContract.Bytecode,
+		ConstructorABI:  selectedContract.ABI,
+		ConstructorArgs: args,
+	}
+	if c.model.Value != "" {
+		value, ok := new(big.Int).SetString(c.model.Value, 10)
+		if !ok || value.Sign() < 0 {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.InvalidValueError, c.model.Value)}
+			}
+		}
+		params.Value = value
+	}
+
+	textArgs := append([]string{}, c.model.Args...)
+	return c.guard.Guard(model, types.GlobalState.DryRun, func(model types.AppModel) (interface{}, tea.Cmd) {
+		return c.start(model, selectedContract, textArgs, params)
+	})
+}
+
+// start deploys in the background so it can be cancelled with ESC, or only simulates the
+// deployment in dry-run mode. The deployment is recorded like the deploy command does.
+func (c *DeployContractController) start(model types.AppModel, artifact services.AvailableContract, args []string, params services.DeployContractParams) (interface{}, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.model.Simulation = nil
+	model.Loading = true
+
+	// 模拟模式下只预演部署，不签名也不发送
+	if types.GlobalState.DryRun {
+		return model, func() tea.Msg {
+			result, err := c.nftService.SimulateDeploy(ctx, params)
+			return deploySimulationMsg{result: result, err: err}
+		}
+	}
+
+	network := types.GlobalState.Network
+	return model, func() tea.Msg {
+		contract, err := c.contractCompiler.DeployArtifact(ctx, c.nftService, artifact, args, params.Value, network)
+		if err != nil && contract.Address != "" {
+			// 合约已上链，只是记录失败
+			failed := services.NewErrorResult(services.OperationDeploy, err)
+			failed.Contract = contract.Address
+			return deployResultMsg{
+				err:    fmt.Errorf("合约已部署在 %s，但保存合约信息失败: %v", contract.Address, err),
+				result: networkResult(failed),
+			}
+		} else if err != nil {
+			return deployResultMsg{
+				err:    describeTxError("部署合约失败", err),
+				result: networkResult(services.NewErrorResult(services.OperationDeploy, err)),
+			}
+		}
+		return deployResultMsg{contract: contract}
+	}
+}
+
 // View renders the deploy contract page
 func (c *DeployContractController) View() string {
-	return views.DeployContractView(c.model)
+	if c.guard.Active() {
+		return c.guard.View()
+	}
+	return views.DeployContractView(c.model, c.cancel != nil)
 }
 
 func (c *DeployContractController) Name() constant.Page {
@@ -50,6 +50,8 @@ func (c *DeployController) Update(model types.AppModel, msg tea.Msg) (interface{
 				nextPage = constant.DeployContractPage
 			case 1:
 				nextPage = constant.CheckTotalPage
+			case 2:
+				nextPage = constant.ImportContractPage
 			}
 
 			return model, func() tea.Msg {
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethereum/go-ethereum/common"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
+)
+
+// ImportContractController registers a contract deployed outside this tool by its address
+type ImportContractController struct {
+	nftService       *services.NftService
+	contractCompiler *services.ContractCompiler
+	address          string
+	// imported is the last contract imported from this page
+	imported *services.DeployedContract
+
+	// cancel aborts the import in flight, nil when nothing is running
+	cancel context.CancelFunc
+}
+
+// importResultMsg reports the outcome of an import started from the import contract page
+type importResultMsg struct {
+	contract services.DeployedContract
+	err      error
+}
+
+// NewImportContractController creates a new import contract controller
+func NewImportContractController(nftService *services.NftService, contractCompiler *services.ContractCompiler) *ImportContractController {
+	return &ImportContractController{
+		nftService:       nftService,
+		contractCompiler: contractCompiler,
+	}
+}
+
+// Update handles the import contract page updates
+func (c *ImportContractController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
+	switch msg := msg.(type) {
+	case importResultMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{
+					Err:    fmt.Errorf("%s: %v", constant.ImportFailed, msg.err),
+					Result: networkResult(services.NewErrorResult(services.OperationImport, msg.err)),
+				}
+			}
+		}
+		c.imported = &msg.contract
+		c.address = ""
+		return model, func() tea.Msg {
+			return types.SuccessMsg{
+				Message: fmt.Sprintf(constant.ImportSuccess, msg.contract.Address, msg.contract.ContractName),
+				Result:  networkResult(services.NewDeployResult(msg.contract)),
+			}
+		}
+
+	case tea.KeyMsg:
+		key := constant.KeyboardKey(msg.String())
+
+		// 导入中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
+			}
+			return model, nil
+		}
+
+		switch key {
+		case constant.KeyEnter:
+			if !common.IsHexAddress(c.address) {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf(constant.InvalidAddressError, c.address)}
+				}
+			}
+
+			// 读取链上代码并匹配 ABI，在后台进行以便按 ESC 取消
+			ctx, cancel := context.WithCancel(context.Background())
+			c.cancel = cancel
+			c.imported = nil
+			model.Loading = true
+			address, network := c.address, types.GlobalState.Network
+			return model, func() tea.Msg {
+				contract, err := c.contractCompiler.ImportContract(ctx, c.nftService, address, network)
+				return importResultMsg{contract: contract, err: err}
+			}
+		case constant.KeyBackspace:
+			if len(c.address) > 0 {
+				c.address = c.address[:len(c.address)-1]
+			}
+		case constant.KeyEsc:
+			c.address, c.imported = "", nil
+			return model, func() tea.Msg {
+				return types.ChangePageMsg{Page: constant.DeployPage}
+			}
+		default:
+			if len(msg.String()) == 1 {
+				c.address += msg.String()
+			}
+		}
+	}
+
+	return model, nil
+}
+
+// View renders the import contract page
+func (c *ImportContractController) View() string {
+	return views.ImportContractView(c.address, c.imported, c.cancel != nil)
+}
+
+func (c *ImportContractController) Name() constant.Page {
+	return constant.ImportContractPage
+}
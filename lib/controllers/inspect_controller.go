@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethereum/go-ethereum/common"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
+)
+
+// InspectController handles the inspect contract page logic, all queries are read-only
+type InspectController struct {
+	nftService      *services.NftService
+	contractService *services.ContractCompiler
+	model           *models.InspectModel
+}
+
+// NewInspectController creates a new inspect controller
+func NewInspectController(nftService *services.NftService, contractService *services.ContractCompiler, model *models.InspectModel) *InspectController {
+	return &InspectController{
+		nftService:      nftService,
+		contractService: contractService,
+		model:           model,
+	}
+}
+
+// inspectOverviewMsg carries the owner, batch size and URI of the selected contract
+type inspectOverviewMsg struct {
+	owner     string
+	batchSize int
+	uri       string
+	err       error
+}
+
+// inspectQueryMsg carries the balances and approval read for the entered account
+type inspectQueryMsg struct {
+	ids      []*big.Int
+	balances []*big.Int
+	approved *bool
+	err      error
+}
+
+// Update handles the inspect page updates
+func (c *InspectController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
+	switch msg := msg.(type) {
+	case inspectOverviewMsg:
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf("读取合约信息失败: %v", msg.err)}
+			}
+		}
+		c.model.Owner, c.model.BatchSize, c.model.URI = msg.owner, msg.batchSize, msg.uri
+		model.Logger.LogResult(*networkResult(services.NewInspectResult(c.model.ContractAddress, msg.owner, msg.batchSize, msg.uri, "", nil, nil)))
+		return model, nil
+
+	case inspectQueryMsg:
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf("查询失败: %v", msg.err)}
+			}
+		}
+		c.model.QueriedIDs, c.model.Balances, c.model.Approved = msg.ids, msg.balances, msg.approved
+		model.Logger.LogResult(*networkResult(services.NewInspectResult(
+			c.model.ContractAddress, c.model.Owner, c.model.BatchSize, c.model.URI, c.model.Account, msg.ids, msg.balances)))
+		return model, nil
+
+	case tea.KeyMsg:
+		key := constant.KeyboardKey(msg.String())
+
+		switch c.model.InputMode {
+		case constant.ContractSelectMode:
+			return c.updateContractSelect(model, key)
+		default:
+			return c.updateInput(model, key)
+		}
+	}
+
+	return model, nil
+}
+
+// updateContractSelect moves through the deployed contracts and reads the overview of the chosen one
+func (c *InspectController) updateContractSelect(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	switch key {
+	case constant.KeyUp:
+		if c.model.Cursor > 0 {
+			c.model.Cursor--
+		}
+	case constant.KeyDown:
+		if c.model.Cursor < len(c.model.Contracts)-1 {
+			c.model.Cursor++
+		}
+	case constant.KeyEnter:
+		if len(c.model.Contracts) == 0 {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.NoDeployedContract)}
+			}
+		}
+
+		contractAddress := c.model.Contracts[c.model.Cursor].Address
+		*c.model = models.InspectModel{
+			Contracts:       c.model.Contracts,
+			Cursor:          c.model.Cursor,
+			InputMode:       constant.AccountInputMode,
+			ContractAddress: contractAddress,
+		}
+		model.Loading = true
+		return model, c.readOverview(contractAddress)
+	case constant.KeyEsc:
+		return model, func() tea.Msg {
+			return types.ChangePageMsg{Page: constant.MenuPage}
+		}
+	}
+	return model, nil
+}
+
+// updateInput edits the account, token IDs and operator fields and runs the query
+func (c *InspectController) updateInput(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	field := c.currentField()
+
+	switch key {
+	case constant.KeyBackspace:
+		if len(*field) > 0 {
+			*field = (*field)[:len(*field)-1]
+		}
+	case constant.KeyEnter:
+		switch c.model.InputMode {
+		case constant.AccountInputMode:
+			if !common.IsHexAddress(c.model.Account) {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf(constant.InvalidAddressError, c.model.Account)}
+				}
+			}
+			c.model.InputMode = constant.TokenIDsInputMode
+		case constant.TokenIDsInputMode:
+			if _, err := parseTokenIDs(c.model.TokenIDs); err != nil {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: err}
+				}
+			}
+			c.model.InputMode = constant.OperatorInputMode
+		case constant.OperatorInputMode:
+			if c.model.Operator != "" && !common.IsHexAddress(c.model.Operator) {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: fmt.Errorf(constant.InvalidAddressError, c.model.Operator)}
+				}
+			}
+			ids, _ := parseTokenIDs(c.model.TokenIDs)
+			model.Loading = true
+			return model, c.query(c.model.ContractAddress, c.model.Account, ids, c.model.Operator)
+		}
+	case constant.KeyEsc:
+		// 逐级返回上一个输入框
+		c.model.QueriedIDs, c.model.Balances, c.model.Approved = nil, nil, nil
+		switch c.model.InputMode {
+		case constant.OperatorInputMode:
+			c.model.InputMode = constant.TokenIDsInputMode
+		case constant.TokenIDsInputMode:
+			c.model.InputMode = constant.AccountInputMode
+		default:
+			c.model.InputMode = constant.ContractSelectMode
+		}
+	default:
+		if len(key) == 1 {
+			*field += string(key)
+		}
+	}
+	return model, nil
+}
+
+// currentField returns the input edited in the current mode
+func (c *InspectController) currentField() *string {
+	switch c.model.InputMode {
+	case constant.TokenIDsInputMode:
+		return &c.model.TokenIDs
+	case constant.OperatorInputMode:
+		return &c.model.Operator
+	default:
+		return &c.model.Account
+	}
+}
+
+// parseTokenIDs parses a comma separated list of decimal token IDs
+func parseTokenIDs(input string) ([]*big.Int, error) {
+	var ids []*big.Int
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(part, 10, 64); err != nil {
+			return nil, fmt.Errorf(constant.InvalidTokenIDsError, part)
+		}
+		id, _ := new(big.Int).SetString(part, 10)
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf(constant.EmptyNFTIDError)
+	}
+	return ids, nil
+}
+
+// readOverview reads the owner, batch size and URI of the contract in the background
+func (c *InspectController) readOverview(contractAddress string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		owner, err := c.nftService.Owner(ctx, contractAddress)
+		if err != nil {
+			return inspectOverviewMsg{err: err}
+		}
+		batchSize, err := c.nftService.BatchSize(ctx, contractAddress)
+		if err != nil {
+			return inspectOverviewMsg{err: err}
+		}
+		uri, err := c.nftService.URI(ctx, contractAddress, big.NewInt(0))
+		if err != nil {
+			return inspectOverviewMsg{err: err}
+		}
+		return inspectOverviewMsg{owner: owner, batchSize: batchSize, uri: uri}
+	}
+}
+
+// query reads the balances of account and, when an operator is given, its approval in the background
+func (c *InspectController) query(contractAddress string, account string, ids []*big.Int, operator string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		accounts := make([]string, len(ids))
+		for i := range accounts {
+			accounts[i] = account
+		}
+		balances, err := c.nftService.BalanceOfBatch(ctx, contractAddress, accounts, ids)
+		if err != nil {
+			return inspectQueryMsg{err: err}
+		}
+
+		result := inspectQueryMsg{ids: ids, balances: balances}
+		if operator != "" {
+			approved, err := c.nftService.IsApprovedForAll(ctx, contractAddress, account, operator)
+			if err != nil {
+				return inspectQueryMsg{err: err}
+			}
+			result.approved = &approved
+		}
+		return result
+	}
+}
+
+// View renders the inspect page
+func (c *InspectController) View() string {
+	if c.model.InputMode == constant.ContractSelectMode {
+		// 每次渲染时重新读取已部署的合约
+		if contracts, err := c.contractService.GetContractsOnChain(types.GlobalState.ChainID); err == nil {
+			c.model.Contracts = contracts
+			if len(contracts) > 0 && c.model.Cursor >= len(contracts) {
+				c.model.Cursor = len(contracts) - 1
+			}
+		}
+	}
+	return views.InspectView(c.model)
+}
+
+func (c *InspectController) Name() constant.Page {
+	return constant.InspectPage
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
+)
+
+// mainnetGuard asks for the network name to be typed before a transaction is sent on a
+// mainnet profile. Pages that send embed one and hand it their key presses while it is active.
+type mainnetGuard struct {
+	// send runs once the name was typed, nil when nothing is waiting for confirmation
+	send  func(model types.AppModel) (interface{}, tea.Cmd)
+	typed string
+}
+
+// Guard runs send right away unless the active network is a mainnet, then it first asks
+// for the network name. simulated tells a send that only dry-runs, it signs nothing and is
+// not guarded. Only the caller knows whether send simulates, so dry-run mode alone never
+// skips the guard.
+func (g *mainnetGuard) Guard(model types.AppModel, simulated bool, send func(model types.AppModel) (interface{}, tea.Cmd)) (interface{}, tea.Cmd) {
+	if !types.GlobalState.Mainnet || simulated {
+		return send(model)
+	}
+	g.send, g.typed = send, ""
+	return model, nil
+}
+
+// Active reports whether the guard is waiting for the network name
+func (g *mainnetGuard) Active() bool {
+	return g.send != nil
+}
+
+// Update edits the typed name, Enter sends when it matches and ESC gives up
+func (g *mainnetGuard) Update(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	switch key {
+	case constant.KeyEsc:
+		g.send, g.typed = nil, ""
+	case constant.KeyBackspace:
+		if len(g.typed) > 0 {
+			g.typed = g.typed[:len(g.typed)-1]
+		}
+	case constant.KeyEnter:
+		if g.typed != types.GlobalState.Network {
+			g.typed = ""
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.MainnetConfirmMismatch)}
+			}
+		}
+		send := g.send
+		g.send, g.typed = nil, ""
+		return send(model)
+	default:
+		if len(key) == 1 {
+			g.typed += string(key)
+		}
+	}
+	return model, nil
+}
+
+// View renders the confirmation prompt
+func (g *mainnetGuard) View() string {
+	return views.MainnetConfirmView(types.GlobalState.Network, g.typed)
+}
@@ -45,6 +45,16 @@ func (c *MenuController) Update(model types.AppModel, msg tea.Msg) (interface{},
 				nextPage = constant.SelectContractPage
 			case 2:
 				nextPage = constant.CheckTotalPage
+			case 3:
+				nextPage = constant.InspectPage
+			case 4:
+				nextPage = constant.ContractCallPage
+			case 5:
+				nextPage = constant.NetworkPage
+			case 6:
+				nextPage = constant.ContractAdminPage
+			case 7:
+				nextPage = constant.PendingTxPage
 			}
 
 			return model, func() tea.Msg {
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	tea "github.com/charmbracelet/bubbletea"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
+)
+
+// NetworkController lists the network profiles and switches the service between them
+type NetworkController struct {
+	nftService       *services.NftService
+	contractCompiler *services.ContractCompiler
+	networks         []services.NetworkProfile
+	networksPath     string
+	cursor           int
+	// switchTo points the service and the global state at a profile
+	switchTo func(services.NetworkProfile) error
+
+	// cancel aborts the chain ID check in flight, nil when nothing is running
+	cancel context.CancelFunc
+}
+
+// networkVerifiedMsg reports the chain ID of the node of a newly chosen network
+type networkVerifiedMsg struct {
+	network string
+	chainID *big.Int
+	// claimed counts the contracts without a chain ID that were found on this chain
+	claimed int
+	err     error
+}
+
+// NewNetworkController creates a new network controller, networks is empty without a networks file
+func NewNetworkController(nftService *services.NftService, contractCompiler *services.ContractCompiler, networks []services.NetworkProfile, networksPath string, switchTo func(services.NetworkProfile) error) *NetworkController {
+	return &NetworkController{
+		nftService:       nftService,
+		contractCompiler: contractCompiler,
+		networks:         networks,
+		networksPath:     networksPath,
+		switchTo:         switchTo,
+	}
+}
+
+// Update handles the network page updates
+func (c *NetworkController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
+	switch msg := msg.(type) {
+	case networkVerifiedMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf("%s: %v", constant.NetworkVerifyFailed, msg.err)}
+			}
+		}
+		// 网络未固定 chain ID 时按节点报告的链筛选合约
+		if types.GlobalState.Network == msg.network {
+			types.GlobalState.ChainID = msg.chainID.Uint64()
+		}
+		model.Logger.Log("INFO", fmt.Sprintf("切换到网络 %s，chain ID %s", msg.network, msg.chainID))
+		if msg.claimed > 0 {
+			model.Logger.Log("INFO", fmt.Sprintf("%d 个旧合约记录已归入 chain ID %s", msg.claimed, msg.chainID))
+		}
+		return model, func() tea.Msg {
+			return types.SuccessMsg{Message: fmt.Sprintf(constant.NetworkSwitched, msg.network, msg.chainID)}
+		}
+
+	case tea.KeyMsg:
+		key := constant.KeyboardKey(msg.String())
+
+		// 校验中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
+			}
+			return model, nil
+		}
+
+		switch key {
+		case constant.KeyUp:
+			if c.cursor > 0 {
+				c.cursor--
+			}
+		case constant.KeyDown:
+			if c.cursor < len(c.networks)-1 {
+				c.cursor++
+			}
+		case constant.KeyEnter:
+			if len(c.networks) == 0 {
+				return model, nil
+			}
+			network := c.networks[c.cursor]
+			if err := c.switchTo(network); err != nil {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: err}
+				}
+			}
+
+			// 切换后立即确认节点所在的链，之后每笔交易签名前还会再校验。
+			// 未记录链的旧合约若在这条链上有代码，顺便归入这条链
+			ctx, cancel := context.WithCancel(context.Background())
+			c.cancel = cancel
+			model.Loading = true
+			return model, func() tea.Msg {
+				chainID, err := c.nftService.VerifyChain(ctx)
+				if err != nil {
+					return networkVerifiedMsg{network: network.Name, err: err}
+				}
+				claimed, err := c.contractCompiler.ClaimUnassignedContracts(ctx, c.nftService, network.Name)
+				return networkVerifiedMsg{network: network.Name, chainID: chainID, claimed: claimed, err: err}
+			}
+		case constant.KeyEsc:
+			return model, func() tea.Msg {
+				return types.ChangePageMsg{Page: constant.MenuPage}
+			}
+		}
+	}
+
+	return model, nil
+}
+
+// View renders the network page
+func (c *NetworkController) View() string {
+	return views.NetworkView(c.networks, c.cursor, c.networksPath, c.cancel != nil)
+}
+
+func (c *NetworkController) Name() constant.Page {
+	return constant.NetworkPage
+}
@@ -11,16 +11,24 @@ import (
 type PasswordController struct {
 	service *password.Service
 	input   string
+	// nextPage is shown after a successful login
+	nextPage constant.Page
 }
 
 // NewPasswordController creates a new password controller
 func NewPasswordController(service *password.Service) *PasswordController {
 	return &PasswordController{
-		service: service,
-		input:   "",
+		service:  service,
+		input:    "",
+		nextPage: constant.MenuPage,
 	}
 }
 
+// SetNextPage sets the page shown after a successful login
+func (c *PasswordController) SetNextPage(page constant.Page) {
+	c.nextPage = page
+}
+
 // Update handles the password page updates
 func (c *PasswordController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
 
@@ -41,9 +49,9 @@ func (c *PasswordController) Update(model types.AppModel, msg tea.Msg) (interfac
 				}
 			}
 
-			// Password verified, move to menu page
+			// Password verified, move to the next page
 			return model, func() tea.Msg {
-				return types.ChangePageMsg{Page: constant.MenuPage}
+				return types.ChangePageMsg{Page: c.nextPage}
 			}
 		default:
 			c.input += msg.String()
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
+)
+
+// PendingTxController handles the pending transactions page, it lists the tracked
+// transactions that are not mined yet and speeds them up or cancels them
+type PendingTxController struct {
+	nftService *services.NftService
+	tracker    *services.TxTracker
+	model      *models.PendingTxModel
+
+	// cancel aborts the replacement in flight, nil when nothing is running
+	cancel context.CancelFunc
+	// guard asks for the network name before sending a replacement on a mainnet
+	guard mainnetGuard
+}
+
+// pendingListMsg carries the pending transactions after they were looked up on chain
+type pendingListMsg struct {
+	txs []services.TrackedTx
+	err error
+}
+
+// pendingReplacedMsg reports the outcome of a speed-up or cancellation
+type pendingReplacedMsg struct {
+	kind     services.TxAttemptKind
+	original string
+	txHash   string
+	err      error
+}
+
+// NewPendingTxController creates a new pending transactions controller reading the
+// transactions tracked at trackerPath
+func NewPendingTxController(nftService *services.NftService, trackerPath string, model *models.PendingTxModel) *PendingTxController {
+	return &PendingTxController{
+		nftService: nftService,
+		tracker:    services.NewTxTracker(trackerPath),
+		model:      model,
+	}
+}
+
+// Update handles the pending transactions page updates
+func (c *PendingTxController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pendingListMsg:
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf("核对交易状态失败: %v", msg.err)}
+			}
+		}
+		c.model.Transactions = msg.txs
+		c.clampCursor()
+		return model, func() tea.Msg {
+			return types.SuccessMsg{Message: fmt.Sprintf(constant.PendingTxRefreshed, len(msg.txs))}
+		}
+
+	case pendingReplacedMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			prefix := "加速交易失败"
+			if msg.kind == services.AttemptCancel {
+				prefix = "取消交易失败"
+			}
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: describeTxError(prefix, msg.err)}
+			}
+		}
+
+		c.model.InputMode = constant.TxSelectMode
+		message := fmt.Sprintf(constant.PendingTxSpedUp, msg.original, views.TxLinks([]string{msg.txHash}))
+		if msg.kind == services.AttemptCancel {
+			message = fmt.Sprintf(constant.PendingTxCancelSent, msg.original, views.TxLinks([]string{msg.txHash}))
+		}
+		return model, func() tea.Msg {
+			return types.SuccessMsg{Message: message}
+		}
+
+	case tea.KeyMsg:
+		key := constant.KeyboardKey(msg.String())
+
+		// 发送中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
+			}
+			return model, nil
+		}
+		if c.guard.Active() {
+			return c.guard.Update(model, key)
+		}
+
+		if c.model.InputMode == constant.TxActionMode {
+			return c.updateActionSelect(model, key)
+		}
+		return c.updateTxSelect(model, key)
+	}
+
+	return model, nil
+}
+
+// updateTxSelect moves through the pending transactions and looks them up on chain
+func (c *PendingTxController) updateTxSelect(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	switch key {
+	case constant.KeyUp:
+		if c.model.Cursor > 0 {
+			c.model.Cursor--
+		}
+	case constant.KeyDown:
+		if c.model.Cursor < len(c.model.Transactions)-1 {
+			c.model.Cursor++
+		}
+	case constant.KeyRefresh:
+		model.Loading = true
+		return model, c.refresh()
+	case constant.KeyEnter:
+		if len(c.model.Transactions) == 0 {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.NoPendingTx)}
+			}
+		}
+		c.model.Selected = c.model.Transactions[c.model.Cursor]
+		c.model.ActionCursor = 0
+		c.model.InputMode = constant.TxActionMode
+	case constant.KeyEsc:
+		return model, func() tea.Msg {
+			return types.ChangePageMsg{Page: constant.MenuPage}
+		}
+	}
+	return model, nil
+}
+
+// updateActionSelect picks speed up or cancel for the selected transaction
+func (c *PendingTxController) updateActionSelect(model types.AppModel, key constant.KeyboardKey) (interface{}, tea.Cmd) {
+	switch key {
+	case constant.KeyUp:
+		if c.model.ActionCursor > 0 {
+			c.model.ActionCursor--
+		}
+	case constant.KeyDown:
+		if c.model.ActionCursor < len(constant.PendingTxActionChoices)-1 {
+			c.model.ActionCursor++
+		}
+	case constant.KeyEnter:
+		// 替换交易只能真实发送，模拟模式下没有意义
+		if types.GlobalState.DryRun {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: fmt.Errorf(constant.PendingTxDryRunError)}
+			}
+		}
+		kind := services.AttemptSpeedUp
+		if c.model.ActionCursor == 1 {
+			kind = services.AttemptCancel
+		}
+		return c.guard.Guard(model, false, func(model types.AppModel) (interface{}, tea.Cmd) {
+			return c.start(model, kind)
+		})
+	case constant.KeyEsc:
+		c.model.InputMode = constant.TxSelectMode
+	}
+	return model, nil
+}
+
+// start sends the replacement of the selected transaction in the background so it can be
+// cancelled with ESC
+func (c *PendingTxController) start(model types.AppModel, kind services.TxAttemptKind) (interface{}, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	model.Loading = true
+
+	original := c.model.Selected.Hash()
+	return model, func() tea.Msg {
+		var txHash string
+		var err error
+		if kind == services.AttemptCancel {
+			txHash, err = c.nftService.CancelTransaction(ctx, original)
+		} else {
+			txHash, err = c.nftService.SpeedUpTransaction(ctx, original)
+		}
+		return pendingReplacedMsg{kind: kind, original: original, txHash: txHash, err: err}
+	}
+}
+
+// refresh looks up the pending transactions on chain, settling those mined since
+func (c *PendingTxController) refresh() tea.Cmd {
+	return func() tea.Msg {
+		txs, err := c.nftService.PendingTransactions(context.Background())
+		return pendingListMsg{txs: txs, err: err}
+	}
+}
+
+// clampCursor keeps the cursor on the list after it changed
+func (c *PendingTxController) clampCursor() {
+	if c.model.Cursor >= len(c.model.Transactions) {
+		c.model.Cursor = max(len(c.model.Transactions)-1, 0)
+	}
+}
+
+// View renders the pending transactions page
+func (c *PendingTxController) View() string {
+	if c.guard.Active() {
+		return c.guard.View()
+	}
+	if c.model.InputMode == constant.TxSelectMode {
+		// 每次渲染时重新读取记录，其他进程发送或替换的交易也会显示
+		if txs, err := c.tracker.Pending(types.GlobalState.ChainID); err == nil {
+			c.model.Transactions = txs
+			c.clampCursor()
+		}
+	}
+	return views.PendingTxView(c.model, c.cancel != nil)
+}
+
+func (c *PendingTxController) Name() constant.Page {
+	return constant.PendingTxPage
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
+)
+
+// ResumeAirdropController offers to resume an airdrop left unfinished by an earlier run
+type ResumeAirdropController struct {
+	nftService  *services.NftService
+	journalPath string
+	// journal is the last saved state of the unfinished airdrop, only read by the page
+	journal *services.AirdropJournal
+
+	// cancel aborts the airdrop in flight, nil when nothing is being sent
+	cancel  context.CancelFunc
+	updates chan tea.Msg
+	batches []services.BatchStatus
+	// simulation holds the report of the last dry run
+	simulation []services.SimulationResult
+	done       bool
+	// guard asks for the network name before resuming on a mainnet
+	guard mainnetGuard
+}
+
+// NewResumeAirdropController creates a new resume airdrop controller
+func NewResumeAirdropController(nftService *services.NftService, journalPath string) *ResumeAirdropController {
+	return &ResumeAirdropController{
+		nftService:  nftService,
+		journalPath: journalPath,
+	}
+}
+
+// FindUnfinished loads the journal and reports whether it holds an unfinished airdrop
+func (c *ResumeAirdropController) FindUnfinished() (bool, error) {
+	journal, err := services.LoadAirdropJournal(c.journalPath)
+	if err != nil {
+		return false, err
+	}
+	if journal == nil || journal.Finished() {
+		return false, nil
+	}
+	c.journal = journal
+	return true, nil
+}
+
+// Update handles the resume airdrop page updates
+func (c *ResumeAirdropController) Update(model types.AppModel, msg tea.Msg) (interface{}, tea.Cmd) {
+	switch msg := msg.(type) {
+	case simulationResultMsg:
+		c.cancel = nil
+		model.Loading = false
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: describeTxError(constant.SimulateFailed, msg.err)}
+			}
+		}
+		c.simulation = msg.results
+		return model, nil
+
+	case airdropBatchMsg:
+		c.batches = append(c.batches, msg.status)
+		model.Logger.LogResult(*msg.result)
+		if msg.status.Err == nil {
+			model.Logger.Log("INFO", fmt.Sprintf("第 %d/%d 批 NFT 发送成功，交易哈希: %s", msg.status.Index, msg.status.Total, msg.status.TxHash))
+		}
+		return model, waitForAirdrop(c.updates)
+
+	case airdropResultMsg:
+		c.cancel = nil
+		c.updates = nil
+		model.Loading = false
+
+		// 重新读取记录，显示最新状态
+		if journal, err := services.LoadAirdropJournal(c.journalPath); err == nil && journal != nil {
+			c.journal = journal
+		}
+
+		if msg.err != nil {
+			return model, func() tea.Msg {
+				return types.ErrorMsg{Err: msg.err, Result: msg.result}
+			}
+		}
+
+		c.done = true
+		successMsg := fmt.Sprintf("空投已完成！共 %d 笔交易，交易哈希:\n%s", len(msg.txHashes), views.TxLinks(msg.txHashes))
+		return model, func() tea.Msg {
+			return types.SuccessMsg{Message: successMsg, Result: msg.result}
+		}
+
+	case tea.KeyMsg:
+		key := constant.KeyboardKey(msg.String())
+
+		// 发送中只响应 ESC，用于取消
+		if c.cancel != nil {
+			if key == constant.KeyEsc {
+				c.cancel()
+			}
+			return model, nil
+		}
+		if c.guard.Active() {
+			return c.guard.Update(model, key)
+		}
+
+		switch key {
+		case constant.KeyEnter:
+			if c.done {
+				return model, nil
+			}
+			return c.guard.Guard(model, types.GlobalState.DryRun, c.resume)
+
+		case constant.KeyDiscard:
+			if c.done {
+				return model, nil
+			}
+			if err := c.journal.Discard(); err != nil {
+				return model, func() tea.Msg {
+					return types.ErrorMsg{Err: err}
+				}
+			}
+			model.Logger.Log("INFO", fmt.Sprintf("放弃未完成的空投，合约: %s", c.journal.ContractAddress))
+			return model, func() tea.Msg {
+				return types.ChangePageMsg{Page: constant.MenuPage}
+			}
+
+		case constant.KeyEsc:
+			return model, func() tea.Msg {
+				return types.ChangePageMsg{Page: constant.MenuPage}
+			}
+		}
+	}
+
+	return model, nil
+}
+
+// resume runs the rest of the unfinished airdrop in the background, or only simulates it
+// in dry-run mode
+func (c *ResumeAirdropController) resume(model types.AppModel) (interface{}, tea.Cmd) {
+	// the runner works on its own copy so the page never reads a journal being written
+	journal, err := services.LoadAirdropJournal(c.journalPath)
+	if err != nil {
+		return model, func() tea.Msg {
+			return types.ErrorMsg{Err: fmt.Errorf("读取空投记录失败: %v", err)}
+		}
+	}
+	if journal == nil {
+		return model, func() tea.Msg {
+			return types.ErrorMsg{Err: fmt.Errorf(constant.JournalMissingError, c.journalPath)}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.batches = nil
+	c.simulation = nil
+	model.Loading = true
+
+	// 模拟模式下只预演未完成的批次，不签名也不发送
+	if types.GlobalState.DryRun {
+		return model, func() tea.Msg {
+			results, err := c.nftService.SimulateJournal(ctx, journal)
+			return simulationResultMsg{results: results, err: err}
+		}
+	}
+
+	c.updates = make(chan tea.Msg)
+
+	go runAirdrop(ctx, c.nftService, journal, c.updates)
+	return model, waitForAirdrop(c.updates)
+}
+
+// View renders the resume airdrop page
+func (c *ResumeAirdropController) View() string {
+	if c.guard.Active() {
+		return c.guard.View()
+	}
+	if c.journal == nil {
+		return constant.BackToMenuMessage + "\n"
+	}
+	return views.ResumeAirdropView(c.journal, c.batches, c.simulation, types.GlobalState.DryRun, c.cancel != nil, c.done)
+}
+
+func (c *ResumeAirdropController) Name() constant.Page {
+	return constant.ResumeAirdropPage
+}
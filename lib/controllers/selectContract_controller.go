@@ -21,7 +21,7 @@ type SelectContractController struct {
 // NewSelectContractController creates a new select contract controller
 func NewSelectContractController(contractService *services.ContractCompiler) *SelectContractController {
 	// 从deployed_contracts.json读取已部署的合约地址
-	contracts, err := contractService.GetDeployedContracts()
+	contracts, err := contractService.GetContractsOnChain(types.GlobalState.ChainID)
 	if err != nil {
 		return &SelectContractController{
 			contractService: contractService,
@@ -37,6 +37,7 @@ func NewSelectContractController(contractService *services.ContractCompiler) *Se
 		choices = append(choices, types.ContractChoice{
 			Address:    contract.Address,
 			DeployTime: contract.DeployTime.Format("2006-01-02 15:04:05"),
+			Label:      views.ContractLabel(contract),
 		})
 	}
 
@@ -78,7 +79,7 @@ func (c *SelectContractController) Update(model types.AppModel, msg tea.Msg) (in
 			types.GlobalState.SelectedContract = selectedContract
 
 			// 获取选中合约的 tokenURI
-			contracts, err := c.contractService.GetDeployedContracts()
+			contracts, err := c.contractService.GetContractsOnChain(types.GlobalState.ChainID)
 			if err != nil {
 				return model, func() tea.Msg {
 					return types.ErrorMsg{Err: fmt.Errorf("获取合约信息失败: %v", err)}
@@ -110,7 +111,7 @@ func (c *SelectContractController) Update(model types.AppModel, msg tea.Msg) (in
 // View renders the menu page
 func (c *SelectContractController) View() string {
 	// 在每次渲染视图时重新读取已部署的合约信息
-	contracts, err := c.contractService.GetDeployedContracts()
+	contracts, err := c.contractService.GetContractsOnChain(types.GlobalState.ChainID)
 	if err == nil {
 		// 更新合约选择列表
 		c.choices = []types.ContractChoice{}
@@ -118,6 +119,7 @@ func (c *SelectContractController) View() string {
 			c.choices = append(c.choices, types.ContractChoice{
 				Address:    contract.Address,
 				DeployTime: contract.DeployTime.Format("2006-01-02 15:04:05"),
+				Label:      views.ContractLabel(contract),
 			})
 		}
 
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+)
+
+// networkResult stamps result with the active network, ready for a SuccessMsg or ErrorMsg
+func networkResult(result services.Result) *services.Result {
+	result = result.WithNetwork(types.GlobalState.Network, types.GlobalState.ChainID)
+	return &result
+}
+
+// describeTxError prefixes a failed transaction error for display. A cancellation before
+// anything was broadcast is reported as such, after broadcasting the service already
+// returns the pending transaction hash and the pending transactions page can replace it.
+// A signer refused for not owning the contract is explained in full.
+func describeTxError(prefix string, err error) error {
+	var pendingErr *services.TxPendingError
+	if !errors.As(err, &pendingErr) && errors.Is(err, context.Canceled) {
+		return fmt.Errorf("%s: 已取消，交易未发送", prefix)
+	}
+	if pendingErr != nil {
+		return fmt.Errorf("%s: %v，"+constant.PendingTxHint, prefix, err)
+	}
+	var notOwner *services.NotOwnerError
+	if errors.As(err, &notOwner) {
+		return fmt.Errorf("%s: "+constant.NotOwnerError, prefix, notOwner.Signer, notOwner.Contract, notOwner.Owner)
+	}
+	return fmt.Errorf("%s: %v", prefix, err)
+}
@@ -1,13 +1,9 @@
 package controllers
 
 import (
-	"fmt"
-	"os"
-	"regexp"
-	"strings"
-
 	tea "github.com/charmbracelet/bubbletea"
 	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
 	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
 	views "github.com/web3-smart-wallet/smart-contract-cli/lib/views"
 )
@@ -52,9 +48,15 @@ func (c *UploadController) Update(model types.AppModel, msg tea.Msg) (interface{
 			// m.confirmController.SetNFTID(m.airdropController.model.NFTInput)
 			// m.confirmController.SetURI(m.airdropController.model.URI)
 
-			return model, func() tea.Msg {
-				return types.ChangePageMsg{Page: constant.ConfirmPage}
-			}
+			// 切换页面后再让确认页在后台预估 gas
+			return model, tea.Sequence(
+				func() tea.Msg {
+					return types.ChangePageMsg{Page: constant.ConfirmPage}
+				},
+				func() tea.Msg {
+					return estimateRequestMsg{}
+				},
+			)
 		case constant.KeyEsc:
 			return model, func() tea.Msg {
 				return types.ChangePageMsg{Page: constant.AirdropPage}
@@ -76,37 +78,16 @@ func (c *UploadController) View() string {
 
 // parseWalletAddresses reads and validates wallet addresses from a file
 func (c *UploadController) parseWalletAddresses(filePath string) ([]string, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("读取文件失败: %v", err)
-	}
-
-	lines := strings.Split(string(content), "\n")
-
 	// 清空之前的地址列表，避免重复
 	types.GlobalState.UploadWalletAddresses = []string{}
 
-	// Ethereum address regex
-	ethAddressRegex := regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
-
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		if !ethAddressRegex.MatchString(line) {
-			return nil, fmt.Errorf("第 %d 行包含无效的以太坊地址: %s", i+1, line)
-		}
-
-		types.GlobalState.UploadWalletAddresses = append(types.GlobalState.UploadWalletAddresses, line)
-	}
-
-	if len(types.GlobalState.UploadWalletAddresses) == 0 {
-		return nil, fmt.Errorf("文件中没有找到有效的钱包地址")
+	addresses, err := services.ReadAddressFile(filePath)
+	if err != nil {
+		return nil, err
 	}
 
-	return types.GlobalState.UploadWalletAddresses, nil
+	types.GlobalState.UploadWalletAddresses = addresses
+	return addresses, nil
 }
 
 func (c *UploadController) Name() constant.Page {
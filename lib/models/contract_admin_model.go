@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// ContractAdminModel represents the data for the contract admin page
+type ContractAdminModel struct {
+	Contracts []services.DeployedContract
+	Cursor    int
+	InputMode string
+
+	// selected contract, its owner and the active signer, read when the contract is selected
+	ContractAddress string
+	Owner           string
+	Signer          string
+	ActionCursor    int
+
+	// confirmation inputs, the new owner is typed twice and renouncing needs RenouncePhrase
+	NewOwner        string
+	NewOwnerConfirm string
+	Phrase          string
+
+	// result of the last dry run
+	Simulation []services.SimulationResult
+}
+
+// NewContractAdminModel creates a new contract admin model
+func NewContractAdminModel() *ContractAdminModel {
+	return &ContractAdminModel{
+		Contracts: []services.DeployedContract{},
+		InputMode: constant.ContractSelectMode,
+	}
+}
+
+// IsOwner reports whether the active signer owns the selected contract
+func (m *ContractAdminModel) IsOwner() bool {
+	return m.Owner != "" && strings.EqualFold(m.Owner, m.Signer)
+}
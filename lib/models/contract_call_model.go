@@ -0,0 +1,54 @@
+package models
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// ContractCallModel represents the data for the call contract function page
+type ContractCallModel struct {
+	Contracts []services.DeployedContract
+	Cursor    int
+	InputMode string
+
+	// selected contract and its functions, read-only ones first
+	ContractAddress string
+	ContractABI     string
+	Reads           []abi.Method
+	Writes          []abi.Method
+	FunctionCursor  int
+
+	// one text input per parameter of the selected function, Value is the wei sent to payable functions
+	Args     []string
+	ArgIndex int
+	Value    string
+
+	// result of the last call, Outputs for read-only functions and TxHash for transactions
+	Outputs    []string
+	TxHash     string
+	Simulation []services.SimulationResult
+}
+
+// NewContractCallModel creates a new contract call model
+func NewContractCallModel() *ContractCallModel {
+	return &ContractCallModel{
+		Contracts: []services.DeployedContract{},
+		InputMode: constant.ContractSelectMode,
+	}
+}
+
+// Functions returns the read-only functions followed by the state-changing ones
+func (m *ContractCallModel) Functions() []abi.Method {
+	return append(append([]abi.Method{}, m.Reads...), m.Writes...)
+}
+
+// SelectedFunction returns the function under the cursor
+func (m *ContractCallModel) SelectedFunction() abi.Method {
+	return m.Functions()[m.FunctionCursor]
+}
+
+// IsRead reports whether the function under the cursor is read-only
+func (m *ContractCallModel) IsRead() bool {
+	return m.FunctionCursor < len(m.Reads)
+}
@@ -1,23 +1,66 @@
 package models
 
-import "github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
 
 // deployContract represents the data for the deployContract page
 type DeployContractModel struct {
 	// ShowError bool
-	URI                 string
 	AvailableContracts  []services.AvailableContract
 	SelectedContract    int  // Index of the selected contract, -1 if none selected
 	IsSelectingContract bool // Whether we're in contract selection mode
+
+	// constructor of the selected contract and one text input per parameter,
+	// Value is the wei sent to payable constructors
+	Constructor abi.Method
+	Args        []string
+	ArgIndex    int
+	Value       string
+	// Deployer is the signer address, the default for owner parameters
+	Deployer string
+
+	// Simulation is the report of the last dry-run deployment
+	Simulation []services.SimulationResult
 }
 
 // NewDeployContractModel creates a new deployContract model
 func NewDeployContractModel() *DeployContractModel {
 	return &DeployContractModel{
 		// ShowError: false,
-		URI:                 "",
 		AvailableContracts:  []services.AvailableContract{},
 		SelectedContract:    -1,
 		IsSelectingContract: true,
 	}
 }
+
+// URIArgIndex returns the index of the string constructor parameter holding the token URI, -1 if there is none
+func (m *DeployContractModel) URIArgIndex() int {
+	for i, input := range m.Constructor.Inputs {
+		if input.Type.T == abi.StringTy && strings.Contains(strings.ToLower(input.Name), "uri") {
+			return i
+		}
+	}
+	return -1
+}
+
+// ArgDefault returns the value used for constructor input i when it is left empty:
+// the deployer for owner addresses, empty otherwise
+func (m *DeployContractModel) ArgDefault(i int) string {
+	input := m.Constructor.Inputs[i]
+	if input.Type.T == abi.AddressTy && strings.Contains(strings.ToLower(input.Name), "owner") {
+		return m.Deployer
+	}
+	return ""
+}
+
+// Fields returns the number of inputs on the form, the constructor parameters plus the value for payable constructors
+func (m *DeployContractModel) Fields() int {
+	if m.Constructor.IsPayable() {
+		return len(m.Args) + 1
+	}
+	return len(m.Args)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"math/big"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// InspectModel represents the data for the inspect contract page
+type InspectModel struct {
+	Contracts []services.DeployedContract
+	Cursor    int
+	InputMode string
+
+	// query inputs
+	ContractAddress string
+	Account         string
+	TokenIDs        string
+	Operator        string
+
+	// contract overview, read when a contract is selected
+	Owner     string
+	BatchSize int
+	URI       string
+
+	// query results, Approved is nil when no operator was given
+	QueriedIDs []*big.Int
+	Balances   []*big.Int
+	Approved   *bool
+}
+
+// NewInspectModel creates a new inspect model
+func NewInspectModel() *InspectModel {
+	return &InspectModel{
+		Contracts: []services.DeployedContract{},
+		InputMode: constant.ContractSelectMode,
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// PendingTxModel represents the data for the pending transactions page
+type PendingTxModel struct {
+	Transactions []services.TrackedTx
+	Cursor       int
+	InputMode    string
+
+	// Selected is the transaction to speed up or cancel
+	Selected     services.TrackedTx
+	ActionCursor int
+}
+
+// NewPendingTxModel creates a new pending transactions model
+func NewPendingTxModel() *PendingTxModel {
+	return &PendingTxModel{
+		Transactions: []services.TrackedTx{},
+		InputMode:    constant.TxSelectMode,
+	}
+}
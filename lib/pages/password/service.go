@@ -4,13 +4,23 @@ import "fmt"
 
 type Service struct {
 	password string
+	// unlock replaces the comparison with password when set, e.g. by decrypting a keystore
+	unlock func(password string) error
 }
 
 func NewService(password string) *Service {
 	return &Service{password: password}
 }
 
+// NewUnlockService creates a service that accepts the password when unlock succeeds with it
+func NewUnlockService(unlock func(password string) error) *Service {
+	return &Service{unlock: unlock}
+}
+
 func (s *Service) VerifyPassword(password string) error {
+	if s.unlock != nil {
+		return s.unlock(password)
+	}
 	if password != s.password {
 		return fmt.Errorf("密码错误")
 	}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// JobState is where a job is in its life
+type JobState string
+
+const (
+	// JobQueued waits for the transactions in flight to finish
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+)
+
+// Job is a deployment, URI update or airdrop running in the background, polled by its ID
+type Job struct {
+	Version int      `json:"version"`
+	ID      string   `json:"id"`
+	State   JobState `json:"state"`
+	// Operation is the operation of the final result: deploy, set-uri or airdrop
+	Operation string    `json:"operation"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Contract is empty for a deployment until it is mined
+	Contract   string `json:"contract,omitempty"`
+	TokenID    string `json:"tokenId,omitempty"`
+	Recipients int    `json:"recipients,omitempty"`
+	// Journal is the file an airdrop keeps its progress in, posting the same airdrop again
+	// after a restart resumes it
+	Journal string `json:"journal,omitempty"`
+	// Batches is 0 until the airdrop is planned, BatchesDone counts the batches reported so far
+	Batches     int `json:"batches"`
+	BatchesDone int `json:"batchesDone"`
+	// BatchResults has one airdrop.batch result per reported batch, in order
+	BatchResults []services.Result `json:"batchResults"`
+	// Result is the deploy, set-uri or airdrop result once the job is done
+	Result *services.Result `json:"result,omitempty"`
+
+	// mu guards the job, it is updated while sending and read by requests
+	mu sync.Mutex
+}
+
+// jobList is the answer of GET /v1/jobs
+type jobList struct {
+	Version int    `json:"version"`
+	Jobs    []*Job `json:"jobs"`
+}
+
+// start records that the job is sending, an airdrop after it was planned into batches
+func (j *Job) start(batches int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.State, j.Batches, j.UpdatedAt = JobRunning, batches, time.Now()
+}
+
+// batch records the result of one batch
+func (j *Job) batch(result services.Result) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.BatchResults = append(j.BatchResults, result)
+	if result.OK {
+		j.BatchesDone++
+	}
+	j.UpdatedAt = time.Now()
+}
+
+// finish records the result of the job
+func (j *Job) finish(result services.Result) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.State = JobSucceeded
+	if !result.OK {
+		j.State = JobFailed
+	}
+	if j.Contract == "" {
+		j.Contract = result.Contract
+	}
+	j.Result, j.UpdatedAt = &result, time.Now()
+}
+
+// snapshot copies the job so it can be encoded while it goes on
+func (j *Job) snapshot() *Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return &Job{
+		Version:      j.Version,
+		ID:           j.ID,
+		State:        j.State,
+		Operation:    j.Operation,
+		CreatedAt:    j.CreatedAt,
+		UpdatedAt:    j.UpdatedAt,
+		Contract:     j.Contract,
+		TokenID:      j.TokenID,
+		Recipients:   j.Recipients,
+		Journal:      j.Journal,
+		Batches:      j.Batches,
+		BatchesDone:  j.BatchesDone,
+		BatchResults: slices.Clone(j.BatchResults),
+		Result:       j.Result,
+	}
+}
+
+// jobStore keeps the jobs of this server run in memory, the airdrop journals are what
+// survives a restart
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func newJobStore() *jobStore {
+	return &jobStore{jobs: make(map[string]*Job)}
+}
+
+// add queues job, filling in its ID and state
+func (s *jobStore) add(job *Job) *Job {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	now := time.Now()
+	job.Version = services.ResultVersion
+	job.ID = hex.EncodeToString(id)
+	job.State = JobQueued
+	job.CreatedAt, job.UpdatedAt = now, now
+	job.BatchResults = []services.Result{}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return job
+}
+
+// get returns the job with id
+func (s *jobStore) get(id string) (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+// list snapshots every job, newest first
+func (s *jobStore) list() []*Job {
+	s.mu.Lock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.mu.Unlock()
+
+	snapshots := make([]*Job, len(jobs))
+	for i, job := range jobs {
+		snapshots[i] = job.snapshot()
+	}
+	slices.SortFunc(snapshots, func(a, b *Job) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return snapshots
+}
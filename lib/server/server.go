@@ -0,0 +1,397 @@
+// Package server exposes the NFT operations over a local HTTP JSON API for back-office
+// tools. Every request needs the server's bearer token. Deploy, set-uri and airdrops are
+// validated, then run as jobs on the server's context whose progress is polled.
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// DefaultJournalDir is where the server keeps one journal per airdrop
+const DefaultJournalDir = "airdrop_journals"
+
+// maxRequestBody bounds request bodies, an airdrop of 10k recipients is about 450KB
+const maxRequestBody = 4 << 20
+
+// errBadRequest wraps invalid requests, they are answered with 400 and ErrorCodeUsage
+var errBadRequest = errors.New("invalid request")
+
+// errNotFound wraps requests for unknown jobs and contracts
+var errNotFound = errors.New("not found")
+
+// badRequest reports an invalid request
+func badRequest(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errBadRequest, fmt.Sprintf(format, args...))
+}
+
+// Server serves the API for one network and signer
+type Server struct {
+	nftService       *services.NftService
+	contractCompiler *services.ContractCompiler
+	network          services.NetworkProfile
+	token            string
+	journalDir       string
+
+	// sendMu serializes everything that signs, the signer has one nonce sequence
+	sendMu sync.Mutex
+	jobs   *jobStore
+
+	// ctx stops running jobs on Close, their journal keeps what was sent
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a server sending with nftService on network. Requests must carry token as
+// a bearer token, airdrop jobs keep their journals in journalDir.
+func New(nftService *services.NftService, contractCompiler *services.ContractCompiler, network services.NetworkProfile, token string, journalDir string) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		nftService:       nftService,
+		contractCompiler: contractCompiler,
+		network:          network,
+		token:            token,
+		journalDir:       journalDir,
+		jobs:             newJobStore(),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// Close cancels the queued and running jobs and waits for them to stop
+func (s *Server) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1/contracts", s.route(services.OperationContractsList, s.listContracts))
+	mux.Handle("POST /v1/deploy", s.route(services.OperationDeploy, s.deploy))
+	mux.Handle("POST /v1/set-uri", s.route(services.OperationSetURI, s.setURI))
+	mux.Handle("POST /v1/airdrops", s.route(services.OperationAirdrop, s.startAirdrop))
+	mux.Handle("GET /v1/jobs", s.route(services.OperationJob, s.listJobs))
+	mux.Handle("GET /v1/jobs/{id}", s.route(services.OperationJob, s.getJob))
+	return mux
+}
+
+// handlerFunc answers a request with a status and a JSON body, or an error
+type handlerFunc func(r *http.Request) (int, any, error)
+
+// route checks the token and writes what handler returns, errors as a failed Result of operation
+func (s *Server) route(operation string, handler handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			result := services.NewErrorResult(operation, errors.New("missing or wrong bearer token"))
+			result.Error.Code = services.ErrorCodeUnauthorized
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, s.stamp(result))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+		status, body, err := handler(r)
+		if err != nil {
+			status, result := errorResult(operation, err)
+			writeJSON(w, status, s.stamp(result))
+			return
+		}
+		writeJSON(w, status, body)
+	})
+}
+
+// authorized compares the bearer token in constant time
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// stamp sets the network of the server on result
+func (s *Server) stamp(result services.Result) services.Result {
+	return result.WithNetwork(s.network.Name, s.network.ChainID)
+}
+
+// errorResult maps err to its HTTP status and failed Result
+func errorResult(operation string, err error) (int, services.Result) {
+	result := services.NewErrorResult(operation, err)
+	switch {
+	case errors.Is(err, errBadRequest):
+		result.Error.Code = services.ErrorCodeUsage
+		return http.StatusBadRequest, result
+	case errors.Is(err, errNotFound):
+		result.Error.Code = services.ErrorCodeNotFound
+		return http.StatusNotFound, result
+	}
+	switch result.Error.Code {
+	case services.ErrorCodeNotOwner:
+		return http.StatusForbidden, result
+	case services.ErrorCodeCancelled:
+		// the client went away before anything was sent
+		return http.StatusServiceUnavailable, result
+	}
+	return http.StatusBadGateway, result
+}
+
+// writeJSON writes body with status
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// decode reads the JSON request body into v, unknown fields are rejected so typos fail
+func decode(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return badRequest("invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// listContracts lists the registry on the server's chain, ?all=true on every chain
+func (s *Server) listContracts(r *http.Request) (int, any, error) {
+	chainID := s.network.ChainID
+	if r.URL.Query().Get("all") == "true" {
+		chainID = 0
+	}
+	contracts, err := s.contractCompiler.GetContractsOnChain(chainID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, s.stamp(services.NewContractsResult(contracts)), nil
+}
+
+// deployRequest deploys a contract of contracts/ by name
+type deployRequest struct {
+	// Contract is the contractName of an artifact in contracts/
+	Contract string `json:"contract"`
+	// URI fills the constructor's URI parameter when Args is empty, the owner is the signer
+	URI  string   `json:"uri"`
+	Args []string `json:"args"`
+	// Value is the wei sent to a payable constructor, as a decimal string
+	Value string `json:"value"`
+}
+
+// deploy validates a deployment and queues it as a job, the contract is recorded once
+// the deployment is mined
+func (s *Server) deploy(r *http.Request) (int, any, error) {
+	var request deployRequest
+	if err := decode(r, &request); err != nil {
+		return 0, nil, err
+	}
+	if request.Contract == "" {
+		return 0, nil, badRequest("contract is required")
+	}
+	var value *big.Int
+	if request.Value != "" {
+		var ok bool
+		if value, ok = new(big.Int).SetString(request.Value, 10); !ok || value.Sign() < 0 {
+			return 0, nil, badRequest("invalid value %q, expected wei", request.Value)
+		}
+	}
+
+	available, err := s.contractCompiler.GetAvailableContracts()
+	if err != nil {
+		return 0, nil, err
+	}
+	var artifact *services.AvailableContract
+	for i := range available {
+		if available[i].ContractName == request.Contract {
+			artifact = &available[i]
+			break
+		}
+	}
+	if artifact == nil {
+		return 0, nil, fmt.Errorf("%w: no artifact named %s in contracts/", errNotFound, request.Contract)
+	}
+
+	constructor, err := services.ConstructorOf(artifact.ABI)
+	if err != nil {
+		return 0, nil, err
+	}
+	signer, err := s.nftService.SignerAddress()
+	if err != nil {
+		return 0, nil, err
+	}
+	args := request.Args
+	if len(args) == 0 {
+		if args, err = services.DefaultConstructorArgs(constructor, request.URI, signer); err != nil {
+			return 0, nil, badRequest("%v", err)
+		}
+	}
+	if _, err := services.ParseABIArguments(constructor, args); err != nil {
+		return 0, nil, badRequest("%v", err)
+	}
+
+	return s.startJob(&Job{Operation: services.OperationDeploy}, func(job *Job) services.Result {
+		job.start(0)
+		contract, err := s.contractCompiler.DeployArtifact(s.ctx, s.nftService, *artifact, args, value, s.network.Name)
+		if err != nil {
+			result := services.NewErrorResult(services.OperationDeploy, err)
+			result.Contract = contract.Address
+			return result
+		}
+		return services.NewDeployResult(contract)
+	})
+}
+
+// setURIRequest sets the token URI of a contract
+type setURIRequest struct {
+	Contract string `json:"contract"`
+	URI      string `json:"uri"`
+}
+
+// setURI checks the signer owns the contract and queues setURI as a job
+func (s *Server) setURI(r *http.Request) (int, any, error) {
+	var request setURIRequest
+	if err := decode(r, &request); err != nil {
+		return 0, nil, err
+	}
+	if !common.IsHexAddress(request.Contract) || request.URI == "" {
+		return 0, nil, badRequest("contract must be an address and uri is required")
+	}
+	contract := common.HexToAddress(request.Contract).Hex()
+
+	// 不是 owner 时直接拒绝，不必排队
+	if err := s.nftService.CheckOwner(r.Context(), contract); err != nil {
+		return 0, nil, err
+	}
+
+	return s.startJob(&Job{Operation: services.OperationSetURI, Contract: contract}, func(job *Job) services.Result {
+		job.start(0)
+		txHash, err := s.nftService.CallContractFunction(s.ctx, services.SetURIParams(contract, request.URI))
+		if err != nil {
+			result := services.NewErrorResult(services.OperationSetURI, err)
+			result.Contract = contract
+			return result
+		}
+		return services.NewSetURIResult(contract, request.URI, txHash)
+	})
+}
+
+// airdropRequest mints TokenID to every recipient, setting URI first when given
+type airdropRequest struct {
+	Contract   string   `json:"contract"`
+	TokenID    string   `json:"tokenId"`
+	Recipients []string `json:"recipients"`
+	URI        string   `json:"uri"`
+}
+
+// startAirdrop validates an airdrop and queues it as a job, the answer is the job to poll
+func (s *Server) startAirdrop(r *http.Request) (int, any, error) {
+	var request airdropRequest
+	if err := decode(r, &request); err != nil {
+		return 0, nil, err
+	}
+	if !common.IsHexAddress(request.Contract) {
+		return 0, nil, badRequest("contract must be an address")
+	}
+	if id, ok := new(big.Int).SetString(request.TokenID, 10); !ok || id.Sign() < 0 {
+		return 0, nil, badRequest("tokenId must be a decimal number")
+	}
+	if len(request.Recipients) == 0 {
+		return 0, nil, badRequest("recipients is empty")
+	}
+	for _, recipient := range request.Recipients {
+		if !common.IsHexAddress(recipient) {
+			return 0, nil, badRequest("invalid recipient %q", recipient)
+		}
+	}
+	request.Contract = common.HexToAddress(request.Contract).Hex()
+
+	// 不是 owner 时直接拒绝，不必排队
+	if err := s.nftService.CheckOwner(r.Context(), request.Contract); err != nil {
+		return 0, nil, err
+	}
+
+	job := &Job{
+		Operation:  services.OperationAirdrop,
+		Contract:   request.Contract,
+		TokenID:    request.TokenID,
+		Recipients: len(request.Recipients),
+		Journal:    s.journalPathOf(request),
+	}
+	return s.startJob(job, func(job *Job) services.Result {
+		return s.runAirdrop(job, request)
+	})
+}
+
+// journalPathOf names the journal of request after a hash of the airdrop and the chain, so
+// posting the same airdrop again resumes it and an unfinished one never blocks another
+func (s *Server) journalPathOf(request airdropRequest) string {
+	data, _ := json.Marshal(struct {
+		ChainID uint64         `json:"chainId"`
+		Airdrop airdropRequest `json:"airdrop"`
+	}{s.network.ChainID, request})
+	sum := sha256.Sum256(data)
+	return filepath.Join(s.journalDir, "airdrop_"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// runAirdrop runs the airdrop of job, resuming its journal when an earlier attempt of the
+// same airdrop did not finish
+func (s *Server) runAirdrop(job *Job, request airdropRequest) services.Result {
+	failed := func(err error) services.Result {
+		result := services.NewErrorResult(services.OperationAirdrop, err)
+		result.Contract = request.Contract
+		return result
+	}
+	if err := os.MkdirAll(s.journalDir, 0755); err != nil {
+		return failed(fmt.Errorf("failed to create journal directory: %v", err))
+	}
+	journal, _, err := s.nftService.ResumeOrPlanAirdrop(s.ctx, job.Journal, request.Contract, request.Recipients, request.TokenID, request.URI)
+	if err != nil {
+		return failed(err)
+	}
+
+	job.start(len(journal.Batches))
+	txHashes, err := s.nftService.RunAirdrop(s.ctx, journal, func(status services.BatchStatus) {
+		job.batch(s.stamp(services.NewBatchResult(journal, status)))
+	})
+	return services.NewAirdropResult(journal, txHashes, err)
+}
+
+// startJob queues job and runs it in the background on the server's context once nothing
+// else is sending, so a client that goes away never stops a transaction half way. The
+// answer is the job to poll.
+func (s *Server) startJob(job *Job, run func(job *Job) services.Result) (int, any, error) {
+	job = s.jobs.add(job)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.sendMu.Lock()
+		defer s.sendMu.Unlock()
+		job.finish(s.stamp(run(job)))
+	}()
+	return http.StatusAccepted, job.snapshot(), nil
+}
+
+// listJobs lists every job of this server run, newest first
+func (s *Server) listJobs(r *http.Request) (int, any, error) {
+	return http.StatusOK, jobList{Version: services.ResultVersion, Jobs: s.jobs.list()}, nil
+}
+
+// getJob returns the progress of one job
+func (s *Server) getJob(r *http.Request) (int, any, error) {
+	job, ok := s.jobs.get(r.PathValue("id"))
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: no job %s", errNotFound, r.PathValue("id"))
+	}
+	return http.StatusOK, job.snapshot(), nil
+}
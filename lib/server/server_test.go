@@ -0,0 +1,324 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/suite"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+const (
+	hardhatPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	hardhatAddress    = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+	otherPrivateKey   = "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+	otherAddress      = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+	testToken         = "test-token-0123456789"
+)
+
+// autoCommitBackend mines a block after every transaction so bind.WaitMined returns right away
+type autoCommitBackend struct {
+	simulated.Client
+	sim *simulated.Backend
+}
+
+func (b *autoCommitBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.sim.Commit()
+	return nil
+}
+
+type ServerTestSuite struct {
+	suite.Suite
+	Backend    *simulated.Backend
+	NftService *services.NftService
+	Server     *Server
+	HTTP       *httptest.Server
+	workDir    string
+}
+
+// the API is driven over HTTP against an in-process simulated chain
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+func (s *ServerTestSuite) SetupTest() {
+	artifact, err := os.ReadFile("../services/testdata/MyToken.json")
+	s.Require().NoError(err)
+
+	// the registry and journals live in the working directory, contracts/ holds the artifact
+	s.workDir, err = os.Getwd()
+	s.Require().NoError(err)
+	dir := s.T().TempDir()
+	s.Require().NoError(os.Chdir(dir))
+	s.Require().NoError(os.Mkdir("contracts", 0755))
+	s.Require().NoError(os.WriteFile(filepath.Join("contracts", "MyToken.json"), artifact, 0644))
+
+	balance, _ := new(big.Int).SetString("10000000000000000000000", 10)
+	s.Backend = simulated.NewBackend(types.GenesisAlloc{
+		common.HexToAddress(hardhatAddress): {Balance: balance},
+		common.HexToAddress(otherAddress):   {Balance: balance},
+	})
+	s.NftService = s.newService(hardhatPrivateKey)
+	s.Server, s.HTTP = s.newServer(s.NftService)
+}
+
+func (s *ServerTestSuite) TearDownTest() {
+	s.HTTP.Close()
+	s.Server.Close()
+	s.Require().NoError(s.Backend.Close())
+	s.Require().NoError(os.Chdir(s.workDir))
+}
+
+// newService creates a service on the simulated chain signing with privateKey
+func (s *ServerTestSuite) newService(privateKey string) *services.NftService {
+	return services.NewNftServiceWithBackend(&autoCommitBackend{Client: s.Backend.Client(), sim: s.Backend}, privateKey)
+}
+
+// newServer serves the API of service over httptest
+func (s *ServerTestSuite) newServer(service *services.NftService) (*Server, *httptest.Server) {
+	network := services.NetworkProfile{Name: "simulated", ChainID: 1337}
+	server := New(service, services.NewContractCompiler("./artifacts"), network, testToken, DefaultJournalDir)
+	return server, httptest.NewServer(server.Handler())
+}
+
+// do sends a request with the token and decodes the JSON answer into out
+func (s *ServerTestSuite) do(base string, method string, path string, body any, out any) int {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		s.Require().NoError(err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	request, err := http.NewRequest(method, base+path, reader)
+	s.Require().NoError(err)
+	request.Header.Set("Authorization", "Bearer "+testToken)
+
+	response, err := http.DefaultClient.Do(request)
+	s.Require().NoError(err)
+	defer response.Body.Close()
+	s.Require().Equal("application/json", response.Header.Get("Content-Type"))
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(out))
+	return response.StatusCode
+}
+
+// wait polls the job with id until it is done and returns it
+func (s *ServerTestSuite) wait(base string, id string) *Job {
+	job := &Job{}
+	s.Require().Eventually(func() bool {
+		s.Require().Equal(http.StatusOK, s.do(base, http.MethodGet, "/v1/jobs/"+id, nil, job))
+		return job.State == JobSucceeded || job.State == JobFailed
+	}, 10*time.Second, 20*time.Millisecond)
+	return job
+}
+
+// deploy deploys MyToken over the API and returns its address
+func (s *ServerTestSuite) deploy() string {
+	var job Job
+	status := s.do(s.HTTP.URL, http.MethodPost, "/v1/deploy", map[string]any{
+		"contract": "MyToken",
+		"uri":      "https://api.example.com/init/{id}",
+	}, &job)
+	s.Require().Equal(http.StatusAccepted, status)
+	done := s.wait(s.HTTP.URL, job.ID)
+	s.Require().Equal(JobSucceeded, done.State, "%+v", done.Result)
+	return done.Result.Deployment.Address
+}
+
+func (s *ServerTestSuite) TestRejectsMissingToken() {
+	for _, header := range []string{"", "Bearer wrong-token", testToken} {
+		request, err := http.NewRequest(http.MethodGet, s.HTTP.URL+"/v1/contracts", nil)
+		s.Require().NoError(err)
+		if header != "" {
+			request.Header.Set("Authorization", header)
+		}
+		response, err := http.DefaultClient.Do(request)
+		s.Require().NoError(err)
+
+		var result services.Result
+		s.Require().NoError(json.NewDecoder(response.Body).Decode(&result))
+		response.Body.Close()
+		s.Equal(http.StatusUnauthorized, response.StatusCode)
+		s.Equal(services.ErrorCodeUnauthorized, result.Error.Code)
+	}
+}
+
+func (s *ServerTestSuite) TestDeployAndList() {
+	var job Job
+	status := s.do(s.HTTP.URL, http.MethodPost, "/v1/deploy", map[string]any{
+		"contract": "MyToken",
+		"uri":      "https://api.example.com/init/{id}",
+	}, &job)
+	s.Require().Equal(http.StatusAccepted, status)
+	s.Equal(services.OperationDeploy, job.Operation)
+	s.Empty(job.Contract)
+
+	done := s.wait(s.HTTP.URL, job.ID)
+	s.Require().Equal(JobSucceeded, done.State, "%+v", done.Result)
+	result := done.Result
+	s.Equal(result.Deployment.Address, done.Contract)
+	s.Equal(services.ResultVersion, result.Version)
+	s.Equal(services.OperationDeploy, result.Operation)
+	s.Equal("simulated", result.Network)
+	s.Equal(uint64(1337), result.ChainID)
+	s.Equal(hardhatAddress, result.Deployment.Deployer)
+	s.Equal("https://api.example.com/init/{id}", result.Deployment.TokenURI)
+	s.NotEmpty(result.Deployment.TxHash)
+
+	var listing services.Result
+	s.Require().Equal(http.StatusOK, s.do(s.HTTP.URL, http.MethodGet, "/v1/contracts", nil, &listing))
+	s.Require().Len(listing.Registry.Contracts, 1)
+	s.Equal(result.Deployment.Address, listing.Registry.Contracts[0].Address)
+	s.Equal("MyToken", listing.Registry.Contracts[0].ContractName)
+
+	// unknown artifacts and fields are refused before anything is sent
+	var failed services.Result
+	s.Equal(http.StatusNotFound, s.do(s.HTTP.URL, http.MethodPost, "/v1/deploy", map[string]any{"contract": "Missing"}, &failed))
+	s.Equal(services.ErrorCodeNotFound, failed.Error.Code)
+	s.Equal(http.StatusBadRequest, s.do(s.HTTP.URL, http.MethodPost, "/v1/deploy", map[string]any{"contract": "MyToken", "url": "typo"}, &failed))
+	s.Equal(services.ErrorCodeUsage, failed.Error.Code)
+}
+
+func (s *ServerTestSuite) TestSetURI() {
+	contract := s.deploy()
+
+	var job Job
+	status := s.do(s.HTTP.URL, http.MethodPost, "/v1/set-uri", map[string]any{
+		"contract": contract,
+		"uri":      "https://api.example.com/v2/{id}",
+	}, &job)
+	s.Require().Equal(http.StatusAccepted, status)
+	s.Equal(services.OperationSetURI, job.Operation)
+	done := s.wait(s.HTTP.URL, job.ID)
+	s.Require().Equal(JobSucceeded, done.State, "%+v", done.Result)
+	s.NotEmpty(done.Result.URIUpdate.TxHash)
+
+	uri, err := s.NftService.URI(context.Background(), contract, big.NewInt(0))
+	s.Require().NoError(err)
+	s.Equal("https://api.example.com/v2/{id}", uri)
+
+	// a server signing with another account is refused before queueing
+	_, other := s.newServer(s.newService(otherPrivateKey))
+	defer other.Close()
+	var result services.Result
+	status = s.do(other.URL, http.MethodPost, "/v1/set-uri", map[string]any{
+		"contract": contract,
+		"uri":      "https://api.example.com/stolen/{id}",
+	}, &result)
+	s.Equal(http.StatusForbidden, status)
+	s.Equal(services.ErrorCodeNotOwner, result.Error.Code)
+}
+
+func (s *ServerTestSuite) TestAirdropJob() {
+	contract := s.deploy()
+	recipients := []string{otherAddress, "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC", "0x90F79bf6EB2c4f870365E785982E1f101E93b906"}
+
+	var job Job
+	status := s.do(s.HTTP.URL, http.MethodPost, "/v1/airdrops", map[string]any{
+		"contract":   contract,
+		"tokenId":    "7",
+		"recipients": recipients,
+		"uri":        "https://api.example.com/drop/{id}",
+	}, &job)
+	s.Require().Equal(http.StatusAccepted, status)
+	s.NotEmpty(job.ID)
+	s.Equal(3, job.Recipients)
+	s.Equal(DefaultJournalDir, filepath.Dir(job.Journal))
+
+	done := s.wait(s.HTTP.URL, job.ID)
+	s.Require().Equal(JobSucceeded, done.State, "%+v", done.Result)
+	s.Equal(done.Batches, done.BatchesDone)
+	s.Len(done.BatchResults, done.Batches)
+	s.Equal(services.OperationAirdrop, done.Result.Operation)
+	s.Len(done.Result.Airdrop.TxHashes, done.Batches)
+
+	for _, recipient := range recipients {
+		balance, err := s.NftService.BalanceOf(context.Background(), contract, recipient, big.NewInt(7))
+		s.Require().NoError(err)
+		s.Equal(int64(1), balance.Int64())
+	}
+
+	// the deployment is a job too, the airdrop is the newest
+	var jobs jobList
+	s.Require().Equal(http.StatusOK, s.do(s.HTTP.URL, http.MethodGet, "/v1/jobs", nil, &jobs))
+	s.Require().Len(jobs.Jobs, 2)
+	s.Equal(job.ID, jobs.Jobs[0].ID)
+
+	var result services.Result
+	s.Equal(http.StatusNotFound, s.do(s.HTTP.URL, http.MethodGet, "/v1/jobs/unknown", nil, &result))
+	s.Equal(services.ErrorCodeNotFound, result.Error.Code)
+}
+
+func (s *ServerTestSuite) TestUnfinishedAirdropDoesNotBlockOthers() {
+	contract := s.deploy()
+	post := func(tokenID string) string {
+		var job Job
+		status := s.do(s.HTTP.URL, http.MethodPost, "/v1/airdrops", map[string]any{
+			"contract":   contract,
+			"tokenId":    tokenID,
+			"recipients": []string{otherAddress},
+		}, &job)
+		s.Require().Equal(http.StatusAccepted, status)
+		return job.ID
+	}
+
+	// an airdrop interrupted before its first batch was sent
+	request := airdropRequest{Contract: contract, TokenID: "1", Recipients: []string{otherAddress}}
+	s.Require().NoError(os.MkdirAll(DefaultJournalDir, 0755))
+	journalPath := s.Server.journalPathOf(request)
+	unfinished := services.NewAirdropJournal(journalPath, contract, "1", "", [][]string{{otherAddress}})
+	s.Require().NoError(unfinished.Save())
+
+	// another airdrop runs on its own journal
+	job := s.wait(s.HTTP.URL, post("2"))
+	s.Require().Equal(JobSucceeded, job.State, "%+v", job.Result)
+	s.NotEqual(journalPath, job.Journal)
+
+	// posting the interrupted one again resumes it
+	job = s.wait(s.HTTP.URL, post("1"))
+	s.Require().Equal(JobSucceeded, job.State, "%+v", job.Result)
+	s.Equal(journalPath, job.Journal)
+	resumed, err := services.LoadAirdropJournal(job.Journal)
+	s.Require().NoError(err)
+	s.True(resumed.Finished())
+	s.Equal(unfinished.CreatedAt.Unix(), resumed.CreatedAt.Unix())
+}
+
+func (s *ServerTestSuite) TestAirdropValidation() {
+	contract := s.deploy()
+
+	var result services.Result
+	status := s.do(s.HTTP.URL, http.MethodPost, "/v1/airdrops", map[string]any{
+		"contract":   contract,
+		"tokenId":    "7",
+		"recipients": []string{"not-an-address"},
+	}, &result)
+	s.Equal(http.StatusBadRequest, status)
+	s.Equal(services.ErrorCodeUsage, result.Error.Code)
+
+	// only the owner can mint, no job is queued
+	_, other := s.newServer(s.newService(otherPrivateKey))
+	defer other.Close()
+	status = s.do(other.URL, http.MethodPost, "/v1/airdrops", map[string]any{
+		"contract":   contract,
+		"tokenId":    "7",
+		"recipients": []string{hardhatAddress},
+	}, &result)
+	s.Equal(http.StatusForbidden, status)
+	s.Equal(services.ErrorCodeNotOwner, result.Error.Code)
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ContractFunctions lists the functions of a contract ABI, split into read-only
+// (view and pure) and state-changing ones, each sorted by name
+func ContractFunctions(abiJSON string) (reads []abi.Method, writes []abi.Method, err error) {
+	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse contract ABI: %v", err)
+	}
+
+	for _, method := range parsedABI.Methods {
+		if method.IsConstant() {
+			reads = append(reads, method)
+		} else {
+			writes = append(writes, method)
+		}
+	}
+	sort.Slice(reads, func(i, j int) bool { return reads[i].Sig < reads[j].Sig })
+	sort.Slice(writes, func(i, j int) bool { return writes[i].Sig < writes[j].Sig })
+	return reads, writes, nil
+}
+
+// ParseABIArguments converts one text input per method parameter into the Go values
+// go-ethereum packs for those types
+func ParseABIArguments(method abi.Method, inputs []string) ([]any, error) {
+	if len(inputs) != len(method.Inputs) {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", method.Sig, len(method.Inputs), len(inputs))
+	}
+
+	args := make([]any, len(inputs))
+	for i, input := range method.Inputs {
+		value, err := ParseABIValue(input.Type, inputs[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s %s): %v", i+1, input.Type, input.Name, err)
+		}
+		args[i] = value
+	}
+	return args, nil
+}
+
+// ParseABIValue converts text into the Go value go-ethereum packs for t. Scalars are
+// written as is: decimal or 0x hex numbers, true/false, 0x hex bytes. Arrays and tuples
+// are JSON arrays whose elements follow the same rules, e.g. ["0xabc...", 5, [1, 2]].
+func ParseABIValue(t abi.Type, input string) (any, error) {
+	value, err := parseABIValue(t, strings.TrimSpace(input))
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+// parseABIValue returns input as a reflect.Value of exactly t.GetType(), so it can be
+// stored inside the slices and structs go-ethereum expects for composite types
+func parseABIValue(t abi.Type, input string) (reflect.Value, error) {
+	switch t.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(input) {
+			return reflect.Value{}, fmt.Errorf("invalid address %q", input)
+		}
+		return reflect.ValueOf(common.HexToAddress(input)), nil
+
+	case abi.IntTy, abi.UintTy:
+		return parseABIInteger(t, input)
+
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(input)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bool %q, use true or false", input)
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.StringTy:
+		return reflect.ValueOf(input), nil
+
+	case abi.BytesTy:
+		data, err := decodeHexInput(input)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(data), nil
+
+	case abi.FixedBytesTy:
+		data, err := decodeHexInput(input)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(data) != t.Size {
+			return reflect.Value{}, fmt.Errorf("expected %d bytes, got %d", t.Size, len(data))
+		}
+		value := reflect.New(t.GetType()).Elem()
+		reflect.Copy(value, reflect.ValueOf(data))
+		return value, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		elements, err := splitJSONArray(input)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		var value reflect.Value
+		if t.T == abi.ArrayTy {
+			if len(elements) != t.Size {
+				return reflect.Value{}, fmt.Errorf("expected %d elements, got %d", t.Size, len(elements))
+			}
+			value = reflect.New(t.GetType()).Elem()
+		} else {
+			value = reflect.MakeSlice(t.GetType(), len(elements), len(elements))
+		}
+		for i, element := range elements {
+			item, err := parseABIValue(*t.Elem, element)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			value.Index(i).Set(item)
+		}
+		return value, nil
+
+	case abi.TupleTy:
+		elements, err := splitJSONArray(input)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(elements) != len(t.TupleElems) {
+			return reflect.Value{}, fmt.Errorf("expected %d tuple fields, got %d", len(t.TupleElems), len(elements))
+		}
+		value := reflect.New(t.GetType()).Elem()
+		for i, element := range elements {
+			field, err := parseABIValue(*t.TupleElems[i], element)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("field %s: %v", t.TupleRawNames[i], err)
+			}
+			value.Field(i).Set(field)
+		}
+		return value, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+}
+
+// parseABIInteger parses a decimal or 0x hex integer and checks it fits t
+func parseABIInteger(t abi.Type, input string) (reflect.Value, error) {
+	n, ok := new(big.Int).SetString(input, 0)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("invalid integer %q", input)
+	}
+
+	minValue, maxValue := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(t.Size))
+	if t.T == abi.IntTy {
+		maxValue.Rsh(maxValue, 1)
+		minValue.Neg(maxValue)
+	}
+	if n.Cmp(minValue) < 0 || n.Cmp(maxValue) >= 0 {
+		return reflect.Value{}, fmt.Errorf("%s out of range for %s", input, t)
+	}
+
+	// go-ethereum uses native integers up to 64 bits and *big.Int above that
+	goType := t.GetType()
+	switch goType.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.ValueOf(n.Int64()).Convert(goType), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.ValueOf(n.Uint64()).Convert(goType), nil
+	}
+	return reflect.ValueOf(n), nil
+}
+
+// decodeHexInput decodes 0x prefixed hex, an empty input is empty bytes
+func decodeHexInput(input string) ([]byte, error) {
+	if input == "" || input == "0x" {
+		return []byte{}, nil
+	}
+	data, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex bytes %q: %v", input, err)
+	}
+	return data, nil
+}
+
+// splitJSONArray splits a JSON array into the text of its elements, JSON strings are unquoted
+func splitJSONArray(input string) ([]string, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(input), &raw); err != nil {
+		return nil, fmt.Errorf("expected a JSON array like [1, 2]: %v", err)
+	}
+
+	elements := make([]string, len(raw))
+	for i, element := range raw {
+		var s string
+		if err := json.Unmarshal(element, &s); err == nil {
+			elements[i] = s
+			continue
+		}
+		elements[i] = strings.TrimSpace(string(element))
+	}
+	return elements, nil
+}
+
+// FormatABIValue renders a value decoded by go-ethereum for display: addresses and
+// bytes as hex, arrays as [a, b] and tuples as (a, b)
+func FormatABIValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case common.Address:
+		return v.Hex()
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return hexutil.Encode(v)
+	case string:
+		return strconv.Quote(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(data), rv)
+			return hexutil.Encode(data)
+		}
+		return formatABIList(rv)
+	case reflect.Slice:
+		return formatABIList(rv)
+	case reflect.Struct:
+		fields := make([]string, rv.NumField())
+		for i := range fields {
+			fields[i] = FormatABIValue(rv.Field(i).Interface())
+		}
+		return "(" + strings.Join(fields, ", ") + ")"
+	}
+	return fmt.Sprint(value)
+}
+
+// formatABIList renders the elements of an array or slice as [a, b]
+func formatABIList(rv reflect.Value) string {
+	items := make([]string, rv.Len())
+	for i := range items {
+		items[i] = FormatABIValue(rv.Index(i).Interface())
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// ConstructorOf returns the constructor of a contract ABI, a constructor without inputs
+// when the ABI declares none
+func ConstructorOf(abiJSON string) (abi.Method, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return abi.Method{}, fmt.Errorf("failed to parse contract ABI: %v", err)
+	}
+	return parsedABI.Constructor, nil
+}
+
+// ConstructorURI returns the argument of the constructor's token URI parameter, if it has one
+func ConstructorURI(constructor abi.Method, args []string) string {
+	for i, input := range constructor.Inputs {
+		if isURIInput(input) && i < len(args) {
+			return args[i]
+		}
+	}
+	return ""
+}
+
+// DefaultConstructorArgs fills the constructor like the deploy page's defaults: the URI
+// parameter with uri and owner addresses with owner. Other parameters have no default.
+func DefaultConstructorArgs(constructor abi.Method, uri string, owner string) ([]string, error) {
+	args := make([]string, len(constructor.Inputs))
+	for i, input := range constructor.Inputs {
+		switch {
+		case isURIInput(input):
+			if uri == "" {
+				return nil, fmt.Errorf("a URI is required for constructor parameter %s", input.Name)
+			}
+			args[i] = uri
+		case input.Type.T == abi.AddressTy && strings.Contains(strings.ToLower(input.Name), "owner"):
+			args[i] = owner
+		default:
+			return nil, fmt.Errorf("constructor parameter %s (%s) has no default, pass every argument", input.Name, input.Type)
+		}
+	}
+	return args, nil
+}
+
+// isURIInput reports whether a constructor parameter takes the token URI
+func isURIInput(input abi.Argument) bool {
+	return input.Type.T == abi.StringTy && strings.Contains(strings.ToLower(input.Name), "uri")
+}
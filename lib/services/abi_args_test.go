@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// abiType builds an ABI type, with components for tuples
+func abiType(t *testing.T, name string, components ...abi.ArgumentMarshaling) abi.Type {
+	typ, err := abi.NewType(name, "", components)
+	require.NoError(t, err)
+	return typ
+}
+
+func TestParseABIValue(t *testing.T) {
+	tests := []struct {
+		typ   string
+		input string
+		want  any
+	}{
+		{"address", OtherAddress, common.HexToAddress(OtherAddress)},
+		{"uint256", "1000000000000000000000", new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)},
+		{"uint256", "0xff", big.NewInt(255)},
+		{"int128", "-5", big.NewInt(-5)},
+		{"uint8", "255", uint8(255)},
+		{"int64", "-42", int64(-42)},
+		{"bool", "true", true},
+		{"string", "hello world", "hello world"},
+		{"bytes", "0xdeadbeef", []byte{0xde, 0xad, 0xbe, 0xef}},
+		{"bytes", "", []byte{}},
+		{"bytes4", "0x01020304", [4]byte{1, 2, 3, 4}},
+		{"uint256[]", "[1, \"2\", \"0x03\"]", []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}},
+		{"address[2]", "[\"" + OtherAddress + "\", \"" + HardhatAddress + "\"]", [2]common.Address{common.HexToAddress(OtherAddress), common.HexToAddress(HardhatAddress)}},
+		{"bool[][]", "[[true], [false, true]]", [][]bool{{true}, {false, true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.input, func(t *testing.T) {
+			got, err := ParseABIValue(abiType(t, tt.typ), tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseABIValueTuple(t *testing.T) {
+	typ := abiType(t, "tuple",
+		abi.ArgumentMarshaling{Name: "to", Type: "address"},
+		abi.ArgumentMarshaling{Name: "ids", Type: "uint256[]"},
+		abi.ArgumentMarshaling{Name: "memo", Type: "string"},
+	)
+
+	value, err := ParseABIValue(typ, `["`+OtherAddress+`", [1, 2], "gift"]`)
+	require.NoError(t, err)
+
+	// the value packs, so its Go type is the one go-ethereum expects
+	args := abi.Arguments{{Type: typ}}
+	packed, err := args.Pack(value)
+	require.NoError(t, err)
+	unpacked, err := args.Unpack(packed)
+	require.NoError(t, err)
+	require.Equal(t, "("+OtherAddress+", [1, 2], \"gift\")", FormatABIValue(unpacked[0]))
+}
+
+func TestParseABIValueRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		typ   string
+		input string
+	}{
+		{"address", "0x1234"},
+		{"uint8", "256"},
+		{"uint256", "-1"},
+		{"int8", "128"},
+		{"int8", "-129"},
+		{"uint256", "abc"},
+		{"bool", "yes please"},
+		{"bytes", "0xabc"},
+		{"bytes4", "0x0102"},
+		{"uint256[]", "1, 2"},
+		{"uint256[2]", "[1]"},
+		{"address[]", "[\"0x1234\"]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.input, func(t *testing.T) {
+			_, err := ParseABIValue(abiType(t, tt.typ), tt.input)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFormatABIValue(t *testing.T) {
+	require.Equal(t, OtherAddress, FormatABIValue(common.HexToAddress(OtherAddress)))
+	require.Equal(t, "42", FormatABIValue(big.NewInt(42)))
+	require.Equal(t, "0x0102", FormatABIValue([]byte{1, 2}))
+	require.Equal(t, "0x01020304", FormatABIValue([4]byte{1, 2, 3, 4}))
+	require.Equal(t, "[1, 2]", FormatABIValue([]*big.Int{big.NewInt(1), big.NewInt(2)}))
+	require.Equal(t, "true", FormatABIValue(true))
+	require.Equal(t, "7", FormatABIValue(uint8(7)))
+}
+
+func (s *NftServiceTestSuite) TestCallFunctionFromTextInputs() {
+	contractAddress := s.deployContract()
+	ctx := context.Background()
+
+	reads, writes, err := ContractFunctions(s.AbiJSON)
+	s.Require().NoError(err)
+	method := func(methods []abi.Method, sig string) abi.Method {
+		for _, m := range methods {
+			if m.Sig == sig {
+				return m
+			}
+		}
+		s.FailNow("missing method", sig)
+		return abi.Method{}
+	}
+	setApproval := method(writes, "setApprovalForAll(address,bool)")
+	isApproved := method(reads, "isApprovedForAll(address,address)")
+
+	args, err := ParseABIArguments(setApproval, []string{OtherAddress, "true"})
+	s.Require().NoError(err)
+	_, err = s.NftService.CallContractFunction(ctx, ContractCallParams{
+		ContractAddress: contractAddress,
+		ContractABI:     s.AbiJSON,
+		FunctionName:    setApproval.Name,
+		FunctionArgs:    args,
+	})
+	s.Require().NoError(err)
+
+	args, err = ParseABIArguments(isApproved, []string{HardhatAddress, OtherAddress})
+	s.Require().NoError(err)
+	outputs, err := s.NftService.CallView(ctx, ContractCallParams{
+		ContractAddress: contractAddress,
+		ContractABI:     s.AbiJSON,
+		FunctionName:    isApproved.Name,
+		FunctionArgs:    args,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(outputs, 1)
+	s.Require().Equal("true", FormatABIValue(outputs[0]))
+
+	_, err = ParseABIArguments(isApproved, []string{HardhatAddress})
+	s.Require().Error(err)
+}
+
+func TestDefaultConstructorArgs(t *testing.T) {
+	artifact, err := LoadAvailableContract(AbiPath)
+	require.NoError(t, err)
+	constructor, err := ConstructorOf(artifact.ABI)
+	require.NoError(t, err)
+
+	args, err := DefaultConstructorArgs(constructor, "https://example.com/{id}.json", HardhatAddress)
+	require.NoError(t, err)
+	require.Equal(t, []string{HardhatAddress, "https://example.com/{id}.json"}, args)
+	require.Equal(t, "https://example.com/{id}.json", ConstructorURI(constructor, args))
+
+	// the URI has no default
+	_, err = DefaultConstructorArgs(constructor, "", HardhatAddress)
+	require.Error(t, err)
+}
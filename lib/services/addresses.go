@@ -0,0 +1,38 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ethAddressRegex matches a 0x prefixed 20 byte hex address
+var ethAddressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// ReadAddressFile reads wallet addresses from a file with one address per line, blank
+// lines are skipped and any other line that is not an address is an error
+func ReadAddressFile(filePath string) ([]string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	var addresses []string
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if !ethAddressRegex.MatchString(line) {
+			return nil, fmt.Errorf("第 %d 行包含无效的以太坊地址: %s", i+1, line)
+		}
+		addresses = append(addresses, line)
+	}
+
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("文件中没有找到有效的钱包地址")
+	}
+	return addresses, nil
+}
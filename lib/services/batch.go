@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BatchStatus reports the outcome of one airdrop batch
+type BatchStatus struct {
+	// Index is the 1-based position of the batch
+	Index int
+	// Total is the number of batches in the airdrop
+	Total int
+	// Recipients is the number of addresses minted to by the batch
+	Recipients int
+	// TxHash is empty when the batch failed before anything was broadcast
+	TxHash string
+	Err    error
+}
+
+// splitBatches cuts addresses into consecutive chunks of at most size addresses
+func splitBatches(addresses []string, size int) [][]string {
+	batches := make([][]string, 0, (len(addresses)+size-1)/size)
+	for start := 0; start < len(addresses); start += size {
+		end := min(start+size, len(addresses))
+		batches = append(batches, addresses[start:end])
+	}
+	return batches
+}
+
+// mintBatches splits addresses by the contract's batchSize and builds one mintToMultple call per batch
+func (s *NftService) mintBatches(ctx context.Context, contractAddr string, addresses []string, nftID string) ([]ContractCallParams, error) {
+	journal, err := s.PlanAirdrop(ctx, "", contractAddr, addresses, nftID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]ContractCallParams, len(journal.Batches))
+	for i, batch := range journal.Batches {
+		calls[i], err = mintParams(contractAddr, batch.Recipients, nftID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return calls, nil
+}
+
+// PlanAirdrop splits addresses by the contract's batchSize into a journal saved at
+// journalPath. Nothing is written or sent until RunAirdrop is called.
+func (s *NftService) PlanAirdrop(ctx context.Context, journalPath string, contractAddr string, addresses []string, nftID string, uri string) (*AirdropJournal, error) {
+	// 先校验参数，避免无效输入也去请求节点
+	if _, err := mintParams(contractAddr, addresses, nftID); err != nil {
+		return nil, err
+	}
+
+	chainID, err := s.VerifyChain(ctx)
+	if err != nil {
+		return nil, err
+	}
+	size, err := s.BatchSize(ctx, contractAddr)
+	if err != nil {
+		return nil, err
+	}
+	journal := NewAirdropJournal(journalPath, contractAddr, nftID, uri, splitBatches(addresses, size))
+	journal.ChainID = chainID.Uint64()
+	return journal, nil
+}
+
+// UnfinishedAirdropError is returned when the journal records an unfinished airdrop
+// other than the one requested, it has to be finished or discarded first
+type UnfinishedAirdropError struct {
+	ContractAddress string
+	Path            string
+}
+
+func (e *UnfinishedAirdropError) Error() string {
+	return fmt.Sprintf("an unfinished airdrop of contract %s is recorded in %s, finish or discard it first", e.ContractAddress, e.Path)
+}
+
+// JournalChainError is returned when a journal planned on one chain would be run on
+// another, its signed batches and recipients belong to the chain it was planned on
+type JournalChainError struct {
+	JournalChainID uint64
+	ChainID        uint64
+}
+
+func (e *JournalChainError) Error() string {
+	return fmt.Sprintf("the airdrop journal was planned on chain %d but the node is on chain %d, switch back to that network or discard it", e.JournalChainID, e.ChainID)
+}
+
+// ResumeOrPlanAirdrop returns the unfinished journal at journalPath when it records the
+// same airdrop, so a retry never mints twice, and plans a new one when there is none.
+// resumed reports which happened.
+func (s *NftService) ResumeOrPlanAirdrop(ctx context.Context, journalPath string, contractAddr string, addresses []string, nftID string, uri string) (journal *AirdropJournal, resumed bool, err error) {
+	journal, err = LoadAirdropJournal(journalPath)
+	if err != nil {
+		return nil, false, err
+	}
+	if journal != nil && !journal.Finished() {
+		if !journal.Matches(contractAddr, addresses, nftID, uri) {
+			return nil, false, &UnfinishedAirdropError{ContractAddress: journal.ContractAddress, Path: journalPath}
+		}
+		if err := s.checkJournalChain(ctx, journal); err != nil {
+			return nil, false, err
+		}
+		return journal, true, nil
+	}
+	journal, err = s.PlanAirdrop(ctx, journalPath, contractAddr, addresses, nftID, uri)
+	return journal, false, err
+}
+
+// RunAirdrop sets the URI and mints every batch of journal that is not mined yet,
+// saving the journal before and after every transaction. Batches left signed by an
+// interrupted run are looked up on chain first and only sent again when the node
+// never saw them, so resuming never mints a batch twice.
+func (s *NftService) RunAirdrop(ctx context.Context, journal *AirdropJournal, onBatch func(BatchStatus)) ([]string, error) {
+	// setURI and mintToMultple are onlyOwner, refuse before anything is recorded or sent
+	if !journal.Finished() {
+		if err := s.checkJournalChain(ctx, journal); err != nil {
+			return nil, err
+		}
+		if err := s.CheckOwner(ctx, journal.ContractAddress); err != nil {
+			return nil, err
+		}
+	}
+	if err := journal.Save(); err != nil {
+		return nil, err
+	}
+
+	if !journal.URISet {
+		if err := s.runSetURI(ctx, journal); err != nil {
+			return nil, fmt.Errorf("failed to set URI: %w", err)
+		}
+	}
+
+	return s.runBatches(ctx, journal, onBatch)
+}
+
+// runSetURI sets the URI of journal. The signed transaction is recorded before it is
+// broadcast, so a resumed run waits for it like for a signed batch instead of sending
+// another one at a new nonce.
+func (s *NftService) runSetURI(ctx context.Context, journal *AirdropJournal) error {
+	if journal.URIRawTx != "" {
+		receipt, err := s.resumeTransaction(ctx, journal.URIRawTx, "", "setURI")
+		switch {
+		case err == nil:
+			journal.URISet, journal.URITxHash = true, receipt.TxHash.Hex()
+			return journal.Save()
+		case errors.Is(err, errTxDropped):
+			// the node never saw it and its nonce is free again, sign it anew below
+		default:
+			return s.recordSetURIError(journal, err)
+		}
+	}
+
+	txHash, err := s.callContractFunction(ctx, SetURIParams(journal.ContractAddress, journal.URI), func(tx *types.Transaction) error {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		journal.URITxHash = tx.Hash().Hex()
+		journal.URIRawTx = hexutil.Encode(raw)
+		return journal.Save()
+	})
+	if err != nil {
+		return s.recordSetURIError(journal, err)
+	}
+
+	journal.URISet, journal.URITxHash = true, txHash
+	return journal.Save()
+}
+
+// recordSetURIError forgets a setURI transaction that reverted or was cancelled so the
+// next run signs a new one, any other may still be mined and is looked up again on resume
+func (s *NftService) recordSetURIError(journal *AirdropJournal, err error) error {
+	var revertErr *TxRevertedError
+	var replacedErr *TxReplacedError
+	if !errors.As(err, &revertErr) && !errors.As(err, &replacedErr) {
+		return err
+	}
+	journal.URITxHash, journal.URIRawTx = "", ""
+	if saveErr := journal.Save(); saveErr != nil {
+		return fmt.Errorf("%w (%v)", err, saveErr)
+	}
+	return err
+}
+
+// checkJournalChain fails when journal was planned on another chain than the node is on.
+// A journal written before the chain was recorded is assigned the current one.
+func (s *NftService) checkJournalChain(ctx context.Context, journal *AirdropJournal) error {
+	chainID, err := s.VerifyChain(ctx)
+	if err != nil {
+		return err
+	}
+	if journal.ChainID == 0 {
+		journal.ChainID = chainID.Uint64()
+	} else if journal.ChainID != chainID.Uint64() {
+		return &JournalChainError{JournalChainID: journal.ChainID, ChainID: chainID.Uint64()}
+	}
+	return nil
+}
+
+// runBatches sends the batches of journal in order and stops at the first one that fails
+func (s *NftService) runBatches(ctx context.Context, journal *AirdropJournal, onBatch func(BatchStatus)) ([]string, error) {
+	for i := range journal.Batches {
+		batch := &journal.Batches[i]
+		status := BatchStatus{
+			Index:      i + 1,
+			Total:      len(journal.Batches),
+			Recipients: len(batch.Recipients),
+		}
+
+		err := s.runBatch(ctx, journal, batch)
+		status.TxHash, status.Err = batch.TxHash, err
+		if onBatch != nil {
+			onBatch(status)
+		}
+		if err != nil {
+			return journal.MinedTxHashes(), fmt.Errorf("batch %d/%d failed: %w", status.Index, status.Total, err)
+		}
+	}
+	return journal.MinedTxHashes(), nil
+}
+
+// runBatch brings one batch to the mined state, recording every step in the journal
+func (s *NftService) runBatch(ctx context.Context, journal *AirdropJournal, batch *JournalBatch) error {
+	if batch.State == BatchMined {
+		return nil
+	}
+
+	if batch.State == BatchSigned {
+		receipt, err := s.resumeTransaction(ctx, batch.RawTx, "", "mintToMultple")
+		switch {
+		case err == nil:
+			// a speed-up may have been mined in place of the signed transaction
+			batch.State, batch.TxHash, batch.Error = BatchMined, receipt.TxHash.Hex(), ""
+			return journal.Save()
+		case errors.Is(err, errTxDropped):
+			// the node never saw it and its nonce is free again, sign it anew below
+		default:
+			return s.recordBatchError(journal, batch, err)
+		}
+	}
+
+	params, err := mintParams(journal.ContractAddress, batch.Recipients, journal.NFTID)
+	if err != nil {
+		return err
+	}
+
+	txHash, err := s.callContractFunction(ctx, params, func(tx *types.Transaction) error {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		batch.State = BatchSigned
+		batch.TxHash = tx.Hash().Hex()
+		batch.RawTx = hexutil.Encode(raw)
+		batch.Error = ""
+		return journal.Save()
+	})
+	if err != nil {
+		return s.recordBatchError(journal, batch, err)
+	}
+
+	batch.State, batch.TxHash, batch.Error = BatchMined, txHash, ""
+	return journal.Save()
+}
+
+// recordBatchError stores why a batch stopped. A signed batch stays signed unless it
+// reverted or was cancelled, it may still be mined and is looked up again on resume.
+func (s *NftService) recordBatchError(journal *AirdropJournal, batch *JournalBatch, err error) error {
+	var revertErr *TxRevertedError
+	var replacedErr *TxReplacedError
+	if batch.State != BatchSigned || errors.As(err, &revertErr) || errors.As(err, &replacedErr) {
+		batch.State = BatchFailed
+	}
+	batch.Error = err.Error()
+	if saveErr := journal.Save(); saveErr != nil {
+		return fmt.Errorf("%w (%v)", err, saveErr)
+	}
+	return err
+}
+
+// errTxDropped is returned by resumeTransaction when a transaction was never mined and
+// can no longer be, so it is safe to send a new one in its place
+var errTxDropped = errors.New("transaction was dropped")
+
+// resumeTransaction waits for a transaction signed by an earlier run or on another machine.
+// It is looked up by hash first, with the replacements tracked at its nonce, and broadcast
+// from rawTx when the node knows none of them, which cannot send twice because the
+// broadcast reuses the original nonce. label names it in the tracker.
+func (s *NftService) resumeTransaction(ctx context.Context, rawTx string, contractABI string, label string) (receipt *types.Receipt, err error) {
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil && !errors.Is(err, errTxDropped) {
+			s.markUnhealthy()
+		}
+	}()
+
+	tx, fromAddress, err := decodeSignedTx(rawTx)
+	if err != nil {
+		return nil, err
+	}
+
+	sendCtx, cancelSend := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelSend()
+
+	receipt, err = s.trackedReceipt(sendCtx, cfg, client, tx)
+	var replacedErr *TxReplacedError
+	if errors.Is(err, ethereum.NotFound) {
+		known, err := s.trackedKnown(sendCtx, cfg, client, tx)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			if cfg.tracker != nil {
+				if err := cfg.tracker.record(label, fromAddress, tx, AttemptOriginal); err != nil {
+					return nil, err
+				}
+			}
+			if err := client.SendTransaction(sendCtx, tx); err != nil {
+				// the nonce was used by another transaction, look once more in case it was this one
+				receipt, err = s.trackedReceipt(sendCtx, cfg, client, tx)
+				if errors.As(err, &replacedErr) && replacedErr.ReplacedBy != "" {
+					return nil, err
+				} else if err != nil {
+					return nil, errTxDropped
+				}
+			}
+		}
+	} else if errors.As(err, &replacedErr) && replacedErr.ReplacedBy == "" {
+		// the nonce was used by a transaction sent elsewhere
+		return nil, errTxDropped
+	} else if err != nil {
+		return nil, err
+	}
+
+	if receipt == nil {
+		receipt, err = s.waitMined(ctx, cfg, client, tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	replayCtx, cancelReplay := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelReplay()
+	if err := checkReceipt(replayCtx, client, receipt, toCallMsg(fromAddress, tx), contractABI); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// decodeSignedTx decodes a hex encoded signed transaction and recovers its sender
+func decodeSignedTx(rawTx string) (*types.Transaction, common.Address, error) {
+	raw, err := hexutil.Decode(rawTx)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("invalid signed transaction: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, common.Address{}, fmt.Errorf("invalid signed transaction: %v", err)
+	}
+	fromAddress, err := types.Sender(types.NewLondonSigner(tx.ChainId()), tx)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("invalid signed transaction: %v", err)
+	}
+	return tx, fromAddress, nil
+}
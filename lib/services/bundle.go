@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Kinds of transaction bundles
+const (
+	BundleDeploy  = "deploy"
+	BundleAirdrop = "airdrop"
+)
+
+// TxBundle carries transactions through offline signing. PrepareDeploy or PrepareAirdrop
+// fill in nonce, fees and chain ID on a machine with RPC access, SignBundle signs them on
+// a machine without one and BroadcastBundle sends them. Every step writes the same file,
+// which is the only thing that moves between the machines.
+type TxBundle struct {
+	Kind    string `json:"kind"`
+	ChainID string `json:"chainId"`
+	// From is the account the transactions are prepared for, only its key can sign them
+	From string `json:"from"`
+	// ContractABI, TokenURI and the rest of the provenance are recorded with a deployed
+	// contract once it is mined
+	ContractABI     string     `json:"contractAbi,omitempty"`
+	TokenURI        string     `json:"tokenUri,omitempty"`
+	ContractName    string     `json:"contractName,omitempty"`
+	ConstructorArgs []string   `json:"constructorArgs,omitempty"`
+	BytecodeHash    string     `json:"bytecodeHash,omitempty"`
+	Transactions    []BundleTx `json:"transactions"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+
+	// path is where the bundle is saved, empty keeps it in memory only
+	path string
+}
+
+// BundleTx is one transaction of a bundle, State moves from planned to signed to mined
+type BundleTx struct {
+	// Label names the transaction, e.g. "deploy" or "mintToMultple 2/3"
+	Label string     `json:"label"`
+	State BatchState `json:"state"`
+	// Tx is the unsigned transaction written by prepare
+	Tx *types.Transaction `json:"tx"`
+	// RawTx is the signed transaction written by sign
+	RawTx           string `json:"rawTx,omitempty"`
+	TxHash          string `json:"txHash,omitempty"`
+	BlockNumber     uint64 `json:"blockNumber,omitempty"`
+	ContractAddress string `json:"contractAddress,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// bundleCall is a transaction to be priced into a bundle
+type bundleCall struct {
+	label    string
+	msg      ethereum.CallMsg
+	gasLimit uint64
+	policy   FeePolicy
+}
+
+// LoadTxBundle reads the bundle at path
+func LoadTxBundle(path string) (*TxBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %v", err)
+	}
+
+	bundle := &TxBundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle %s: %v", path, err)
+	}
+	if _, ok := new(big.Int).SetString(bundle.ChainID, 10); !ok {
+		return nil, fmt.Errorf("invalid chain ID %q in bundle %s", bundle.ChainID, path)
+	}
+	if !common.IsHexAddress(bundle.From) {
+		return nil, fmt.Errorf("invalid from address %q in bundle %s", bundle.From, path)
+	}
+	for i, tx := range bundle.Transactions {
+		if tx.Tx == nil {
+			return nil, fmt.Errorf("transaction %d of bundle %s is missing", i+1, path)
+		}
+	}
+	bundle.path = path
+	return bundle, nil
+}
+
+// Save writes the bundle, replacing the previous version atomically
+func (b *TxBundle) Save() error {
+	if b.path == "" {
+		return nil
+	}
+
+	b.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle: %v", err)
+	}
+	if err := writeFileAtomic(b.path, data); err != nil {
+		return fmt.Errorf("failed to write bundle: %v", err)
+	}
+	return nil
+}
+
+// DeployedContract returns the registry record of the contract a mined deploy
+// transaction of the bundle created on the named network
+func (b *TxBundle) DeployedContract(tx BundleTx, network string) DeployedContract {
+	chainID, _ := new(big.Int).SetString(b.ChainID, 10)
+	return DeployedContract{
+		Address:         tx.ContractAddress,
+		TokenURI:        b.TokenURI,
+		Abi:             b.ContractABI,
+		DeployBlock:     tx.BlockNumber,
+		ChainID:         chainID.Uint64(),
+		Network:         network,
+		ContractName:    b.ContractName,
+		Deployer:        b.From,
+		DeployTx:        tx.TxHash,
+		ConstructorArgs: b.ConstructorArgs,
+		BytecodeHash:    b.BytecodeHash,
+	}
+}
+
+// Finished reports whether every transaction of the bundle was mined
+func (b *TxBundle) Finished() bool {
+	for _, tx := range b.Transactions {
+		if tx.State != BatchMined {
+			return false
+		}
+	}
+	return true
+}
+
+// PrepareDeploy prices the deployment described by params as an unsigned transaction from
+// the from address and saves it as a bundle at path. Nothing is signed, from only needs to
+// be the address of the key that will sign the bundle. The token URI, contract name and
+// constructor arguments of contract are recorded with the contract once it is mined.
+func (s *NftService) PrepareDeploy(ctx context.Context, path string, from string, params DeployContractParams, contract DeployedContract) (*TxBundle, error) {
+	if !common.IsHexAddress(from) {
+		return nil, fmt.Errorf("invalid from address %q", from)
+	}
+	fromAddress := common.HexToAddress(from)
+
+	msg, params, err := packDeployment(fromAddress, params)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := s.prepareBundle(ctx, path, BundleDeploy, fromAddress, []bundleCall{{
+		label:    "deploy",
+		msg:      msg,
+		gasLimit: params.GasLimit,
+		policy: FeePolicy{
+			MaxFeePerGas:         params.MaxFeePerGas,
+			MaxPriorityFeePerGas: params.MaxPriorityFeePerGas,
+		},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	bundle.ContractABI = params.ConstructorABI
+	bundle.TokenURI = contract.TokenURI
+	bundle.ContractName = contract.ContractName
+	bundle.ConstructorArgs = contract.ConstructorArgs
+	if bundle.BytecodeHash, err = BytecodeHash(params.Bytecode); err != nil {
+		return nil, err
+	}
+	return bundle, bundle.Save()
+}
+
+// PrepareAirdrop prices every transaction RunAirdrop would send for these inputs, setURI
+// when uri is not empty and one mintToMultple per batch, as unsigned transactions from the
+// from address with consecutive nonces and saves them as a bundle at path
+func (s *NftService) PrepareAirdrop(ctx context.Context, path string, from string, contractAddr string, addresses []string, nftID string, uri string) (*TxBundle, error) {
+	if !common.IsHexAddress(from) {
+		return nil, fmt.Errorf("invalid from address %q", from)
+	}
+	fromAddress := common.HexToAddress(from)
+
+	// setURI and mintToMultple are onlyOwner, a bundle from another account would only revert
+	if err := s.checkOwner(ctx, contractAddr, fromAddress.Hex()); err != nil {
+		return nil, err
+	}
+
+	var calls []ContractCallParams
+	var labels []string
+	if uri != "" {
+		calls = append(calls, SetURIParams(contractAddr, uri))
+		labels = append(labels, "setURI")
+	}
+	mints, err := s.mintBatches(ctx, contractAddr, addresses, nftID)
+	if err != nil {
+		return nil, err
+	}
+	for i, params := range mints {
+		calls = append(calls, params)
+		labels = append(labels, fmt.Sprintf("mintToMultple %d/%d", i+1, len(mints)))
+	}
+
+	bundleCalls := make([]bundleCall, len(calls))
+	for i, params := range calls {
+		msg, err := packContractCall(fromAddress, params)
+		if err != nil {
+			return nil, err
+		}
+		bundleCalls[i] = bundleCall{label: labels[i], msg: msg, gasLimit: params.GasLimit}
+	}
+
+	bundle, err := s.prepareBundle(ctx, path, BundleAirdrop, fromAddress, bundleCalls)
+	if err != nil {
+		return nil, err
+	}
+	return bundle, bundle.Save()
+}
+
+// prepareBundle builds one unsigned transaction per call with consecutive nonces starting
+// at the pending nonce of fromAddress
+func (s *NftService) prepareBundle(ctx context.Context, path string, kind string, fromAddress common.Address, calls []bundleCall) (bundle *TxBundle, err error) {
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	prepareCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancel()
+
+	chainID, err := client.ChainID(prepareCtx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkChainID(chainID); err != nil {
+		return nil, err
+	}
+	nonce, err := client.PendingNonceAt(prepareCtx, fromAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle = &TxBundle{
+		Kind:         kind,
+		ChainID:      chainID.String(),
+		From:         fromAddress.Hex(),
+		Transactions: make([]BundleTx, len(calls)),
+		CreatedAt:    time.Now(),
+		path:         path,
+	}
+	for i, call := range calls {
+		fees, err := suggestFees(prepareCtx, client, cfg.feePolicy.merge(call.policy))
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := estimateGasLimit(prepareCtx, client, call.msg, call.gasLimit, cfg.gasMultiplier)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", call.label, err)
+		}
+		bundle.Transactions[i] = BundleTx{
+			Label: call.label,
+			State: BatchPlanned,
+			Tx:    newTransaction(chainID, nonce+uint64(i), call.msg.To, call.msg.Value, gasLimit, call.msg.Data, fees),
+		}
+	}
+	return bundle, nil
+}
+
+// SignBundle signs every planned transaction of bundle with signer and saves it. It makes
+// no RPC calls, so it runs on a machine without network access.
+func SignBundle(ctx context.Context, bundle *TxBundle, signer Signer) error {
+	if signer.Address() != common.HexToAddress(bundle.From) {
+		return fmt.Errorf("bundle was prepared for %s, the signer is %s", bundle.From, signer.Address().Hex())
+	}
+	chainID, ok := new(big.Int).SetString(bundle.ChainID, 10)
+	if !ok {
+		return fmt.Errorf("invalid chain ID %q in bundle", bundle.ChainID)
+	}
+
+	for i := range bundle.Transactions {
+		btx := &bundle.Transactions[i]
+		if btx.State != BatchPlanned {
+			continue
+		}
+		if btx.Tx.Type() != types.LegacyTxType && btx.Tx.ChainId().Cmp(chainID) != 0 {
+			return fmt.Errorf("%s is for chain %s, the bundle for chain %s", btx.Label, btx.Tx.ChainId(), chainID)
+		}
+
+		signed, err := signer.SignTx(ctx, btx.Tx, chainID)
+		if err != nil {
+			return fmt.Errorf("failed to sign %s: %w", btx.Label, err)
+		}
+		raw, err := signed.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		btx.State = BatchSigned
+		btx.RawTx = hexutil.Encode(raw)
+		btx.TxHash = signed.Hash().Hex()
+		btx.Error = ""
+	}
+	return bundle.Save()
+}
+
+// BroadcastBundle sends the signed transactions of bundle in order and waits for each one
+// to be mined, saving the bundle after every transaction. Mined transactions are skipped,
+// so an interrupted broadcast resumes by running it again. onTx, when not nil, is called
+// after every transaction.
+func (s *NftService) BroadcastBundle(ctx context.Context, bundle *TxBundle, onTx func(BundleTx)) error {
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	chainCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	chainID, err := client.ChainID(chainCtx)
+	cancel()
+	if err != nil {
+		s.markUnhealthy()
+		return err
+	}
+	if err := s.checkChainID(chainID); err != nil {
+		return err
+	}
+	if chainID.String() != bundle.ChainID {
+		return fmt.Errorf("bundle is for chain %s, the node is on chain %s", bundle.ChainID, chainID)
+	}
+
+	for i := range bundle.Transactions {
+		btx := &bundle.Transactions[i]
+		switch btx.State {
+		case BatchMined:
+			continue
+		case BatchPlanned:
+			return fmt.Errorf("%s is not signed yet", btx.Label)
+		case BatchFailed:
+			return fmt.Errorf("%s failed, prepare a new bundle: %s", btx.Label, btx.Error)
+		}
+
+		receipt, err := s.resumeTransaction(ctx, btx.RawTx, bundle.ContractABI, btx.Label)
+		if err == nil {
+			btx.State, btx.TxHash, btx.Error = BatchMined, receipt.TxHash.Hex(), ""
+			btx.BlockNumber = receipt.BlockNumber.Uint64()
+			if btx.Tx.To() == nil {
+				btx.ContractAddress = receipt.ContractAddress.Hex()
+			}
+		} else {
+			var revertErr *TxRevertedError
+			var replacedErr *TxReplacedError
+			if errors.Is(err, errTxDropped) {
+				err = fmt.Errorf("nonce %d was used by another transaction", btx.Tx.Nonce())
+				btx.State = BatchFailed
+			} else if errors.As(err, &revertErr) || errors.As(err, &replacedErr) {
+				btx.State = BatchFailed
+			}
+			btx.Error = err.Error()
+		}
+
+		if saveErr := bundle.Save(); saveErr != nil && err == nil {
+			err = saveErr
+		}
+		if onTx != nil {
+			onTx(*btx)
+		}
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", btx.Label, err)
+		}
+	}
+	return nil
+}
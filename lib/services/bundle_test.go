@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func (s *NftServiceTestSuite) TestOfflineAirdropBundle() {
+	ctx := context.Background()
+	contractAddress := s.deployContract()
+	path := filepath.Join(s.T().TempDir(), "airdrop.bundle.json")
+	addresses := []string{OtherAddress, "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"}
+
+	// the online machine has no key, only the address it prepares for
+	online := NewNftServiceWithSigner("", nil)
+	online.backend = s.NftService.backend
+	_, err := online.PrepareAirdrop(ctx, path, HardhatAddress, contractAddress, addresses, "7", "https://api.example.com/offline/{id}")
+	s.Require().NoError(err)
+
+	bundle, err := LoadTxBundle(path)
+	s.Require().NoError(err)
+	s.Require().Len(bundle.Transactions, 2)
+	s.Require().Equal("setURI", bundle.Transactions[0].Label)
+	s.Require().Equal(bundle.Transactions[0].Tx.Nonce()+1, bundle.Transactions[1].Tx.Nonce())
+
+	// the offline machine signs without any RPC
+	other, err := NewPrivateKeySigner(OtherPrivateKey)
+	s.Require().NoError(err)
+	s.Require().ErrorContains(SignBundle(ctx, bundle, other), "prepared for")
+
+	signer, err := NewPrivateKeySigner(HardhatPrivateKey)
+	s.Require().NoError(err)
+	s.Require().NoError(SignBundle(ctx, bundle, signer))
+
+	bundle, err = LoadTxBundle(path)
+	s.Require().NoError(err)
+	s.Require().Equal(BatchSigned, bundle.Transactions[1].State)
+
+	var broadcast []string
+	s.Require().NoError(online.BroadcastBundle(ctx, bundle, func(tx BundleTx) {
+		broadcast = append(broadcast, tx.Label)
+	}))
+	s.Require().Equal([]string{"setURI", "mintToMultple 1/1"}, broadcast)
+	s.Require().True(bundle.Finished())
+	for _, address := range addresses {
+		s.Require().Equal(int64(1), s.balanceOf(contractAddress, address, 7).Int64())
+	}
+
+	// broadcasting again skips what was mined
+	bundle, err = LoadTxBundle(path)
+	s.Require().NoError(err)
+	s.Require().NoError(online.BroadcastBundle(ctx, bundle, nil))
+	s.Require().Equal(int64(1), s.balanceOf(contractAddress, OtherAddress, 7).Int64())
+}
+
+func (s *NftServiceTestSuite) TestOfflineDeployBundle() {
+	ctx := context.Background()
+	path := filepath.Join(s.T().TempDir(), "deploy.bundle.json")
+	// receipts are only looked up once the fresh chain has indexed its first block
+	s.Backend.Commit()
+
+	bundle, err := s.NftService.PrepareDeploy(ctx, path, HardhatAddress, DeployContractParams{
+		Bytecode:        s.AbiInfo.Bytecode,
+		ConstructorABI:  s.AbiJSON,
+		ConstructorArgs: []any{common.HexToAddress(HardhatAddress), "https://api.example.com/bundle/{id}"},
+	}, DeployedContract{ContractName: "MyToken", TokenURI: "https://api.example.com/bundle/{id}"})
+	s.Require().NoError(err)
+	s.Require().Nil(bundle.Transactions[0].Tx.To())
+
+	// a bundle that was not signed is not sent
+	s.Require().ErrorContains(s.NftService.BroadcastBundle(ctx, bundle, nil), "not signed")
+
+	signer, err := NewPrivateKeySigner(HardhatPrivateKey)
+	s.Require().NoError(err)
+	s.Require().NoError(SignBundle(ctx, bundle, signer))
+	s.Require().NoError(s.NftService.BroadcastBundle(ctx, bundle, nil))
+
+	deployed := bundle.Transactions[0]
+	s.Require().Equal(BatchMined, deployed.State)
+	code, err := s.Client.CodeAt(ctx, common.HexToAddress(deployed.ContractAddress), nil)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(code)
+
+	record := bundle.DeployedContract(deployed, "simulated")
+	s.Require().Equal(uint64(1337), record.ChainID)
+	s.Require().Equal("MyToken", record.ContractName)
+	s.Require().Equal(deployed.TxHash, record.DeployTx)
+	s.Require().NotEmpty(record.BytecodeHash)
+
+	// the broadcast result lists the mined transaction
+	result := NewBundleResult(OperationBundleBroadcast, path, bundle, nil)
+	s.Require().Equal(uint64(1337), result.ChainID)
+	s.Require().Equal(deployed.TxHash, result.Bundle.Transactions[0].TxHash)
+	s.Require().Equal(deployed.ContractAddress, result.Bundle.Transactions[0].ContractAddress)
+	s.Require().Empty(result.Bundle.Transactions[0].To)
+}
@@ -1,12 +1,17 @@
 package services
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 type ContractArtifact struct {
@@ -19,8 +24,30 @@ type DeployedContract struct {
 	TokenURI   string    `json:"tokenURI"`
 	Abi        string    `json:"abi"`
 	DeployTime time.Time `json:"deploy_time"`
+	// DeployBlock is where log scans start, 0 for contracts saved before it was recorded
+	DeployBlock uint64 `json:"deploy_block,omitempty"`
+
+	// ChainID is the chain the contract lives on, 0 for contracts saved before it was
+	// recorded until ClaimUnassignedContracts finds their code on a chain
+	ChainID uint64 `json:"chain_id,omitempty"`
+	// Network is the name of the network profile it was deployed or claimed on
+	Network         string   `json:"network,omitempty"`
+	ContractName    string   `json:"contract_name,omitempty"`
+	Deployer        string   `json:"deployer,omitempty"`
+	DeployTx        string   `json:"deploy_tx,omitempty"`
+	ConstructorArgs []string `json:"constructor_args,omitempty"`
+	// BytecodeHash is the keccak256 of the artifact's creation bytecode
+	BytecodeHash string `json:"bytecode_hash,omitempty"`
+
+	// Imported contracts were deployed elsewhere and added by ImportContract, DeployTime
+	// is when they were imported and Owner what owner() returned then
+	Imported bool   `json:"imported,omitempty"`
+	Owner    string `json:"owner,omitempty"`
 }
 
+// DeployedContractsPath is the registry of deployed contracts, relative to the working directory
+const DeployedContractsPath = "deployed_contracts.json"
+
 type DeployedContracts struct {
 	Contracts []DeployedContract `json:"contracts"`
 }
@@ -30,7 +57,9 @@ type AvailableContract struct {
 	ContractName string
 	FilePath     string
 	Bytecode     string
-	ABI          string
+	// DeployedBytecode is the runtime code, empty in artifacts that do not carry it
+	DeployedBytecode string
+	ABI              string
 }
 
 type ContractCompiler struct {
@@ -65,65 +94,146 @@ func (c *ContractCompiler) GetContractBytecode() (string, string, error) {
 	return artifact.Bytecode, artifact.Abi, nil
 }
 
-// SaveDeployedContract 保存已部署的合约信息
-func (c *ContractCompiler) SaveDeployedContract(address string, tokenURI string, abi string) error {
-	deployedFile := "deployed_contracts.json"
-	var deployedContracts DeployedContracts
-
-	// 如果文件存在，读取现有内容
-	if _, err := os.Stat(deployedFile); err == nil {
-		data, err := os.ReadFile(deployedFile)
-		if err != nil {
-			return fmt.Errorf("读取已部署合约文件失败: %v", err)
-		}
-
-		if err := json.Unmarshal(data, &deployedContracts); err != nil {
-			return fmt.Errorf("解析已部署合约文件失败: %v", err)
-		}
+// SaveDeployedContract 保存已部署的合约信息，DeployTime 为空时记为当前时间
+func (c *ContractCompiler) SaveDeployedContract(contract DeployedContract) error {
+	if contract.DeployTime.IsZero() {
+		contract.DeployTime = time.Now()
 	}
+	// 加锁读改写，多个实例同时部署也不会互相覆盖
+	return updateRegistry(DeployedContractsPath, func(contracts []DeployedContract) ([]DeployedContract, error) {
+		return append(contracts, contract), nil
+	})
+}
 
-	// 添加新部署的合约信息
-	newContract := DeployedContract{
-		Address:    address,
-		TokenURI:   tokenURI,
-		Abi:        abi,
-		DeployTime: time.Now(),
+// DeployArtifact deploys artifact with the constructor arguments args, given as text, and
+// records it with its provenance under network. value may be nil.
+func (c *ContractCompiler) DeployArtifact(ctx context.Context, service *NftService, artifact AvailableContract, args []string, value *big.Int, network string) (DeployedContract, error) {
+	constructor, err := ConstructorOf(artifact.ABI)
+	if err != nil {
+		return DeployedContract{}, err
 	}
-	deployedContracts.Contracts = append(deployedContracts.Contracts, newContract)
-
-	// 保存到JSON文件
-	data, err := json.MarshalIndent(deployedContracts, "", "  ")
+	parsedArgs, err := ParseABIArguments(constructor, args)
 	if err != nil {
-		return fmt.Errorf("序列化合约信息失败: %v", err)
+		return DeployedContract{}, err
+	}
+	bytecodeHash, err := BytecodeHash(artifact.Bytecode)
+	if err != nil {
+		return DeployedContract{}, err
 	}
 
-	if err := os.WriteFile(deployedFile, data, 0644); err != nil {
-		return fmt.Errorf("保存合约信息失败: %v", err)
+	result, err := service.DeployContract(ctx, DeployContractParams{
+		Bytecode:        artifact.Bytecode,
+		ConstructorABI:  artifact.ABI,
+		ConstructorArgs: parsedArgs,
+		Value:           value,
+	})
+	if err != nil {
+		return DeployedContract{}, err
 	}
 
-	return nil
+	contract := DeployedContract{
+		Address:         result.ContractAddress,
+		TokenURI:        ConstructorURI(constructor, args),
+		Abi:             artifact.ABI,
+		DeployBlock:     result.BlockNumber,
+		ChainID:         result.ChainID,
+		Network:         network,
+		ContractName:    artifact.ContractName,
+		Deployer:        result.Deployer,
+		DeployTx:        result.TxHash,
+		ConstructorArgs: args,
+		BytecodeHash:    bytecodeHash,
+	}
+	if err := c.SaveDeployedContract(contract); err != nil {
+		// the contract exists on chain, keep its address in the error
+		return contract, fmt.Errorf("deployed %s but failed to record it: %w", contract.Address, err)
+	}
+	return contract, nil
 }
 
 // GetDeployedContracts 获取所有已部署的合约信息
 func (c *ContractCompiler) GetDeployedContracts() ([]DeployedContract, error) {
-	deployedFile := "deployed_contracts.json"
+	return readRegistry(DeployedContractsPath)
+}
 
-	// 如果文件不存在，返回空列表
-	if _, err := os.Stat(deployedFile); os.IsNotExist(err) {
-		return []DeployedContract{}, nil
+// GetContractsOnChain 获取某条链上的合约，以及尚未确定所在链的旧记录。
+// chainID 为 0 表示当前链未知，返回全部合约
+func (c *ContractCompiler) GetContractsOnChain(chainID uint64) ([]DeployedContract, error) {
+	contracts, err := c.GetDeployedContracts()
+	if err != nil || chainID == 0 {
+		return contracts, err
 	}
 
-	data, err := os.ReadFile(deployedFile)
+	onChain := []DeployedContract{}
+	for _, contract := range contracts {
+		if contract.ChainID == chainID || contract.ChainID == 0 {
+			onChain = append(onChain, contract)
+		}
+	}
+	return onChain, nil
+}
+
+// ClaimUnassignedContracts migrates records saved before chain IDs were recorded: every
+// record without one whose address has code on the chain of service is stamped with that
+// chain and network. Records without code stay unassigned for another network to claim.
+// Returns how many records were claimed.
+func (c *ContractCompiler) ClaimUnassignedContracts(ctx context.Context, service *NftService, network string) (int, error) {
+	chainID, err := service.VerifyChain(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("读取已部署合约文件失败: %v", err)
+		return 0, err
+	}
+	return c.claimUnassigned(chainID.Uint64(), network, func(address string) (bool, error) {
+		return service.HasCode(ctx, address)
+	})
+}
+
+// claimUnassigned stamps the records without a chain ID for which hasCode finds code
+func (c *ContractCompiler) claimUnassigned(chainID uint64, network string, hasCode func(address string) (bool, error)) (int, error) {
+	contracts, err := c.GetDeployedContracts()
+	if err != nil {
+		return 0, err
 	}
 
-	var deployedContracts DeployedContracts
-	if err := json.Unmarshal(data, &deployedContracts); err != nil {
-		return nil, fmt.Errorf("解析已部署合约文件失败: %v", err)
+	// 先在锁外查询链上代码，写入时只更新仍未归属的记录
+	found := map[string]bool{}
+	for _, contract := range contracts {
+		if contract.ChainID != 0 {
+			continue
+		}
+		hasIt, err := hasCode(contract.Address)
+		if err != nil {
+			return 0, err
+		}
+		if hasIt {
+			found[contract.Address] = true
+		}
+	}
+	if len(found) == 0 {
+		return 0, nil
 	}
 
-	return deployedContracts.Contracts, nil
+	claimed := 0
+	err = updateRegistry(DeployedContractsPath, func(contracts []DeployedContract) ([]DeployedContract, error) {
+		for i, contract := range contracts {
+			if contract.ChainID == 0 && found[contract.Address] {
+				contracts[i].ChainID = chainID
+				contracts[i].Network = network
+				claimed++
+			}
+		}
+		return contracts, nil
+	})
+	return claimed, err
+}
+
+// BytecodeHash returns the keccak256 of hex encoded creation bytecode, recorded with a
+// deployment to tell which build of an artifact it came from
+func BytecodeHash(bytecode string) (string, error) {
+	code, err := hex.DecodeString(strings.TrimPrefix(bytecode, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid bytecode: %v", err)
+	}
+	return crypto.Keccak256Hash(code).Hex(), nil
 }
 
 // GetLatestDeployedContract 获取最新部署的合约信息
@@ -172,42 +282,47 @@ func (c *ContractCompiler) GetAvailableContracts() ([]AvailableContract, error)
 			filePath := filepath.Join(contractsDir, file.Name())
 			fmt.Printf("Debug: Processing contract file: %s\n", filePath)
 
-			// Read and parse the JSON file
-			data, err := os.ReadFile(filePath)
+			contract, err := LoadAvailableContract(filePath)
 			if err != nil {
-				fmt.Printf("Debug: Failed to read file %s: %v\n", filePath, err)
+				fmt.Printf("Debug: %v\n", err)
 				continue
 			}
 
-			var contractData struct {
-				ContractName string          `json:"contractName"`
-				Bytecode     string          `json:"bytecode"`
-				Abi          json.RawMessage `json:"abi"`
-			}
+			contracts = append(contracts, contract)
+			fmt.Printf("Debug: Successfully added contract: %s from %s\n", contract.ContractName, filePath)
+		}
+	}
 
-			if err := json.Unmarshal(data, &contractData); err != nil {
-				fmt.Printf("Debug: Failed to parse JSON from file %s: %v\n", filePath, err)
-				continue
-			}
+	fmt.Printf("Debug: Returning %d available contracts\n", len(contracts))
+	return contracts, nil
+}
 
-			if contractData.ContractName == "" {
-				fmt.Printf("Debug: Contract name is empty in file %s\n", filePath)
-				continue
-			}
+// LoadAvailableContract reads a contract JSON file with contractName, bytecode and abi fields
+func LoadAvailableContract(filePath string) (AvailableContract, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return AvailableContract{}, fmt.Errorf("failed to read file %s: %v", filePath, err)
+	}
 
-			// Convert ABI to string
-			abiString := string(contractData.Abi)
+	var contractData struct {
+		ContractName     string          `json:"contractName"`
+		Bytecode         string          `json:"bytecode"`
+		DeployedBytecode string          `json:"deployedBytecode"`
+		Abi              json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(data, &contractData); err != nil {
+		return AvailableContract{}, fmt.Errorf("failed to parse JSON from file %s: %v", filePath, err)
+	}
 
-			contracts = append(contracts, AvailableContract{
-				ContractName: contractData.ContractName,
-				FilePath:     filePath,
-				Bytecode:     contractData.Bytecode,
-				ABI:          abiString,
-			})
-			fmt.Printf("Debug: Successfully added contract: %s from %s\n", contractData.ContractName, filePath)
-		}
+	if contractData.ContractName == "" {
+		return AvailableContract{}, fmt.Errorf("contract name is empty in file %s", filePath)
 	}
 
-	fmt.Printf("Debug: Returning %d available contracts\n", len(contracts))
-	return contracts, nil
+	return AvailableContract{
+		ContractName:     contractData.ContractName,
+		FilePath:         filePath,
+		Bytecode:         contractData.Bytecode,
+		DeployedBytecode: contractData.DeployedBytecode,
+		ABI:              string(contractData.Abi),
+	}, nil
 }
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inTempDir runs the rest of a test in an empty working directory, the registry lives in
+// the working directory
+func inTempDir(t *testing.T) {
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(dir))
+	})
+}
+
+func TestGetContractsOnChain(t *testing.T) {
+	inTempDir(t)
+	compiler := NewContractCompiler("./artifacts")
+
+	for _, contract := range []DeployedContract{
+		{Address: "0x0000000000000000000000000000000000000001", ChainID: 1},
+		{Address: "0x0000000000000000000000000000000000000002", ChainID: 11155111},
+		{Address: "0x0000000000000000000000000000000000000003"},
+	} {
+		require.NoError(t, compiler.SaveDeployedContract(contract))
+	}
+
+	contracts, err := compiler.GetContractsOnChain(1)
+	require.NoError(t, err)
+	require.Len(t, contracts, 2)
+	require.Equal(t, uint64(1), contracts[0].ChainID)
+	require.Equal(t, uint64(0), contracts[1].ChainID)
+	require.False(t, contracts[0].DeployTime.IsZero())
+
+	contracts, err = compiler.GetContractsOnChain(0)
+	require.NoError(t, err)
+	require.Len(t, contracts, 3)
+}
+
+func TestBytecodeHash(t *testing.T) {
+	hash, err := BytecodeHash("0x")
+	require.NoError(t, err)
+	// keccak256 of no bytes
+	require.Equal(t, "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hash)
+
+	_, err = BytecodeHash("0xzz")
+	require.Error(t, err)
+}
+
+func (s *NftServiceTestSuite) TestClaimUnassignedContracts() {
+	inTempDir(s.T())
+	compiler := NewContractCompiler("./artifacts")
+	contractAddress := s.deployContract()
+
+	// records from before chain IDs were recorded, only one has code on the simulated chain
+	s.Require().NoError(compiler.SaveDeployedContract(DeployedContract{Address: contractAddress}))
+	s.Require().NoError(compiler.SaveDeployedContract(DeployedContract{Address: OtherAddress}))
+
+	claimed, err := compiler.ClaimUnassignedContracts(context.Background(), s.NftService, "simulated")
+	s.Require().NoError(err)
+	s.Require().Equal(1, claimed)
+
+	contracts, err := compiler.GetDeployedContracts()
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(1337), contracts[0].ChainID)
+	s.Require().Equal("simulated", contracts[0].Network)
+	s.Require().Equal(uint64(0), contracts[1].ChainID)
+
+	// a second run finds nothing new
+	claimed, err = compiler.ClaimUnassignedContracts(context.Background(), s.NftService, "simulated")
+	s.Require().NoError(err)
+	s.Require().Zero(claimed)
+}
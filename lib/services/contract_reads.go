@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// myTokenReadABI holds the read-only ERC1155 and Ownable functions of MyToken
+const myTokenReadABI = `[
+	{"type": "function", "name": "balanceOf", "stateMutability": "view",
+		"inputs": [{"name": "account", "type": "address"}, {"name": "id", "type": "uint256"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "balanceOfBatch", "stateMutability": "view",
+		"inputs": [{"name": "accounts", "type": "address[]"}, {"name": "ids", "type": "uint256[]"}],
+		"outputs": [{"name": "", "type": "uint256[]"}]},
+	{"type": "function", "name": "uri", "stateMutability": "view",
+		"inputs": [{"name": "", "type": "uint256"}],
+		"outputs": [{"name": "", "type": "string"}]},
+	{"type": "function", "name": "owner", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "address"}]},
+	{"type": "function", "name": "batchSize", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "isApprovedForAll", "stateMutability": "view",
+		"inputs": [{"name": "account", "type": "address"}, {"name": "operator", "type": "address"}],
+		"outputs": [{"name": "", "type": "bool"}]}
+]`
+
+// CallView runs a read-only function with eth_call against the latest block and returns
+// its outputs unpacked with the contract ABI. Nothing is signed or sent.
+func (s *NftService) CallView(ctx context.Context, params ContractCallParams) (outputs []any, err error) {
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	// reads do not need a key, but some contracts look at msg.sender
+	var fromAddress common.Address
+	if signer, keyErr := s.getSigner(); keyErr == nil {
+		fromAddress = signer.Address()
+	}
+
+	msg, err := packContractCall(fromAddress, params)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancel()
+
+	output, err := client.CallContract(callCtx, msg, nil)
+	if err != nil {
+		if data, ok := revertDataFromError(err); ok {
+			return nil, fmt.Errorf("%s reverted: %s", params.FunctionName, DecodeRevertReason(data, params.ContractABI))
+		}
+		return nil, fmt.Errorf("failed to call %s: %v", params.FunctionName, err)
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(params.ContractABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse contract ABI: %v", err)
+	}
+	outputs, err = parsedABI.Unpack(params.FunctionName, output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s output: %v", params.FunctionName, err)
+	}
+	return outputs, nil
+}
+
+// callMyToken calls one of the read-only MyToken functions and returns its single output
+func (s *NftService) callMyToken(ctx context.Context, contractAddr string, functionName string, args ...any) (any, error) {
+	outputs, err := s.CallView(ctx, ContractCallParams{
+		ContractAddress: contractAddr,
+		ContractABI:     myTokenReadABI,
+		FunctionName:    functionName,
+		FunctionArgs:    args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) != 1 {
+		return nil, fmt.Errorf("%s returned %d values, expected 1", functionName, len(outputs))
+	}
+	return outputs[0], nil
+}
+
+// BalanceOf returns how many tokenID tokens account holds
+func (s *NftService) BalanceOf(ctx context.Context, contractAddr string, account string, tokenID *big.Int) (*big.Int, error) {
+	output, err := s.callMyToken(ctx, contractAddr, "balanceOf", common.HexToAddress(account), tokenID)
+	if err != nil {
+		return nil, err
+	}
+	return output.(*big.Int), nil
+}
+
+// BalanceOfBatch returns the balance of accounts[i] for tokenIDs[i], both slices must have the same length
+func (s *NftService) BalanceOfBatch(ctx context.Context, contractAddr string, accounts []string, tokenIDs []*big.Int) ([]*big.Int, error) {
+	if len(accounts) != len(tokenIDs) {
+		return nil, fmt.Errorf("got %d accounts but %d token IDs", len(accounts), len(tokenIDs))
+	}
+
+	addresses := make([]common.Address, len(accounts))
+	for i, account := range accounts {
+		addresses[i] = common.HexToAddress(account)
+	}
+
+	output, err := s.callMyToken(ctx, contractAddr, "balanceOfBatch", addresses, tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+	return output.([]*big.Int), nil
+}
+
+// URI returns the metadata URI of tokenID, MyToken returns the same template for every ID
+func (s *NftService) URI(ctx context.Context, contractAddr string, tokenID *big.Int) (string, error) {
+	output, err := s.callMyToken(ctx, contractAddr, "uri", tokenID)
+	if err != nil {
+		return "", err
+	}
+	return output.(string), nil
+}
+
+// Owner returns the address allowed to mint and set the URI
+func (s *NftService) Owner(ctx context.Context, contractAddr string) (string, error) {
+	output, err := s.callMyToken(ctx, contractAddr, "owner")
+	if err != nil {
+		return "", err
+	}
+	return output.(common.Address).Hex(), nil
+}
+
+// BatchSize reads the maximum number of recipients per mintToMultple call from the contract
+func (s *NftService) BatchSize(ctx context.Context, contractAddr string) (int, error) {
+	output, err := s.callMyToken(ctx, contractAddr, "batchSize")
+	if err != nil {
+		return 0, err
+	}
+
+	value := output.(*big.Int)
+	if value.Sign() <= 0 || !value.IsInt64() {
+		return 0, fmt.Errorf("invalid batchSize %s", value)
+	}
+	return int(value.Int64()), nil
+}
+
+// IsApprovedForAll reports whether operator may transfer all tokens of account
+func (s *NftService) IsApprovedForAll(ctx context.Context, contractAddr string, account string, operator string) (bool, error) {
+	output, err := s.callMyToken(ctx, contractAddr, "isApprovedForAll", common.HexToAddress(account), common.HexToAddress(operator))
+	if err != nil {
+		return false, err
+	}
+	return output.(bool), nil
+}
+
+// HasCode reports whether a contract is deployed at address on the connected chain
+func (s *NftService) HasCode(ctx context.Context, address string) (bool, error) {
+	code, err := s.Code(ctx, address)
+	return len(code) > 0, err
+}
+
+// Code returns the runtime bytecode at address on the connected chain, empty when no
+// contract is deployed there
+func (s *NftService) Code(ctx context.Context, address string) (code []byte, err error) {
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid contract address %q", address)
+	}
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	codeCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancel()
+	return client.CodeAt(codeCtx, common.HexToAddress(address), nil)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func (s *NftServiceTestSuite) TestContractReads() {
+	contractAddress := s.deployContract()
+	ctx := context.Background()
+
+	recipients := []string{
+		"0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65",
+		"0x9965507D1a55bcC2695C58ba16FB37d819B0A4dc",
+	}
+	_, err := s.NftService.MintNFTToAddresses(ctx, contractAddress, recipients, "4", nil)
+	s.Require().NoError(err)
+
+	balance, err := s.NftService.BalanceOf(ctx, contractAddress, recipients[0], big.NewInt(4))
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1), balance.Int64())
+
+	balances, err := s.NftService.BalanceOfBatch(ctx, contractAddress,
+		[]string{recipients[0], recipients[1], recipients[0]},
+		[]*big.Int{big.NewInt(4), big.NewInt(4), big.NewInt(5)},
+	)
+	s.Require().NoError(err)
+	s.Require().Equal([]int64{1, 1, 0}, []int64{balances[0].Int64(), balances[1].Int64(), balances[2].Int64()})
+
+	_, err = s.NftService.BalanceOfBatch(ctx, contractAddress, recipients, []*big.Int{big.NewInt(4)})
+	s.Require().Error(err)
+
+	uri, err := s.NftService.URI(ctx, contractAddress, big.NewInt(4))
+	s.Require().NoError(err)
+	s.Require().Equal("https://api.example.com/init/{id}", uri)
+
+	owner, err := s.NftService.Owner(ctx, contractAddress)
+	s.Require().NoError(err)
+	s.Require().Equal(HardhatAddress, owner)
+
+	size, err := s.NftService.BatchSize(ctx, contractAddress)
+	s.Require().NoError(err)
+	s.Require().Equal(50, size)
+
+	approved, err := s.NftService.IsApprovedForAll(ctx, contractAddress, HardhatAddress, OtherAddress)
+	s.Require().NoError(err)
+	s.Require().False(approved)
+
+	_, err = s.NftService.CallContractFunction(ctx, ContractCallParams{
+		ContractAddress: contractAddress,
+		ContractABI:     s.AbiJSON,
+		FunctionName:    "setApprovalForAll",
+		FunctionArgs:    []any{common.HexToAddress(OtherAddress), true},
+	})
+	s.Require().NoError(err)
+
+	approved, err = s.NftService.IsApprovedForAll(ctx, contractAddress, HardhatAddress, OtherAddress)
+	s.Require().NoError(err)
+	s.Require().True(approved)
+}
+
+func (s *NftServiceTestSuite) TestCallViewDecodesReverts() {
+	contractAddress := s.deployContract()
+
+	// mismatched arrays hit the contract's own length check
+	_, err := s.NftService.CallView(context.Background(), ContractCallParams{
+		ContractAddress: contractAddress,
+		ContractABI:     s.AbiJSON,
+		FunctionName:    "balanceOfBatch",
+		FunctionArgs: []any{
+			[]common.Address{common.HexToAddress(HardhatAddress)},
+			[]*big.Int{big.NewInt(1), big.NewInt(2)},
+		},
+	})
+	s.Require().Error(err)
+	s.Require().Contains(err.Error(), "ERC1155InvalidArrayLength")
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// FeePolicy caps the fees paid by transactions sent from NftService.
+// A nil field means no cap is applied.
+type FeePolicy struct {
+	// Upper bound for the max fee per gas (or the gas price of legacy transactions)
+	MaxFeePerGas *big.Int
+	// Upper bound for the priority fee (tip) per gas
+	MaxPriorityFeePerGas *big.Int
+}
+
+// merge returns the policy with the non-nil fields of override taking precedence
+func (p FeePolicy) merge(override FeePolicy) FeePolicy {
+	if override.MaxFeePerGas != nil {
+		p.MaxFeePerGas = override.MaxFeePerGas
+	}
+	if override.MaxPriorityFeePerGas != nil {
+		p.MaxPriorityFeePerGas = override.MaxPriorityFeePerGas
+	}
+	return p
+}
+
+// ParseGwei converts a decimal gwei amount such as "1.5" to wei.
+// An empty string returns nil so unset configuration leaves the cap disabled.
+func ParseGwei(value string) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	gwei, ok := new(big.Float).SetPrec(256).SetString(value)
+	if !ok || gwei.Sign() < 0 {
+		return nil, fmt.Errorf("invalid gwei amount: %s", value)
+	}
+
+	wei, _ := new(big.Float).Mul(gwei, big.NewFloat(params.GWei)).Int(nil)
+	return wei, nil
+}
+
+// txFees holds the fee fields of a transaction about to be built
+type txFees struct {
+	// GasPrice is set for legacy transactions only
+	GasPrice *big.Int
+	// GasTipCap and GasFeeCap are set for dynamic-fee transactions
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// suggestFees prices a transaction as EIP-1559 when the latest block has a base fee,
+// falling back to a legacy gas price on chains without one
+func suggestFees(ctx context.Context, client ChainBackend, policy FeePolicy) (txFees, error) {
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return txFees{}, err
+	}
+
+	if header.BaseFee == nil {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return txFees{}, err
+		}
+		if policy.MaxFeePerGas != nil && gasPrice.Cmp(policy.MaxFeePerGas) > 0 {
+			gasPrice = new(big.Int).Set(policy.MaxFeePerGas)
+		}
+		return txFees{GasPrice: gasPrice}, nil
+	}
+
+	tip, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return txFees{}, err
+	}
+	if policy.MaxPriorityFeePerGas != nil && tip.Cmp(policy.MaxPriorityFeePerGas) > 0 {
+		tip = new(big.Int).Set(policy.MaxPriorityFeePerGas)
+	}
+
+	// leave room for the base fee to double before the transaction gets priced out
+	feeCap := new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip)
+	if policy.MaxFeePerGas != nil && feeCap.Cmp(policy.MaxFeePerGas) > 0 {
+		feeCap = new(big.Int).Set(policy.MaxFeePerGas)
+	}
+	if feeCap.Cmp(header.BaseFee) < 0 {
+		return txFees{}, fmt.Errorf("max fee per gas %s is below the current base fee %s", feeCap, header.BaseFee)
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+
+	return txFees{GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+// newTransaction builds a dynamic-fee or legacy transaction depending on fees.
+// A nil to creates a contract.
+func newTransaction(chainID *big.Int, nonce uint64, to *common.Address, value *big.Int, gasLimit uint64, data []byte, fees txFees) *types.Transaction {
+	if fees.GasPrice != nil {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       to,
+			Value:    value,
+			Gas:      gasLimit,
+			GasPrice: fees.GasPrice,
+			Data:     data,
+		})
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		To:        to,
+		Value:     value,
+		Gas:       gasLimit,
+		GasTipCap: fees.GasTipCap,
+		GasFeeCap: fees.GasFeeCap,
+		Data:      data,
+	})
+}
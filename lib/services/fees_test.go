@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyBackend stubs a pre-London chain, any other call panics on the nil embedded backend
+type legacyBackend struct {
+	ChainBackend
+	gasPrice *big.Int
+}
+
+func (b *legacyBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1)}, nil
+}
+
+func (b *legacyBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return b.gasPrice, nil
+}
+
+func TestParseGwei(t *testing.T) {
+	wei, err := ParseGwei("1.5")
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1_500_000_000), wei)
+
+	wei, err = ParseGwei("")
+	require.NoError(t, err)
+	require.Nil(t, wei)
+
+	_, err = ParseGwei("abc")
+	require.Error(t, err)
+	_, err = ParseGwei("-1")
+	require.Error(t, err)
+}
+
+func TestSuggestFeesFallsBackToLegacy(t *testing.T) {
+	backend := &legacyBackend{gasPrice: big.NewInt(50_000_000_000)}
+
+	fees, err := suggestFees(context.Background(), backend, FeePolicy{})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(50_000_000_000), fees.GasPrice)
+	require.Nil(t, fees.GasFeeCap)
+
+	// the max fee cap also bounds the legacy gas price
+	fees, err = suggestFees(context.Background(), backend, FeePolicy{MaxFeePerGas: big.NewInt(20_000_000_000)})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(20_000_000_000), fees.GasPrice)
+
+	tx := newTransaction(big.NewInt(1), 0, nil, big.NewInt(0), 21000, nil, fees)
+	require.Equal(t, uint8(types.LegacyTxType), tx.Type())
+}
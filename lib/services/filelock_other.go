@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package services
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// lockFile fails on platforms that can neither flock nor LockFileEx, concurrent instances
+// would otherwise lose each other's writes without a warning
+func lockFile(path string) (unlock func(), err error) {
+	return nil, fmt.Errorf("cannot lock %s: file locking is not supported on %s", path, runtime.GOOS)
+}
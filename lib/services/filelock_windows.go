@@ -0,0 +1,29 @@
+//go:build windows
+
+package services
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on the file at path, creating it when needed, and waits
+// while another process holds it. The lock is released by the returned function or when
+// the process exits.
+func lockFile(path string) (unlock func(), err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// every caller locks the same first byte, the lock file itself is never read
+	overlapped := new(windows.Overlapped)
+	if err := windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, overlapped)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// DefaultGasLimitMultiplier pads gas estimates so that state changing between
+// estimation and mining does not make the transaction run out of gas
+const DefaultGasLimitMultiplier = 1.2
+
+// estimateGasLimit returns the explicit gas limit when it is set, otherwise the
+// node's estimate for msg multiplied by the safety multiplier
+func estimateGasLimit(ctx context.Context, client ChainBackend, msg ethereum.CallMsg, explicit uint64, multiplier float64) (uint64, error) {
+	if explicit > 0 {
+		return explicit, nil
+	}
+
+	estimate, err := client.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate gas: %v", err)
+	}
+
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	return uint64(math.Ceil(float64(estimate) * multiplier)), nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ImportContract registers a contract deployed outside this tool, e.g. by a Hardhat script.
+// Its runtime code on the connected chain is matched against the artifacts in contracts/
+// and the artifacts directory to pick its ABI, owner() and uri(0) are read when the ABI
+// has them, and the record is saved with Imported set. network names the active profile.
+func (c *ContractCompiler) ImportContract(ctx context.Context, service *NftService, address string, network string) (DeployedContract, error) {
+	if !common.IsHexAddress(address) {
+		return DeployedContract{}, fmt.Errorf("invalid contract address %q", address)
+	}
+	address = common.HexToAddress(address).Hex()
+
+	chainID, err := service.VerifyChain(ctx)
+	if err != nil {
+		return DeployedContract{}, err
+	}
+	code, err := service.Code(ctx, address)
+	if err != nil {
+		return DeployedContract{}, err
+	}
+	if len(code) == 0 {
+		return DeployedContract{}, fmt.Errorf("no contract code at %s on chain %s", address, chainID)
+	}
+
+	artifacts, err := c.importArtifacts()
+	if err != nil {
+		return DeployedContract{}, err
+	}
+	artifact, ok := matchArtifact(code, artifacts)
+	if !ok {
+		return DeployedContract{}, fmt.Errorf("the code at %s matches none of the %d artifacts in contracts/ and %s", address, len(artifacts), c.artifactsPath)
+	}
+	bytecodeHash, err := BytecodeHash(artifact.Bytecode)
+	if err != nil {
+		return DeployedContract{}, err
+	}
+
+	contract := DeployedContract{
+		Address:      address,
+		Abi:          artifact.ABI,
+		ChainID:      chainID.Uint64(),
+		Network:      network,
+		ContractName: artifact.ContractName,
+		BytecodeHash: bytecodeHash,
+		Imported:     true,
+	}
+
+	// owner() and uri(0) are only read from contracts whose ABI declares them
+	parsedABI, err := abi.JSON(strings.NewReader(artifact.ABI))
+	if err != nil {
+		return DeployedContract{}, fmt.Errorf("failed to parse the ABI of %s: %v", artifact.FilePath, err)
+	}
+	if _, ok := parsedABI.Methods["owner"]; ok {
+		if contract.Owner, err = service.Owner(ctx, address); err != nil {
+			return DeployedContract{}, fmt.Errorf("failed to read owner(): %v", err)
+		}
+	}
+	if _, ok := parsedABI.Methods["uri"]; ok {
+		if contract.TokenURI, err = service.URI(ctx, address, big.NewInt(0)); err != nil {
+			return DeployedContract{}, fmt.Errorf("failed to read uri(0): %v", err)
+		}
+	}
+
+	contract.DeployTime = time.Now()
+	err = updateRegistry(DeployedContractsPath, func(contracts []DeployedContract) ([]DeployedContract, error) {
+		for _, existing := range contracts {
+			if existing.ChainID == contract.ChainID && strings.EqualFold(existing.Address, address) {
+				return nil, fmt.Errorf("%s is already registered on chain %d", address, contract.ChainID)
+			}
+		}
+		return append(contracts, contract), nil
+	})
+	if err != nil {
+		return DeployedContract{}, err
+	}
+	return contract, nil
+}
+
+// importArtifacts loads every artifact with runtime code from contracts/ and the
+// artifacts directory, missing directories are skipped
+func (c *ContractCompiler) importArtifacts() ([]AvailableContract, error) {
+	var artifacts []AvailableContract
+	for _, dir := range []string{"contracts", c.artifactsPath} {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, os.ErrNotExist) {
+				return filepath.SkipDir
+			} else if err != nil {
+				return err
+			}
+			// Hardhat writes a .dbg.json next to every artifact
+			if entry.IsDir() || !strings.HasSuffix(path, ".json") || strings.HasSuffix(path, ".dbg.json") {
+				return nil
+			}
+			artifact, err := LoadAvailableContract(path)
+			if err != nil || artifact.DeployedBytecode == "" {
+				// build info and other JSON files are not artifacts
+				return nil
+			}
+			artifacts = append(artifacts, artifact)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read artifacts in %s: %v", dir, err)
+		}
+	}
+	return artifacts, nil
+}
+
+// matchArtifact finds the artifact whose runtime bytecode is code. A build that only
+// differs in the metadata hash appended by solc still matches when nothing matches exactly.
+func matchArtifact(code []byte, artifacts []AvailableContract) (AvailableContract, bool) {
+	runtimes := make([][]byte, len(artifacts))
+	for i, artifact := range artifacts {
+		runtimes[i], _ = hex.DecodeString(strings.TrimPrefix(artifact.DeployedBytecode, "0x"))
+	}
+
+	for i, runtime := range runtimes {
+		if len(runtime) > 0 && bytes.Equal(code, runtime) {
+			return artifacts[i], true
+		}
+	}
+	stripped := stripMetadata(code)
+	for i, runtime := range runtimes {
+		if len(runtime) > 0 && bytes.Equal(stripped, stripMetadata(runtime)) {
+			return artifacts[i], true
+		}
+	}
+	return AvailableContract{}, false
+}
+
+// stripMetadata removes the CBOR metadata solc appends to runtime code, its length is
+// stored in the last two bytes
+func stripMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	if length+2 > len(code) {
+		return code
+	}
+	return code[:len(code)-length-2]
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchArtifact(t *testing.T) {
+	// runtime code followed by 2 bytes of metadata and its length
+	artifacts := []AvailableContract{
+		{ContractName: "Other", DeployedBytecode: "0x6001600201aaaa0002"},
+		{ContractName: "Token", DeployedBytecode: "0x6080604052bbbb0002"},
+	}
+
+	match, ok := matchArtifact([]byte{0x60, 0x80, 0x60, 0x40, 0x52, 0xbb, 0xbb, 0x00, 0x02}, artifacts)
+	require.True(t, ok)
+	require.Equal(t, "Token", match.ContractName)
+
+	// another build of the same source only differs in the metadata hash
+	match, ok = matchArtifact([]byte{0x60, 0x80, 0x60, 0x40, 0x52, 0xcc, 0xcc, 0x00, 0x02}, artifacts)
+	require.True(t, ok)
+	require.Equal(t, "Token", match.ContractName)
+
+	_, ok = matchArtifact([]byte{0x60, 0x00, 0x00, 0x00}, artifacts)
+	require.False(t, ok)
+}
+
+func (s *NftServiceTestSuite) TestImportContract() {
+	ctx := context.Background()
+	artifact, err := os.ReadFile(AbiPath)
+	s.Require().NoError(err)
+	contractAddress := s.deployContract()
+
+	// a Hardhat project layout with the artifact and its debug file
+	inTempDir(s.T())
+	dir := filepath.Join("artifacts", "contracts", "nft.sol")
+	s.Require().NoError(os.MkdirAll(dir, 0755))
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "MyToken.json"), artifact, 0644))
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "MyToken.dbg.json"), []byte(`{"buildInfo": "x.json"}`), 0644))
+	compiler := NewContractCompiler("./artifacts")
+
+	contract, err := compiler.ImportContract(ctx, s.NftService, contractAddress, "simulated")
+	s.Require().NoError(err)
+	s.Require().True(contract.Imported)
+	s.Require().Equal("MyToken", contract.ContractName)
+	s.Require().Equal(uint64(1337), contract.ChainID)
+	s.Require().Equal(HardhatAddress, contract.Owner)
+	s.Require().Equal("https://api.example.com/init/{id}", contract.TokenURI)
+
+	contracts, err := compiler.GetContractsOnChain(1337)
+	s.Require().NoError(err)
+	s.Require().Len(contracts, 1)
+
+	_, err = compiler.ImportContract(ctx, s.NftService, contractAddress, "simulated")
+	s.Require().ErrorContains(err, "already registered")
+	_, err = compiler.ImportContract(ctx, s.NftService, OtherAddress, "simulated")
+	s.Require().ErrorContains(err, "no contract code")
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// DefaultJournalPath is where the TUI keeps the journal of the current airdrop
+const DefaultJournalPath = "airdrop_journal.json"
+
+// BatchState is the progress of one airdrop batch recorded in the journal
+type BatchState string
+
+const (
+	// BatchPlanned batches have not been signed yet
+	BatchPlanned BatchState = "planned"
+	// BatchSigned batches were signed and possibly broadcast, the outcome is unknown
+	BatchSigned BatchState = "signed"
+	// BatchMined batches were mined successfully
+	BatchMined BatchState = "mined"
+	// BatchFailed batches reverted or failed before broadcasting, nothing was minted
+	BatchFailed BatchState = "failed"
+)
+
+// JournalBatch is one mintToMultple transaction of an airdrop
+type JournalBatch struct {
+	Recipients []string   `json:"recipients"`
+	State      BatchState `json:"state"`
+	TxHash     string     `json:"txHash,omitempty"`
+	// RawTx is the signed transaction, rebroadcast on resume when the node lost it
+	RawTx string `json:"rawTx,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// AirdropJournal records the planned batches of an airdrop and how far it got, so an
+// interrupted airdrop can be resumed without minting twice or skipping recipients.
+// It is written to disk before and after every transaction.
+type AirdropJournal struct {
+	// ChainID is the chain the airdrop was planned on, it is only resumed there
+	ChainID         uint64         `json:"chainId,omitempty"`
+	ContractAddress string         `json:"contractAddress"`
+	NFTID           string         `json:"nftId"`
+	URI             string         `json:"uri,omitempty"`
+	URISet          bool           `json:"uriSet"`
+	Batches         []JournalBatch `json:"batches"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+
+	// URITxHash and URIRawTx record the setURI transaction once it is signed, like a batch
+	URITxHash string `json:"uriTxHash,omitempty"`
+	URIRawTx  string `json:"uriRawTx,omitempty"`
+
+	// path is where the journal is saved, empty keeps it in memory only
+	path string
+}
+
+// NewAirdropJournal plans an airdrop of the given batches, saved to path.
+// An empty uri skips setting the URI.
+func NewAirdropJournal(path string, contractAddr string, nftID string, uri string, batches [][]string) *AirdropJournal {
+	journal := &AirdropJournal{
+		ContractAddress: contractAddr,
+		NFTID:           nftID,
+		URI:             uri,
+		URISet:          uri == "",
+		Batches:         make([]JournalBatch, len(batches)),
+		CreatedAt:       time.Now(),
+		path:            path,
+	}
+	for i, recipients := range batches {
+		journal.Batches[i] = JournalBatch{Recipients: recipients, State: BatchPlanned}
+	}
+	return journal
+}
+
+// LoadAirdropJournal reads the journal at path, it returns nil without an error when there is none
+func LoadAirdropJournal(path string) (*AirdropJournal, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read airdrop journal: %v", err)
+	}
+
+	journal := &AirdropJournal{}
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("failed to parse airdrop journal %s: %v", path, err)
+	}
+	journal.path = path
+	return journal, nil
+}
+
+// Save writes the journal, replacing the previous version atomically
+func (j *AirdropJournal) Save() error {
+	if j.path == "" {
+		return nil
+	}
+
+	j.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode airdrop journal: %v", err)
+	}
+
+	// the journal has to survive a crash right after a transaction is broadcast
+	if err := writeFileAtomic(j.path, data); err != nil {
+		return fmt.Errorf("failed to write airdrop journal: %v", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with data, synced to disk before it is
+// renamed into place so readers see either the old or the new content
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// persist the rename too, not every platform can sync a directory
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// Discard moves an unfinished journal aside so it is no longer offered for resuming
+func (j *AirdropJournal) Discard() error {
+	if j.path == "" {
+		return nil
+	}
+	discarded := fmt.Sprintf("%s.%s.discarded", j.path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(j.path, discarded); err != nil {
+		return fmt.Errorf("failed to discard airdrop journal: %v", err)
+	}
+	return nil
+}
+
+// Finished reports whether the URI was set and every batch was mined
+func (j *AirdropJournal) Finished() bool {
+	if !j.URISet {
+		return false
+	}
+	for _, batch := range j.Batches {
+		if batch.State != BatchMined {
+			return false
+		}
+	}
+	return true
+}
+
+// Recipients returns all recipients in batch order
+func (j *AirdropJournal) Recipients() []string {
+	var recipients []string
+	for _, batch := range j.Batches {
+		recipients = append(recipients, batch.Recipients...)
+	}
+	return recipients
+}
+
+// Matches reports whether the journal describes the airdrop of the given inputs
+func (j *AirdropJournal) Matches(contractAddr string, addresses []string, nftID string, uri string) bool {
+	return j.ContractAddress == contractAddr &&
+		j.NFTID == nftID &&
+		j.URI == uri &&
+		slices.Equal(j.Recipients(), addresses)
+}
+
+// MinedTxHashes returns the hashes of the batches mined so far
+func (j *AirdropJournal) MinedTxHashes() []string {
+	var txHashes []string
+	for _, batch := range j.Batches {
+		if batch.State == BatchMined {
+			txHashes = append(txHashes, batch.TxHash)
+		}
+	}
+	return txHashes
+}
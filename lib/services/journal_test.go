@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func (s *NftServiceTestSuite) TestResumeAirdropAfterInterruption() {
+	contractAddress := s.deployContract()
+	journalPath := filepath.Join(s.T().TempDir(), DefaultJournalPath)
+
+	recipients := make([]string, 120)
+	for i := range recipients {
+		recipients[i] = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+	}
+
+	// without auto-commit the first batch is broadcast but never mined, then the run is cut off
+	pending := NewNftServiceWithBackend(s.Client, HardhatPrivateKey)
+	journal, err := pending.PlanAirdrop(context.Background(), journalPath, contractAddress, recipients, "5", "https://api.example.com/drop/{id}")
+	s.Require().NoError(err)
+	journal.URISet = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	_, err = pending.RunAirdrop(ctx, journal, nil)
+	var pendingErr *TxPendingError
+	s.Require().ErrorAs(err, &pendingErr)
+
+	saved, err := LoadAirdropJournal(journalPath)
+	s.Require().NoError(err)
+	s.Require().False(saved.Finished())
+	s.Require().Len(saved.Batches, 3)
+	s.Require().Equal(BatchSigned, saved.Batches[0].State)
+	s.Require().Equal(pendingErr.TxHash, saved.Batches[0].TxHash)
+	s.Require().Equal(BatchPlanned, saved.Batches[1].State)
+	s.Require().True(saved.Matches(contractAddress, recipients, "5", "https://api.example.com/drop/{id}"))
+
+	// the pending batch gets mined while the app is down
+	s.Backend.Commit()
+
+	var statuses []BatchStatus
+	txHashes, err := s.NftService.RunAirdrop(context.Background(), saved, func(status BatchStatus) {
+		statuses = append(statuses, status)
+	})
+	s.Require().NoError(err)
+	s.Require().Len(txHashes, 3)
+	s.Require().Equal(pendingErr.TxHash, txHashes[0])
+	s.Require().Len(statuses, 3)
+
+	// every recipient got exactly one token
+	for _, recipient := range recipients {
+		s.Require().Equal(int64(1), s.balanceOf(contractAddress, recipient, 5).Int64())
+	}
+
+	finished, err := LoadAirdropJournal(journalPath)
+	s.Require().NoError(err)
+	s.Require().True(finished.Finished())
+	s.Require().Equal(txHashes, finished.MinedTxHashes())
+}
+
+func (s *NftServiceTestSuite) TestResumeRebroadcastsSignedBatch() {
+	contractAddress := s.deployContract()
+	journalPath := filepath.Join(s.T().TempDir(), DefaultJournalPath)
+
+	recipients := []string{
+		"0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65",
+		"0x9965507D1a55bcC2695C58ba16FB37d819B0A4dc",
+	}
+	journal, err := s.NftService.PlanAirdrop(context.Background(), journalPath, contractAddress, recipients, "2", "")
+	s.Require().NoError(err)
+
+	// the process died after the journal recorded the signed transaction but before it was sent
+	params, err := mintParams(contractAddress, recipients, "2")
+	s.Require().NoError(err)
+	errCrash := errors.New("crash")
+	_, err = s.NftService.callContractFunction(context.Background(), params, func(tx *types.Transaction) error {
+		raw, err := tx.MarshalBinary()
+		s.Require().NoError(err)
+		journal.Batches[0].State = BatchSigned
+		journal.Batches[0].TxHash = tx.Hash().Hex()
+		journal.Batches[0].RawTx = hexutil.Encode(raw)
+		return errCrash
+	})
+	s.Require().ErrorIs(err, errCrash)
+	s.Require().NoError(journal.Save())
+
+	saved, err := LoadAirdropJournal(journalPath)
+	s.Require().NoError(err)
+	txHashes, err := s.NftService.RunAirdrop(context.Background(), saved, nil)
+	s.Require().NoError(err)
+
+	// the recorded transaction is the one that got mined
+	s.Require().Equal([]string{journal.Batches[0].TxHash}, txHashes)
+	for _, recipient := range recipients {
+		s.Require().Equal(int64(1), s.balanceOf(contractAddress, recipient, 2).Int64())
+	}
+}
+
+func (s *NftServiceTestSuite) TestResumeRefusesJournalOfOtherChain() {
+	contractAddress := s.deployContract()
+	journalPath := filepath.Join(s.T().TempDir(), DefaultJournalPath)
+	recipients := []string{"0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65"}
+
+	journal, err := s.NftService.PlanAirdrop(context.Background(), journalPath, contractAddress, recipients, "3", "")
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(1337), journal.ChainID)
+
+	// the journal was planned on another network than the one the node serves now
+	journal.ChainID = 1
+	s.Require().NoError(journal.Save())
+
+	_, _, err = s.NftService.ResumeOrPlanAirdrop(context.Background(), journalPath, contractAddress, recipients, "3", "")
+	var chainErr *JournalChainError
+	s.Require().ErrorAs(err, &chainErr)
+	s.Require().Equal(uint64(1), chainErr.JournalChainID)
+	s.Require().Equal(uint64(1337), chainErr.ChainID)
+	s.Require().Equal(ErrorCodeChainMismatch, ErrorCodeOf(err))
+
+	_, err = s.NftService.RunAirdrop(context.Background(), journal, nil)
+	s.Require().ErrorAs(err, &chainErr)
+	s.Require().Zero(s.balanceOf(contractAddress, recipients[0], 3).Int64())
+}
+
+func (s *NftServiceTestSuite) TestDiscardAirdropJournal() {
+	journalPath := filepath.Join(s.T().TempDir(), DefaultJournalPath)
+
+	journal := NewAirdropJournal(journalPath, HardhatAddress, "1", "", [][]string{{OtherAddress}})
+	s.Require().NoError(journal.Save())
+	s.Require().NoError(journal.Discard())
+
+	missing, err := LoadAirdropJournal(journalPath)
+	s.Require().NoError(err)
+	s.Require().Nil(missing)
+
+	discarded, err := filepath.Glob(journalPath + ".*.discarded")
+	s.Require().NoError(err)
+	s.Require().Len(discarded, 1)
+}
+
+func (s *NftServiceTestSuite) TestResumeWaitsForSignedSetURI() {
+	contractAddress := s.deployContract()
+	journalPath := filepath.Join(s.T().TempDir(), DefaultJournalPath)
+	recipients := []string{"0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65"}
+	uri := "https://api.example.com/drop/{id}"
+
+	// without auto-commit setURI is broadcast but never mined, then the run is cut off
+	pending := NewNftServiceWithBackend(s.Client, HardhatPrivateKey)
+	journal, err := pending.PlanAirdrop(context.Background(), journalPath, contractAddress, recipients, "6", uri)
+	s.Require().NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	_, err = pending.RunAirdrop(ctx, journal, nil)
+	var pendingErr *TxPendingError
+	s.Require().ErrorAs(err, &pendingErr)
+
+	saved, err := LoadAirdropJournal(journalPath)
+	s.Require().NoError(err)
+	s.Require().False(saved.URISet)
+	s.Require().Equal(pendingErr.TxHash, saved.URITxHash)
+	s.Require().NotEmpty(saved.URIRawTx)
+
+	// the pending setURI gets mined while the app is down, resuming does not send another
+	s.Backend.Commit()
+	nonce, err := s.Client.PendingNonceAt(context.Background(), common.HexToAddress(HardhatAddress))
+	s.Require().NoError(err)
+	txHashes, err := s.NftService.RunAirdrop(context.Background(), saved, nil)
+	s.Require().NoError(err)
+	s.Require().Len(txHashes, 1)
+
+	resumedNonce, err := s.Client.PendingNonceAt(context.Background(), common.HexToAddress(HardhatAddress))
+	s.Require().NoError(err)
+	s.Require().Equal(nonce+1, resumedNonce)
+
+	finished, err := LoadAirdropJournal(journalPath)
+	s.Require().NoError(err)
+	s.Require().True(finished.URISet)
+	s.Require().Equal(pendingErr.TxHash, finished.URITxHash)
+	current, err := s.NftService.URI(context.Background(), contractAddress, big.NewInt(6))
+	s.Require().NoError(err)
+	s.Require().Equal(uri, current)
+	s.Require().Equal(int64(1), s.balanceOf(contractAddress, recipients[0], 6).Int64())
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// DefaultNetworksPath is where network profiles are read from unless NETWORKS_FILE is set
+const DefaultNetworksPath = "networks.json"
+
+// NetworkProfile names an RPC endpoint together with the chain it has to serve
+type NetworkProfile struct {
+	Name   string `json:"name"`
+	RPCURL string `json:"rpcUrl"`
+	// ChainID is checked against the node before anything is signed, 0 skips the check
+	ChainID uint64 `json:"chainId"`
+	// Explorer links a transaction, "{tx}" is replaced with its hash
+	Explorer string `json:"explorer,omitempty"`
+	// Optional default fee caps in gwei, the environment overrides them
+	MaxFeePerGasGwei         string `json:"maxFeePerGasGwei,omitempty"`
+	MaxPriorityFeePerGasGwei string `json:"maxPriorityFeePerGasGwei,omitempty"`
+	// Mainnet profiles move real funds, sending on them needs an extra typed confirmation
+	Mainnet bool `json:"mainnet,omitempty"`
+}
+
+// FeePolicy returns the default fee caps of the profile
+func (p NetworkProfile) FeePolicy() (FeePolicy, error) {
+	maxFeePerGas, err := ParseGwei(p.MaxFeePerGasGwei)
+	if err != nil {
+		return FeePolicy{}, fmt.Errorf("network %s: invalid maxFeePerGasGwei: %v", p.Name, err)
+	}
+	maxPriorityFeePerGas, err := ParseGwei(p.MaxPriorityFeePerGasGwei)
+	if err != nil {
+		return FeePolicy{}, fmt.Errorf("network %s: invalid maxPriorityFeePerGasGwei: %v", p.Name, err)
+	}
+	return FeePolicy{MaxFeePerGas: maxFeePerGas, MaxPriorityFeePerGas: maxPriorityFeePerGas}, nil
+}
+
+// TxURL links the transaction with the given hash on the explorer, empty without an explorer
+func (p NetworkProfile) TxURL(txHash string) string {
+	if p.Explorer == "" {
+		return ""
+	}
+	return strings.ReplaceAll(p.Explorer, "{tx}", txHash)
+}
+
+// NetworksConfig is the networks file, a list of named profiles
+type NetworksConfig struct {
+	// Default is the profile used when NETWORK is not set
+	Default  string           `json:"default"`
+	Networks []NetworkProfile `json:"networks"`
+}
+
+// LoadNetworks reads the networks file at path, it returns nil without an error when there is none
+func LoadNetworks(path string) (*NetworksConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read networks file: %v", err)
+	}
+
+	config := &NetworksConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse networks file %s: %v", path, err)
+	}
+	if len(config.Networks) == 0 {
+		return nil, fmt.Errorf("networks file %s has no networks", path)
+	}
+
+	names := make(map[string]bool, len(config.Networks))
+	for _, profile := range config.Networks {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("networks file %s has a network without a name", path)
+		}
+		if names[profile.Name] {
+			return nil, fmt.Errorf("networks file %s has network %s twice", path, profile.Name)
+		}
+		names[profile.Name] = true
+		if profile.RPCURL == "" {
+			return nil, fmt.Errorf("network %s has no rpcUrl", profile.Name)
+		}
+		if _, err := profile.FeePolicy(); err != nil {
+			return nil, err
+		}
+	}
+	if config.Default != "" && !names[config.Default] {
+		return nil, fmt.Errorf("default network %s is not in %s", config.Default, path)
+	}
+	return config, nil
+}
+
+// Profile returns the profile called name, an empty name picks the default or else the first profile
+func (c *NetworksConfig) Profile(name string) (NetworkProfile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return c.Networks[0], nil
+	}
+	for _, profile := range c.Networks {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return NetworkProfile{}, fmt.Errorf("unknown network %s", name)
+}
+
+// ChainIDMismatchError is returned when the node serves a different chain than the
+// network profile expects, nothing was signed
+type ChainIDMismatchError struct {
+	Expected *big.Int
+	Actual   *big.Int
+}
+
+func (e *ChainIDMismatchError) Error() string {
+	return fmt.Sprintf("node is on chain %s but the network profile expects chain %s", e.Actual, e.Expected)
+}
+
+// checkChainID fails when chainID is not the chain the network profile pins
+func (s *NftService) checkChainID(chainID *big.Int) error {
+	s.mu.Lock()
+	expected := s.expectedChainID
+	s.mu.Unlock()
+
+	if expected != nil && expected.Cmp(chainID) != 0 {
+		return &ChainIDMismatchError{Expected: expected, Actual: chainID}
+	}
+	return nil
+}
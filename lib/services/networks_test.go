@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testNetworksJSON = `{
+	"default": "hardhat",
+	"networks": [
+		{"name": "hardhat", "rpcUrl": "http://localhost:8545", "chainId": 31337},
+		{"name": "mainnet", "rpcUrl": "https://eth.example.com", "chainId": 1, "mainnet": true,
+		 "explorer": "https://etherscan.io/tx/{tx}", "maxFeePerGasGwei": "50"}
+	]
+}`
+
+func TestLoadNetworks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "networks.json")
+
+	config, err := LoadNetworks(path)
+	require.NoError(t, err)
+	require.Nil(t, config)
+
+	require.NoError(t, os.WriteFile(path, []byte(testNetworksJSON), 0644))
+	config, err = LoadNetworks(path)
+	require.NoError(t, err)
+
+	profile, err := config.Profile("")
+	require.NoError(t, err)
+	require.Equal(t, "hardhat", profile.Name)
+
+	profile, err = config.Profile("mainnet")
+	require.NoError(t, err)
+	require.True(t, profile.Mainnet)
+	require.Equal(t, "https://etherscan.io/tx/0xabc", profile.TxURL("0xabc"))
+	policy, err := profile.FeePolicy()
+	require.NoError(t, err)
+	require.Equal(t, "50000000000", policy.MaxFeePerGas.String())
+
+	_, err = config.Profile("sepolia")
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"networks": [{"name": "a", "rpcUrl": "x"}, {"name": "a", "rpcUrl": "y"}]}`), 0644))
+	_, err = LoadNetworks(path)
+	require.ErrorContains(t, err, "twice")
+}
+
+func (s *NftServiceTestSuite) TestChainIDPinning() {
+	ctx := context.Background()
+	contractAddress := s.deployContract()
+
+	// the simulated chain is 1337, a profile for mainnet must not sign anything on it
+	s.NftService.SetNetwork("", 1)
+	err := s.NftService.SetURI(ctx, contractAddress, "https://api.example.com/wrong/{id}")
+	var mismatch *ChainIDMismatchError
+	s.Require().ErrorAs(err, &mismatch)
+	s.Require().Equal(int64(1337), mismatch.Actual.Int64())
+
+	_, err = s.NftService.VerifyChain(ctx)
+	s.Require().ErrorAs(err, &mismatch)
+
+	s.NftService.SetNetwork("", 1337)
+	chainID, err := s.NftService.VerifyChain(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1337), chainID.Int64())
+	s.Require().NoError(s.NftService.SetURI(ctx, contractAddress, "https://api.example.com/right/{id}"))
+}
+
+func (s *NftServiceTestSuite) TestNetworkSwitchWhileSending() {
+	ctx := context.Background()
+	contractAddress := s.deployContract()
+	tracker := NewTxTracker(filepath.Join(s.T().TempDir(), DefaultTxTrackerPath))
+
+	// a switch reconfigures the service while a background command is still sending
+	done := make(chan struct{})
+	switched := make(chan struct{})
+	go func() {
+		defer close(switched)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			s.NftService.SetTimeouts(Timeouts{})
+			s.NftService.SetFeePolicy(FeePolicy{})
+			s.NftService.SetGasLimitMultiplier(DefaultGasLimitMultiplier)
+			s.NftService.SetTxTracker(tracker)
+		}
+	}()
+	for range 3 {
+		s.Require().NoError(s.NftService.SetURI(ctx, contractAddress, "https://api.example.com/switch/{id}"))
+	}
+	close(done)
+	<-switched
+}
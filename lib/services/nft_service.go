@@ -2,36 +2,52 @@ package services
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
+	"io"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type NFTServiceInterface interface {
-	DeployContractWithABI(params DeployContractParams) (contractAddress string, err error)
+	DeployContractWithABI(ctx context.Context, params DeployContractParams) (contractAddress string, err error)
 }
 
+// myTokenConstructorABI is the constructor deployments fall back to when no ConstructorABI is given
+const myTokenConstructorABI = `[{
+	"inputs": [
+		{"name": "initialOwner", "type": "address"},
+		{"name": "newuri", "type": "string"}
+	],
+	"stateMutability": "nonpayable",
+	"type": "constructor"
+}]`
+
 // DeployContractParams contains all parameters needed for contract deployment
 type DeployContractParams struct {
 	// The compiled bytecode of the smart contract
 	Bytecode string
-	// The ABI of the constructor
+	// The ABI of the constructor, the full contract ABI works too. When empty the
+	// MyToken constructor is used with InitialOwner and InitialURI as its arguments
 	ConstructorABI string
 	// Arguments to pass to the constructor
 	ConstructorArgs []any
-	// Optional gas limit (if not set, default 3000000 will be used)
+	// Optional gas limit (if not set, the estimate times the gas limit multiplier will be used)
 	GasLimit uint64
 	// Optional value to send with deployment (if not set, 0 will be used)
 	Value *big.Int
-	// 添加新字段
+	// Optional fee caps overriding the service's fee policy for this deployment
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	// MyToken constructor arguments, only used when ConstructorABI is empty
 	InitialURI   string
 	InitialOwner string
 }
@@ -46,266 +62,646 @@ type ContractCallParams struct {
 	FunctionName string
 	// Arguments to pass to the function
 	FunctionArgs []any
-	// Optional gas limit (if not set, default 300000 will be used)
+	// Optional gas limit (if not set, the estimate times the gas limit multiplier will be used)
 	GasLimit uint64
 	// Optional value to send with the transaction (if not set, 0 will be used)
 	Value *big.Int
+	// Optional fee caps overriding the service's fee policy for this call
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// ChainBackend is the subset of ethclient.Client that NftService talks to.
+// It is satisfied by *ethclient.Client and by go-ethereum's simulated backend client.
+type ChainBackend interface {
+	bind.DeployBackend
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
 }
 
+// healthCheckInterval is how long a connection is trusted before it is pinged again
+const healthCheckInterval = 30 * time.Second
+
 type NftService struct {
-	rpcUrl     string
-	privateKey string
+	rpcUrl  string
+	backend ChainBackend
+
+	// mu guards the long-lived RPC connection owned by the service, the signer and the
+	// settings, which a network switch changes while operations run
+	mu        sync.Mutex
+	feePolicy FeePolicy
+	// gasMultiplier pads gas estimates when no explicit gas limit is given
+	gasMultiplier float64
+	timeouts      Timeouts
+	// tracker records the broadcast transactions, nil when they are not tracked
+	tracker *TxTracker
+
+	client      *ethclient.Client
+	lastHealthy time.Time
+	// signer is nil until a keystore is unlocked, signerErr reports an invalid private key
+	signer    Signer
+	signerErr error
+	// expectedChainID is the chain of the network profile, nil skips the check
+	expectedChainID *big.Int
+}
+
+// serviceSettings are the settings an operation runs with, copied when it starts so a
+// network switch meanwhile does not change them half way
+type serviceSettings struct {
+	timeouts      Timeouts
+	feePolicy     FeePolicy
+	gasMultiplier float64
+	tracker       *TxTracker
+}
+
+// settings copies the current settings
+func (s *NftService) settings() serviceSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return serviceSettings{
+		timeouts:      s.timeouts,
+		feePolicy:     s.feePolicy,
+		gasMultiplier: s.gasMultiplier,
+		tracker:       s.tracker,
+	}
 }
 
 func NewNftService(rpcUrl string, privateKey string) *NftService {
+	signer, err := NewPrivateKeySigner(privateKey)
+	service := NewNftServiceWithSigner(rpcUrl, signer)
+	service.signerErr = err
+	return service
+}
 
+// NewNftServiceWithSigner creates a service that signs with signer, which may be nil
+// until SetSigner is called, e.g. after the keystore is unlocked
+func NewNftServiceWithSigner(rpcUrl string, signer Signer) *NftService {
 	return &NftService{
-		rpcUrl:     rpcUrl,
-		privateKey: strings.TrimPrefix(privateKey, "0x"),
+		rpcUrl:        rpcUrl,
+		signer:        signer,
+		gasMultiplier: DefaultGasLimitMultiplier,
+		timeouts:      DefaultTimeouts,
 	}
 }
 
-// getKeyPair converts a private key string to ECDSA private key and corresponding public address
-// Parameters:
-//   - privateKeyStr: The private key in string format (without 0x prefix)
-//
-// Returns:
-//   - *ecdsa.PrivateKey: The ECDSA private key
-//   - common.Address: The Ethereum address derived from the public key
-//   - error: Any error that occurred during conversion
-func (s *NftService) getKeyPair() (privateKey *ecdsa.PrivateKey, fromAddress common.Address, err error) {
-	// check if privatekey starts with 0x
-	// Convert private key string to ECDSA private key
-	privateKey, err = crypto.HexToECDSA(s.privateKey)
+// NewNftServiceWithBackend creates a service that sends every call through the given backend
+// instead of dialing rpcUrl, e.g. an in-process simulated chain in tests
+func NewNftServiceWithBackend(backend ChainBackend, privateKey string) *NftService {
+	service := NewNftService("", privateKey)
+	service.backend = backend
+	return service
+}
+
+// SetSigner replaces the account transactions are signed with
+func (s *NftService) SetSigner(signer Signer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signer, s.signerErr = signer, nil
+}
+
+// SetNetwork points the service at another RPC endpoint and pins the chain it must serve,
+// a chainID of 0 skips the check. The current connection is closed.
+func (s *NftService) SetNetwork(rpcUrl string, chainID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+	s.rpcUrl = rpcUrl
+	s.expectedChainID = nil
+	if chainID != 0 {
+		s.expectedChainID = new(big.Int).SetUint64(chainID)
+	}
+}
+
+// VerifyChain returns the chain ID of the node and fails when the network profile expects another one
+func (s *NftService) VerifyChain(ctx context.Context) (chainID *big.Int, err error) {
+	client, err := s.getClient(ctx)
 	if err != nil {
-		return nil, common.Address{}, err
+		return nil, err
 	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
 
-	// Get the public key and address
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, common.Address{}, fmt.Errorf("error casting public key to ECDSA")
+	chainCtx, cancel := context.WithTimeout(ctx, s.settings().timeouts.Send)
+	defer cancel()
+	chainID, err = client.ChainID(chainCtx)
+	if err != nil {
+		return nil, err
+	}
+	return chainID, s.checkChainID(chainID)
+}
+
+// SetFeePolicy sets the default fee caps applied to every transaction
+func (s *NftService) SetFeePolicy(policy FeePolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feePolicy = policy
+}
+
+// SetGasLimitMultiplier sets the safety multiplier applied to gas estimates
+func (s *NftService) SetGasLimitMultiplier(multiplier float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gasMultiplier = multiplier
+}
+
+// SetTimeouts sets the dial, send and mining timeouts, zero values keep the defaults
+func (s *NftService) SetTimeouts(timeouts Timeouts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeouts = timeouts.withDefaults()
+}
+
+// getClient returns the chain backend shared by all calls.
+// The RPC connection is dialed lazily on first use, pinged when it has not been
+// used successfully for healthCheckInterval and redialed when the ping fails.
+func (s *NftService) getClient(ctx context.Context) (ChainBackend, error) {
+	if s.backend != nil {
+		return s.backend, nil
 	}
-	fromAddress = crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	return privateKey, fromAddress, nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil && time.Since(s.lastHealthy) > healthCheckInterval {
+		pingCtx, cancel := context.WithTimeout(ctx, s.timeouts.Dial)
+		_, err := s.client.ChainID(pingCtx)
+		cancel()
+		if err != nil {
+			// the connection is broken, drop it and dial again below
+			s.client.Close()
+			s.client = nil
+		} else {
+			s.lastHealthy = time.Now()
+		}
+	}
+
+	if s.client == nil {
+		dialCtx, cancel := context.WithTimeout(ctx, s.timeouts.Dial)
+		client, err := ethclient.DialContext(dialCtx, s.rpcUrl)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %v", s.rpcUrl, err)
+		}
+		s.client = client
+		s.lastHealthy = time.Now()
+	}
+
+	return s.client, nil
+}
+
+// markUnhealthy forces a health check of the connection before it is used again
+func (s *NftService) markUnhealthy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastHealthy = time.Time{}
+}
+
+// Close releases the RPC connection owned by the service and disconnects a signer that
+// holds a connection, like a remote signer. An injected backend is left open, it belongs
+// to the caller.
+func (s *NftService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+	if closer, ok := s.signer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// SignerAddress returns the address transactions are sent from
+func (s *NftService) SignerAddress() (string, error) {
+	signer, err := s.getSigner()
+	if err != nil {
+		return "", err
+	}
+	return signer.Address().Hex(), nil
+}
+
+// getSigner returns the signer transactions are sent with
+func (s *NftService) getSigner() (Signer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.signerErr != nil {
+		return nil, s.signerErr
+	}
+	if s.signer == nil {
+		return nil, fmt.Errorf("no signer, unlock the keystore first")
+	}
+	return s.signer, nil
+}
+
+// DeployResult describes a mined contract deployment
+type DeployResult struct {
+	ContractAddress string
+	TxHash          string
+	BlockNumber     uint64
+	Deployer        string
+	ChainID         uint64
 }
 
 // DeployContractWithABI deploys smart contract to the blockchain with constructor arguments
 // Parameters:
+//   - ctx: Cancels the deployment, the send and mining timeouts apply on top of it
 //   - params: DeployContractParams struct containing all deployment parameters
 //
 // Returns:
 //   - contractAddress: The address where the contract was deployed
 //   - error: Any error that occurred during deployment
-func (s *NftService) DeployContractWithABI(params DeployContractParams) (contractAddress string, err error) {
-	// Connect to the Ethereum client
-	client, err := ethclient.Dial(s.rpcUrl)
+func (s *NftService) DeployContractWithABI(ctx context.Context, params DeployContractParams) (contractAddress string, err error) {
+	result, err := s.DeployContract(ctx, params)
 	if err != nil {
 		return "", err
 	}
-	defer client.Close()
+	return result.ContractAddress, nil
+}
 
-	// Get the key pair
-	privateKey, fromAddress, err := s.getKeyPair()
-	if err != nil {
-		return "", err
-	}
+// DeployContract deploys like DeployContractWithABI and also reports the deployment
+// transaction, the block it was mined in and the deployer
+func (s *NftService) DeployContract(ctx context.Context, params DeployContractParams) (result *DeployResult, err error) {
+	// Settings of this transaction, a network switch meanwhile does not change them
+	cfg := s.settings()
 
-	// Get the nonce
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	// Get the shared Ethereum client
+	client, err := s.getClient(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
 
-	// Get gas price
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	// Get the signer
+	signer, err := s.getSigner()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	fromAddress := signer.Address()
 
-	// Decode the bytecode
-	decodedBytecode := common.FromHex(params.Bytecode)
+	// Bound the RPCs needed to build and broadcast the transaction
+	sendCtx, cancelSend := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelSend()
 
-	// 获取部署者地址作为默认的 initialOwner（如果未指定）
-	if params.InitialOwner == "" {
-		params.InitialOwner = fromAddress.Hex()
+	// Get the nonce
+	nonce, err := client.PendingNonceAt(sendCtx, fromAddress)
+	if err != nil {
+		return nil, err
 	}
 
-	// 设置构造函数参数
-	params.ConstructorABI = `[{
-		"inputs": [
-			{"name": "initialOwner", "type": "address"},
-			{"name": "newuri", "type": "string"}
-		],
-		"stateMutability": "nonpayable",
-		"type": "constructor"
-	}]`
-
-	params.ConstructorArgs = []interface{}{
-		common.HexToAddress(params.InitialOwner),
-		params.InitialURI,
+	// Price the transaction, EIP-1559 when the chain supports it
+	fees, err := suggestFees(sendCtx, client, cfg.feePolicy.merge(FeePolicy{
+		MaxFeePerGas:         params.MaxFeePerGas,
+		MaxPriorityFeePerGas: params.MaxPriorityFeePerGas,
+	}))
+	if err != nil {
+		return nil, err
 	}
 
-	// If we have constructor arguments, encode them and append to bytecode
-	if len(params.ConstructorArgs) > 0 {
-		parsedABI, err := abi.JSON(strings.NewReader(params.ConstructorABI))
-		if err != nil {
-			return "", fmt.Errorf("failed to parse constructor ABI: %v", err)
-		}
-
-		// Pack the constructor arguments
-		encodedArgs, err := parsedABI.Pack("", params.ConstructorArgs...)
-		if err != nil {
-			return "", fmt.Errorf("failed to encode constructor arguments: %v", err)
-		}
-
-		// Append encoded arguments to bytecode
-		decodedBytecode = append(decodedBytecode, encodedArgs...)
+	// Pack the bytecode with the constructor arguments
+	msg, params, err := packDeployment(fromAddress, params)
+	if err != nil {
+		return nil, err
 	}
 
-	// Set default gas limit if not provided
-	gasLimit := params.GasLimit
-	if gasLimit == 0 {
-		gasLimit = 3000000
+	// Estimate the gas limit unless one is provided
+	gasLimit, err := estimateGasLimit(sendCtx, client, msg, params.GasLimit, cfg.gasMultiplier)
+	if err != nil {
+		return nil, err
 	}
 
-	// Set default value if not provided
-	value := params.Value
-	if value == nil {
-		value = big.NewInt(0)
+	// Get the chain ID and make sure it is the chain of the network profile
+	chainID, err := client.ChainID(sendCtx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkChainID(chainID); err != nil {
+		return nil, err
 	}
 
 	// Create transaction data
-	tx := types.NewContractCreation(nonce, value, gasLimit, gasPrice, decodedBytecode)
+	tx := newTransaction(chainID, nonce, nil, msg.Value, gasLimit, msg.Data, fees)
 
-	// Get the chain ID
-	chainID, err := client.ChainID(context.Background())
+	// Sign the transaction, a signer asking for approval gets its own timeout
+	signedTx, err := s.signTx(ctx, cfg, signer, tx, chainID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Sign the transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	// Send the transaction, tracked so it can be sped up or cancelled while it is pending
+	broadcastCtx, cancelBroadcast := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelBroadcast()
+	err = s.sendTracked(broadcastCtx, cfg, client, "deploy", fromAddress, signedTx, AttemptOriginal)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Send the transaction
-	err = client.SendTransaction(context.Background(), signedTx)
+	// Wait for the transaction to be mined
+	receipt, err := s.waitMined(ctx, cfg, client, signedTx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Wait for the transaction to be mined
-	receipt, err := bind.WaitMined(context.Background(), client, signedTx)
-	if err != nil {
-		return "", err
+	// A mined transaction can still have reverted
+	replayCtx, cancelReplay := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelReplay()
+	if err := checkReceipt(replayCtx, client, receipt, toCallMsg(fromAddress, signedTx), params.ConstructorABI); err != nil {
+		return nil, err
 	}
 
-	// Return the contract address
-	return receipt.ContractAddress.Hex(), nil
+	return &DeployResult{
+		ContractAddress: receipt.ContractAddress.Hex(),
+		TxHash:          receipt.TxHash.Hex(),
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Deployer:        fromAddress.Hex(),
+		ChainID:         chainID.Uint64(),
+	}, nil
 }
 
 // CallContractFunction executes a function on a deployed smart contract
 // Parameters:
+//   - ctx: Cancels the call, the send and mining timeouts apply on top of it
 //   - params: ContractCallParams struct containing all call parameters
 //
 // Returns:
 //   - txHash: The transaction hash of the executed function call
 //   - error: Any error that occurred during the function call
-func (s *NftService) CallContractFunction(params ContractCallParams) (txHash string, err error) {
-	// Connect to the Ethereum client
-	client, err := ethclient.Dial(s.rpcUrl)
+func (s *NftService) CallContractFunction(ctx context.Context, params ContractCallParams) (txHash string, err error) {
+	return s.callContractFunction(ctx, params, nil)
+}
+
+// callContractFunction is CallContractFunction with a hook that runs after signing and
+// before broadcasting, an error from onSigned aborts without sending anything
+func (s *NftService) callContractFunction(ctx context.Context, params ContractCallParams, onSigned func(*types.Transaction) error) (txHash string, err error) {
+	// Settings of this transaction, a network switch meanwhile does not change them
+	cfg := s.settings()
+
+	// Get the shared Ethereum client
+	client, err := s.getClient(ctx)
 	if err != nil {
 		return "", err
 	}
-	defer client.Close()
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
 
-	// Get the key pair
-	privateKey, fromAddress, err := s.getKeyPair()
+	// Get the signer
+	signer, err := s.getSigner()
 	if err != nil {
 		return "", err
 	}
+	fromAddress := signer.Address()
 
-	// Parse the contract ABI
-	parsedABI, err := abi.JSON(strings.NewReader(params.ContractABI))
-	if err != nil {
-		return "", fmt.Errorf("failed to parse contract ABI: %v", err)
-	}
+	// Bound the RPCs needed to build and broadcast the transaction
+	sendCtx, cancelSend := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelSend()
 
 	// Pack the function data
-	data, err := parsedABI.Pack(params.FunctionName, params.FunctionArgs...)
+	msg, err := packContractCall(fromAddress, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to pack function data: %v", err)
+		return "", err
 	}
 
 	// Get the nonce
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := client.PendingNonceAt(sendCtx, fromAddress)
 	if err != nil {
 		return "", err
 	}
 
-	// Get gas price
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	// Price the transaction, EIP-1559 when the chain supports it
+	fees, err := suggestFees(sendCtx, client, cfg.feePolicy.merge(FeePolicy{
+		MaxFeePerGas:         params.MaxFeePerGas,
+		MaxPriorityFeePerGas: params.MaxPriorityFeePerGas,
+	}))
 	if err != nil {
 		return "", err
 	}
 
-	// Set default gas limit if not provided
-	gasLimit := params.GasLimit
-	if gasLimit == 0 {
-		gasLimit = 300000
+	// Estimate the gas limit unless one is provided
+	gasLimit, err := estimateGasLimit(sendCtx, client, msg, params.GasLimit, cfg.gasMultiplier)
+	if err != nil {
+		return "", err
 	}
 
-	// Set default value if not provided
-	value := params.Value
-	if value == nil {
-		value = big.NewInt(0)
+	// Get the chain ID and make sure it is the chain of the network profile
+	chainID, err := client.ChainID(sendCtx)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkChainID(chainID); err != nil {
+		return "", err
 	}
 
 	// Create transaction data
-	contractAddress := common.HexToAddress(params.ContractAddress)
-	tx := types.NewTransaction(
-		nonce,
-		contractAddress,
-		value,
-		gasLimit,
-		gasPrice,
-		data,
-	)
-
-	// Get the chain ID
-	chainID, err := client.ChainID(context.Background())
+	tx := newTransaction(chainID, nonce, msg.To, msg.Value, gasLimit, msg.Data, fees)
+
+	// Sign the transaction, a signer asking for approval gets its own timeout
+	signedTx, err := s.signTx(ctx, cfg, signer, tx, chainID)
 	if err != nil {
 		return "", err
 	}
 
-	// Sign the transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
-	if err != nil {
-		return "", err
+	if onSigned != nil {
+		if err := onSigned(signedTx); err != nil {
+			return "", err
+		}
 	}
 
-	// Send the transaction
-	err = client.SendTransaction(context.Background(), signedTx)
+	// Send the transaction, tracked so it can be sped up or cancelled while it is pending
+	broadcastCtx, cancelBroadcast := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelBroadcast()
+	err = s.sendTracked(broadcastCtx, cfg, client, params.FunctionName, fromAddress, signedTx, AttemptOriginal)
 	if err != nil {
 		return "", err
 	}
 
 	// Wait for the transaction to be mined
-	receipt, err := bind.WaitMined(context.Background(), client, signedTx)
+	receipt, err := s.waitMined(ctx, cfg, client, signedTx)
 	if err != nil {
 		return "", err
 	}
 
+	// A mined transaction can still have reverted
+	replayCtx, cancelReplay := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelReplay()
+	if err := checkReceipt(replayCtx, client, receipt, toCallMsg(fromAddress, signedTx), params.ContractABI); err != nil {
+		return "", err
+	}
+
 	// Return the transaction hash
 	return receipt.TxHash.Hex(), nil
 }
 
-// MintNFTToAddresses mints NFTs to multiple addresses
-func (s *NftService) MintNFTToAddresses(contractAddr string, addresses []string, nftID string) (string, error) {
+// packDeployment encodes the contract creation described by params as a message sent from
+// fromAddress. It also returns params with the constructor ABI and arguments filled in.
+func packDeployment(fromAddress common.Address, params DeployContractParams) (ethereum.CallMsg, DeployContractParams, error) {
+	// Decode the bytecode
+	decodedBytecode := common.FromHex(params.Bytecode)
+
+	// 未指定构造函数时沿用 MyToken 的 (initialOwner, newuri)
+	if params.ConstructorABI == "" {
+		// 获取部署者地址作为默认的 initialOwner（如果未指定）
+		if params.InitialOwner == "" {
+			params.InitialOwner = fromAddress.Hex()
+		}
+		params.ConstructorABI = myTokenConstructorABI
+		params.ConstructorArgs = []interface{}{
+			common.HexToAddress(params.InitialOwner),
+			params.InitialURI,
+		}
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(params.ConstructorABI))
+	if err != nil {
+		return ethereum.CallMsg{}, params, fmt.Errorf("failed to parse constructor ABI: %v", err)
+	}
+	if params.Value != nil && params.Value.Sign() > 0 && !parsedABI.Constructor.IsPayable() {
+		return ethereum.CallMsg{}, params, fmt.Errorf("constructor is not payable, cannot send value %s", params.Value)
+	}
+
+	// If the constructor takes arguments, encode them and append to bytecode
+	if len(params.ConstructorArgs) > 0 || len(parsedABI.Constructor.Inputs) > 0 {
+		encodedArgs, err := parsedABI.Pack("", params.ConstructorArgs...)
+		if err != nil {
+			return ethereum.CallMsg{}, params, fmt.Errorf("failed to encode constructor arguments: %v", err)
+		}
+
+		// Append encoded arguments to bytecode
+		decodedBytecode = append(decodedBytecode, encodedArgs...)
+	}
+
+	// Set default value if not provided
+	value := params.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	return ethereum.CallMsg{
+		From:  fromAddress,
+		Value: value,
+		Data:  decodedBytecode,
+	}, params, nil
+}
+
+// packContractCall encodes the function call described by params as a message sent from fromAddress
+func packContractCall(fromAddress common.Address, params ContractCallParams) (ethereum.CallMsg, error) {
+	// Parse the contract ABI
+	parsedABI, err := abi.JSON(strings.NewReader(params.ContractABI))
+	if err != nil {
+		return ethereum.CallMsg{}, fmt.Errorf("failed to parse contract ABI: %v", err)
+	}
+
+	// Pack the function data
+	data, err := parsedABI.Pack(params.FunctionName, params.FunctionArgs...)
+	if err != nil {
+		return ethereum.CallMsg{}, fmt.Errorf("failed to pack function data: %v", err)
+	}
+
+	// Set default value if not provided
+	value := params.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	contractAddress := common.HexToAddress(params.ContractAddress)
+	return ethereum.CallMsg{
+		From:  fromAddress,
+		To:    &contractAddress,
+		Value: value,
+		Data:  data,
+	}, nil
+}
+
+// EstimateContractCall returns the gas limit CallContractFunction would use for params
+// without signing or sending anything
+func (s *NftService) EstimateContractCall(ctx context.Context, params ContractCallParams) (gasLimit uint64, err error) {
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	signer, err := s.getSigner()
+	if err != nil {
+		return 0, err
+	}
+	fromAddress := signer.Address()
+
+	msg, err := packContractCall(fromAddress, params)
+	if err != nil {
+		return 0, err
+	}
+
+	estimateCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancel()
+	return estimateGasLimit(estimateCtx, client, msg, params.GasLimit, cfg.gasMultiplier)
+}
+
+// MintNFTToAddresses mints one nftID token to every address.
+// Addresses are split into batches of the contract's batchSize and each batch is sent
+// as its own transaction once the previous one is mined. onBatch, when not nil, is
+// called after every batch. On failure the hashes of the batches already mined are
+// returned together with the error.
+func (s *NftService) MintNFTToAddresses(ctx context.Context, contractAddr string, addresses []string, nftID string, onBatch func(BatchStatus)) ([]string, error) {
+	journal, err := s.PlanAirdrop(ctx, "", contractAddr, addresses, nftID, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckOwner(ctx, contractAddr); err != nil {
+		return nil, err
+	}
+	return s.runBatches(ctx, journal, onBatch)
+}
+
+// EstimateMintNFTToAddresses returns the gas limit MintNFTToAddresses would use for each batch
+func (s *NftService) EstimateMintNFTToAddresses(ctx context.Context, contractAddr string, addresses []string, nftID string) ([]uint64, error) {
+	calls, err := s.mintBatches(ctx, contractAddr, addresses, nftID)
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := make([]uint64, len(calls))
+	for i, params := range calls {
+		estimates[i], err = s.EstimateContractCall(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("batch %d/%d: %w", i+1, len(calls), err)
+		}
+	}
+	return estimates, nil
+}
+
+// mintParams builds the mintToMultple call minting one nftID token to every address
+func mintParams(contractAddr string, addresses []string, nftID string) (ContractCallParams, error) {
 	// 将字符串地址转换为 common.Address 数组
 	recipients := make([]common.Address, len(addresses))
 	for i, addr := range addresses {
@@ -316,16 +712,16 @@ func (s *NftService) MintNFTToAddresses(contractAddr string, addresses []string,
 	// 去除可能的空格
 	trimmedNftID := strings.TrimSpace(nftID)
 	if trimmedNftID == "" {
-		return "", fmt.Errorf("NFT ID不能为空")
+		return ContractCallParams{}, fmt.Errorf("NFT ID不能为空")
 	}
 
 	tokenID, ok := new(big.Int).SetString(trimmedNftID, 10)
 	if !ok {
-		return "", fmt.Errorf("无效的 NFT ID: %s", trimmedNftID)
+		return ContractCallParams{}, fmt.Errorf("无效的 NFT ID: %s", trimmedNftID)
 	}
 
 	// 准备调用参数
-	params := ContractCallParams{
+	return ContractCallParams{
 		ContractAddress: contractAddr,
 		ContractABI: `[{
 			"inputs": [
@@ -346,16 +742,29 @@ func (s *NftService) MintNFTToAddresses(contractAddr string, addresses []string,
 			big.NewInt(1), // *big.Int
 			[]byte{},      // bytes
 		},
-		GasLimit: 500000, // 增加 gas limit 以处理多个地址
+	}, nil
+}
+
+// SetURI sets the base URI for all tokens
+func (s *NftService) SetURI(ctx context.Context, contractAddr string, newURI string) error {
+	// setURI 只有 owner 能调用，先确认签名账户是 owner
+	if err := s.CheckOwner(ctx, contractAddr); err != nil {
+		return err
 	}
 
 	// 调用合约
-	return s.CallContractFunction(params)
+	_, err := s.CallContractFunction(ctx, SetURIParams(contractAddr, newURI))
+	return err
 }
 
-// SetURI sets the base URI for all tokens
-func (s *NftService) SetURI(contractAddr string, newURI string) error {
-	params := ContractCallParams{
+// EstimateSetURI returns the gas limit SetURI would use
+func (s *NftService) EstimateSetURI(ctx context.Context, contractAddr string, newURI string) (uint64, error) {
+	return s.EstimateContractCall(ctx, SetURIParams(contractAddr, newURI))
+}
+
+// SetURIParams builds the setURI call
+func SetURIParams(contractAddr string, newURI string) ContractCallParams {
+	return ContractCallParams{
 		ContractAddress: contractAddr,
 		ContractABI: `[{
 			"inputs": [
@@ -370,10 +779,5 @@ func (s *NftService) SetURI(contractAddr string, newURI string) error {
 		FunctionArgs: []interface{}{
 			newURI,
 		},
-		GasLimit: 300000,
 	}
-
-	// 调用合约
-	_, err := s.CallContractFunction(params)
-	return err
 }
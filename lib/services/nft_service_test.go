@@ -1,25 +1,39 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"math/big"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
 	"github.com/stretchr/testify/suite"
 )
 
 type NftServiceTestSuite struct {
 	suite.Suite
 	NftService *NftService
+	Backend    *simulated.Backend
+	Client     simulated.Client
+	AbiInfo    AbiInfoFile
+	AbiJSON    string
 }
 
 const (
-	HardhatNodeUrl    = "http://localhost:8545"
 	HardhatPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
 	HardhatAddress    = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
-	AbiPath           = "../../artifacts/contracts/nft.sol/MyToken.json"
+	// Hardhat account #1, funded but not the owner of deployed contracts
+	OtherPrivateKey = "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+	OtherAddress    = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+	// AbiPath is a Hardhat artifact of contracts/nft.sol checked in so the suite runs without compiling
+	AbiPath = "testdata/MyToken.json"
 )
 
 type AbiInfoFile struct {
@@ -27,131 +41,181 @@ type AbiInfoFile struct {
 	Bytecode string `json:"bytecode"`
 }
 
-// run hardhat node before running the tests
+// autoCommitBackend mines a block after every transaction so bind.WaitMined returns right away
+type autoCommitBackend struct {
+	simulated.Client
+	sim *simulated.Backend
+}
+
+func (b *autoCommitBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.sim.Commit()
+	return nil
+}
+
+// the suite runs against an in-process simulated chain, no hardhat node required
 func TestNftServiceSuite(t *testing.T) {
 	suite.Run(t, new(NftServiceTestSuite))
 }
 
 func (s *NftServiceTestSuite) SetupTest() {
-	s.NftService = NewNftService(HardhatNodeUrl, HardhatPrivateKey)
-}
+	// fund the hardhat accounts #0 and #1 on a fresh simulated chain
+	balance, _ := new(big.Int).SetString("10000000000000000000000", 10)
+	s.Backend = simulated.NewBackend(types.GenesisAlloc{
+		common.HexToAddress(HardhatAddress): {Balance: balance},
+		common.HexToAddress(OtherAddress):   {Balance: balance},
+	})
+	s.Client = s.Backend.Client()
+	s.NftService = NewNftServiceWithBackend(&autoCommitBackend{Client: s.Client, sim: s.Backend}, HardhatPrivateKey)
 
-func (s *NftServiceTestSuite) TestDeployContract() {
 	// read bytecode from file
 	abiInfoFile, err := os.ReadFile(AbiPath)
 	if err != nil {
 		s.T().Fatalf("failed to read bytecode: %v", err)
 	}
 
-	var abiInfo AbiInfoFile
-	err = json.Unmarshal(abiInfoFile, &abiInfo)
+	err = json.Unmarshal(abiInfoFile, &s.AbiInfo)
 	if err != nil {
 		s.T().Fatalf("failed to unmarshal abi info: %v", err)
 	}
 
-	abiBytes, err := json.Marshal(abiInfo.Abi)
+	abiBytes, err := json.Marshal(s.AbiInfo.Abi)
 	if err != nil {
 		s.T().Fatalf("failed to marshal abi: %v", err)
 	}
+	s.AbiJSON = string(abiBytes)
+}
+
+func (s *NftServiceTestSuite) TearDownTest() {
+	s.Require().NoError(s.Backend.Close())
+}
 
+// deployContract deploys the fixture contract owned by the hardhat account
+func (s *NftServiceTestSuite) deployContract() string {
 	// Convert the address string to common.Address
 	initialOwner := common.HexToAddress(HardhatAddress)
 
 	contractAddress, err := s.NftService.DeployContractWithABI(
+		context.Background(),
 		DeployContractParams{
-			Bytecode:       string(abiInfo.Bytecode),
-			ConstructorABI: string(abiBytes),
+			Bytecode:       s.AbiInfo.Bytecode,
+			ConstructorABI: s.AbiJSON,
 			ConstructorArgs: []any{
 				initialOwner,
+				"https://api.example.com/init/{id}",
 			},
 		},
 	)
-	if err != nil {
-		s.T().Fatalf("failed to deploy contract: %v", err)
-	}
-	s.Require().NotNil(contractAddress)
+	s.Require().NoError(err)
 	s.Require().NotEmpty(contractAddress)
+	return contractAddress
 }
 
-func (s *NftServiceTestSuite) TestSetURI() {
-	// First deploy the contract
-	abiInfoFile, err := os.ReadFile(AbiPath)
-	if err != nil {
-		s.T().Fatalf("failed to read bytecode: %v", err)
-	}
+// balanceOf reads the ERC1155 balance of account for tokenID straight from the chain
+func (s *NftServiceTestSuite) balanceOf(contractAddress string, account string, tokenID int64) *big.Int {
+	parsedABI, err := abi.JSON(strings.NewReader(s.AbiJSON))
+	s.Require().NoError(err)
 
-	var abiInfo AbiInfoFile
-	err = json.Unmarshal(abiInfoFile, &abiInfo)
-	if err != nil {
-		s.T().Fatalf("failed to unmarshal abi info: %v", err)
-	}
+	data, err := parsedABI.Pack("balanceOf", common.HexToAddress(account), big.NewInt(tokenID))
+	s.Require().NoError(err)
 
-	abiBytes, err := json.Marshal(abiInfo.Abi)
-	if err != nil {
-		s.T().Fatalf("failed to marshal abi: %v", err)
-	}
+	contract := common.HexToAddress(contractAddress)
+	output, err := s.Client.CallContract(context.Background(), ethereum.CallMsg{To: &contract, Data: data}, nil)
+	s.Require().NoError(err)
 
-	initialOwner := common.HexToAddress(HardhatAddress)
+	values, err := parsedABI.Unpack("balanceOf", output)
+	s.Require().NoError(err)
+	return values[0].(*big.Int)
+}
 
-	// Deploy the contract
-	contractAddress, err := s.NftService.DeployContractWithABI(
-		DeployContractParams{
-			Bytecode:       string(abiInfo.Bytecode),
-			ConstructorABI: string(abiBytes),
-			ConstructorArgs: []any{
-				initialOwner,
-			},
-		},
-	)
+func (s *NftServiceTestSuite) TestDeployContract() {
+	contractAddress := s.deployContract()
+
+	code, err := s.Client.CodeAt(context.Background(), common.HexToAddress(contractAddress), nil)
 	s.Require().NoError(err)
-	s.Require().NotEmpty(contractAddress)
+	s.Require().NotEmpty(code)
+}
+
+// vaultInitCode deploys a one byte STOP contract and ignores its constructor arguments
+const vaultInitCode = "0x6001600c60003960016000f300"
+
+// vaultConstructorABI is a payable constructor that differs from MyToken's
+const vaultConstructorABI = `[{
+	"inputs": [
+		{"name": "beneficiary", "type": "address"},
+		{"name": "unlockAt", "type": "uint64"},
+		{"name": "shares", "type": "uint16[]"}
+	],
+	"stateMutability": "payable",
+	"type": "constructor"
+}]`
+
+func (s *NftServiceTestSuite) TestDeployEncodesArtifactConstructor() {
+	ctx := context.Background()
+
+	constructor, err := ConstructorOf(vaultConstructorABI)
+	s.Require().NoError(err)
+	s.Require().True(constructor.IsPayable())
+	args, err := ParseABIArguments(constructor, []string{OtherAddress, "1700000000", "[60, 40]"})
+	s.Require().NoError(err)
+	s.Require().Equal([]any{common.HexToAddress(OtherAddress), uint64(1700000000), []uint16{60, 40}}, args)
+
+	result, err := s.NftService.DeployContract(ctx, DeployContractParams{
+		Bytecode:        vaultInitCode,
+		ConstructorABI:  vaultConstructorABI,
+		ConstructorArgs: args,
+		Value:           big.NewInt(1000),
+	})
+	s.Require().NoError(err)
+
+	// the calldata is the init code followed by exactly the given arguments
+	parsedABI, err := abi.JSON(strings.NewReader(vaultConstructorABI))
+	s.Require().NoError(err)
+	encoded, err := parsedABI.Pack("", args...)
+	s.Require().NoError(err)
+	tx, _, err := s.Client.TransactionByHash(ctx, common.HexToHash(result.TxHash))
+	s.Require().NoError(err)
+	s.Require().Equal(append(common.FromHex(vaultInitCode), encoded...), tx.Data())
+
+	balance, err := s.Client.BalanceAt(ctx, common.HexToAddress(result.ContractAddress), nil)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1000), balance.Int64())
+
+	// value is refused for a constructor that is not payable
+	_, err = s.NftService.DeployContract(ctx, DeployContractParams{
+		Bytecode:        s.AbiInfo.Bytecode,
+		ConstructorABI:  s.AbiJSON,
+		ConstructorArgs: []any{common.HexToAddress(HardhatAddress), "https://api.example.com/{id}"},
+		Value:           big.NewInt(1),
+	})
+	s.Require().ErrorContains(err, "not payable")
+}
+
+func (s *NftServiceTestSuite) TestSetURI() {
+	// First deploy the contract
+	contractAddress := s.deployContract()
 
 	// Call setURI function
 	newURI := "https://api.example.com/token/{id}"
 	txHash, err := s.NftService.CallContractFunction(
+		context.Background(),
 		ContractCallParams{
 			ContractAddress: contractAddress,
-			ContractABI:     string(abiBytes),
+			ContractABI:     s.AbiJSON,
 			FunctionName:    "setURI",
 			FunctionArgs:    []any{newURI},
 		},
 	)
 	s.Require().NoError(err)
 	s.Require().NotEmpty(txHash)
+
+	s.Require().NoError(s.NftService.SetURI(context.Background(), contractAddress, "https://api.example.com/v2/{id}"))
 }
 
 func (s *NftServiceTestSuite) TestMintToMultiple() {
-	// ... existing code for reading ABI and deploying contract ...
-	abiInfoFile, err := os.ReadFile(AbiPath)
-	if err != nil {
-		s.T().Fatalf("failed to read bytecode: %v", err)
-	}
-
-	var abiInfo AbiInfoFile
-	err = json.Unmarshal(abiInfoFile, &abiInfo)
-	if err != nil {
-		s.T().Fatalf("failed to unmarshal abi info: %v", err)
-	}
-
-	abiBytes, err := json.Marshal(abiInfo.Abi)
-	if err != nil {
-		s.T().Fatalf("failed to marshal abi: %v", err)
-	}
-
-	initialOwner := common.HexToAddress(HardhatAddress)
-
-	// Deploy the contract
-	contractAddress, err := s.NftService.DeployContractWithABI(
-		DeployContractParams{
-			Bytecode:       string(abiInfo.Bytecode),
-			ConstructorABI: string(abiBytes),
-			ConstructorArgs: []any{
-				initialOwner,
-			},
-		},
-	)
-	s.Require().NoError(err)
-	s.Require().NotEmpty(contractAddress)
+	contractAddress := s.deployContract()
 
 	// 准备测试数据：创建多个接收地址
 	recipients := []string{
@@ -172,13 +236,268 @@ func (s *NftServiceTestSuite) TestMintToMultiple() {
 	data := []byte{}         // 额外数据（空）
 
 	txHash, err := s.NftService.CallContractFunction(
+		context.Background(),
 		ContractCallParams{
 			ContractAddress: contractAddress,
-			ContractABI:     string(abiBytes),
+			ContractABI:     s.AbiJSON,
 			FunctionName:    "mintToMultple",
 			FunctionArgs:    []any{addresses, tokenId, amount, data},
 		},
 	)
 	s.Require().NoError(err)
 	s.Require().NotEmpty(txHash)
+
+	for _, recipient := range recipients {
+		s.Require().Equal(int64(1), s.balanceOf(contractAddress, recipient, 1).Int64())
+	}
+}
+
+func (s *NftServiceTestSuite) TestMintNFTToAddresses() {
+	contractAddress := s.deployContract()
+
+	recipients := []string{
+		"0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65",
+		"0x9965507D1a55bcC2695C58ba16FB37d819B0A4dc",
+	}
+
+	txHashes, err := s.NftService.MintNFTToAddresses(context.Background(), contractAddress, recipients, " 7 ", nil)
+	s.Require().NoError(err)
+	s.Require().Len(txHashes, 1)
+
+	for _, recipient := range recipients {
+		s.Require().Equal(int64(1), s.balanceOf(contractAddress, recipient, 7).Int64())
+	}
+	s.Require().Equal(int64(0), s.balanceOf(contractAddress, recipients[0], 1).Int64())
+
+	_, err = s.NftService.MintNFTToAddresses(context.Background(), contractAddress, recipients, "abc", nil)
+	s.Require().Error(err)
+}
+
+func (s *NftServiceTestSuite) TestMintSplitsIntoBatches() {
+	contractAddress := s.deployContract()
+
+	size, err := s.NftService.BatchSize(context.Background(), contractAddress)
+	s.Require().NoError(err)
+	s.Require().Equal(50, size)
+
+	// more recipients than mintToMultple accepts in one call
+	recipients := make([]string, 120)
+	for i := range recipients {
+		recipients[i] = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+	}
+
+	estimates, err := s.NftService.EstimateMintNFTToAddresses(context.Background(), contractAddress, recipients, "3")
+	s.Require().NoError(err)
+	s.Require().Len(estimates, 3)
+
+	var statuses []BatchStatus
+	txHashes, err := s.NftService.MintNFTToAddresses(context.Background(), contractAddress, recipients, "3", func(status BatchStatus) {
+		statuses = append(statuses, status)
+	})
+	s.Require().NoError(err)
+	s.Require().Len(txHashes, 3)
+
+	s.Require().Len(statuses, 3)
+	for i, status := range statuses {
+		s.Require().NoError(status.Err)
+		s.Require().Equal(i+1, status.Index)
+		s.Require().Equal(3, status.Total)
+		s.Require().Equal(txHashes[i], status.TxHash)
+	}
+	s.Require().Equal([]int{50, 50, 20}, []int{statuses[0].Recipients, statuses[1].Recipients, statuses[2].Recipients})
+
+	for _, recipient := range recipients {
+		s.Require().Equal(int64(1), s.balanceOf(contractAddress, recipient, 3).Int64())
+	}
+}
+
+func (s *NftServiceTestSuite) TestMintStopsAtFailedBatch() {
+	contractAddress := s.deployContract()
+
+	recipients := make([]string, 60)
+	for i := range recipients {
+		recipients[i] = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+	}
+
+	// minting to the zero address reverts, so the first batch already fails and nothing else is sent
+	recipients[0] = common.Address{}.Hex()
+	calls := 0
+	txHashes, err := s.NftService.MintNFTToAddresses(context.Background(), contractAddress, recipients, "1", func(BatchStatus) {
+		calls++
+	})
+	s.Require().Error(err)
+	s.Require().Contains(err.Error(), "batch 1/2 failed")
+	s.Require().Empty(txHashes)
+	s.Require().Equal(1, calls)
+
+	// only the owner may mint, another account is refused before any batch
+	other := NewNftServiceWithBackend(s.NftService.backend, OtherPrivateKey)
+	calls = 0
+	_, err = other.MintNFTToAddresses(context.Background(), contractAddress, recipients[1:], "1", func(BatchStatus) {
+		calls++
+	})
+	var notOwner *NotOwnerError
+	s.Require().ErrorAs(err, &notOwner)
+	s.Require().Zero(calls)
+}
+
+func (s *NftServiceTestSuite) TestDynamicFeeTransactions() {
+	contractAddress := s.deployContract()
+
+	// per-call caps take precedence over the service policy
+	s.NftService.SetFeePolicy(FeePolicy{MaxPriorityFeePerGas: big.NewInt(5_000_000_000)})
+	maxTip := big.NewInt(2_000_000_000)
+	txHash, err := s.NftService.CallContractFunction(
+		context.Background(),
+		ContractCallParams{
+			ContractAddress:      contractAddress,
+			ContractABI:          s.AbiJSON,
+			FunctionName:         "setURI",
+			FunctionArgs:         []any{"https://api.example.com/token/{id}"},
+			MaxPriorityFeePerGas: maxTip,
+		},
+	)
+	s.Require().NoError(err)
+
+	tx, _, err := s.Client.TransactionByHash(context.Background(), common.HexToHash(txHash))
+	s.Require().NoError(err)
+	s.Require().Equal(uint8(types.DynamicFeeTxType), tx.Type())
+	s.Require().LessOrEqual(tx.GasTipCap().Cmp(maxTip), 0)
+	s.Require().GreaterOrEqual(tx.GasFeeCap().Cmp(tx.GasTipCap()), 0)
+}
+
+func (s *NftServiceTestSuite) TestGasLimitEstimation() {
+	contractAddress := s.deployContract()
+
+	// a full batch has to fit in the estimated limit
+	recipients := make([]string, 50)
+	for i := range recipients {
+		recipients[i] = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+	}
+	estimates, err := s.NftService.EstimateMintNFTToAddresses(context.Background(), contractAddress, recipients, "1")
+	s.Require().NoError(err)
+	s.Require().Len(estimates, 1)
+
+	txHashes, err := s.NftService.MintNFTToAddresses(context.Background(), contractAddress, recipients, "1", nil)
+	s.Require().NoError(err)
+	s.Require().Len(txHashes, 1)
+	tx, _, err := s.Client.TransactionByHash(context.Background(), common.HexToHash(txHashes[0]))
+	s.Require().NoError(err)
+	s.Require().Equal(estimates[0], tx.Gas())
+
+	receipt, err := s.Client.TransactionReceipt(context.Background(), common.HexToHash(txHashes[0]))
+	s.Require().NoError(err)
+	s.Require().Less(receipt.GasUsed, tx.Gas())
+
+	// an explicit gas limit overrides the estimate
+	txHash, err := s.NftService.CallContractFunction(
+		context.Background(),
+		ContractCallParams{
+			ContractAddress: contractAddress,
+			ContractABI:     s.AbiJSON,
+			FunctionName:    "setURI",
+			FunctionArgs:    []any{"https://api.example.com/token/{id}"},
+			GasLimit:        123456,
+		},
+	)
+	s.Require().NoError(err)
+	tx, _, err = s.Client.TransactionByHash(context.Background(), common.HexToHash(txHash))
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(123456), tx.Gas())
+}
+
+func (s *NftServiceTestSuite) TestRevertedTransactionIsAnError() {
+	contractAddress := s.deployContract()
+
+	// more recipients than batchSize, with an explicit gas limit so the revert gets mined
+	recipients := make([]common.Address, 51)
+	for i := range recipients {
+		recipients[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+	}
+	_, err := s.NftService.CallContractFunction(
+		context.Background(),
+		ContractCallParams{
+			ContractAddress: contractAddress,
+			ContractABI:     s.AbiJSON,
+			FunctionName:    "mintToMultple",
+			FunctionArgs:    []any{recipients, big.NewInt(1), big.NewInt(1), []byte{}},
+			GasLimit:        3000000,
+		},
+	)
+	var revertErr *TxRevertedError
+	s.Require().ErrorAs(err, &revertErr)
+	s.Require().Equal("Batch size exceeds the limit", revertErr.Reason)
+	s.Require().NotEmpty(revertErr.TxHash)
+
+	// a signer that is not the owner hits the Ownable custom error
+	other := NewNftServiceWithBackend(&autoCommitBackend{Client: s.Client, sim: s.Backend}, OtherPrivateKey)
+	_, err = other.CallContractFunction(
+		context.Background(),
+		ContractCallParams{
+			ContractAddress: contractAddress,
+			ContractABI:     s.AbiJSON,
+			FunctionName:    "setURI",
+			FunctionArgs:    []any{"https://evil.example.com/{id}"},
+			GasLimit:        300000,
+		},
+	)
+	s.Require().ErrorAs(err, &revertErr)
+	s.Require().Contains(revertErr.Reason, "OwnableUnauthorizedAccount")
+	s.Require().Contains(revertErr.Reason, OtherAddress)
+}
+
+func (s *NftServiceTestSuite) TestCancelWhilePending() {
+	contractAddress := s.deployContract()
+
+	// without auto-commit nothing gets mined, so the call blocks until cancelled
+	pending := NewNftServiceWithBackend(s.Client, HardhatPrivateKey)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	err := pending.SetURI(ctx, contractAddress, "https://api.example.com/token/{id}")
+	var pendingErr *TxPendingError
+	s.Require().ErrorAs(err, &pendingErr)
+	s.Require().ErrorIs(err, context.Canceled)
+	s.Require().Equal("cancelled, transaction may still be pending: "+pendingErr.TxHash, err.Error())
+
+	// the mining timeout ends the wait the same way
+	pending.SetTimeouts(Timeouts{Mine: 200 * time.Millisecond})
+	err = pending.SetURI(context.Background(), contractAddress, "https://api.example.com/token/{id}")
+	s.Require().ErrorAs(err, &pendingErr)
+	s.Require().ErrorIs(err, context.DeadlineExceeded)
+
+	// both transactions were broadcast and get mined with the next block
+	s.Backend.Commit()
+	receipt, err := s.Client.TransactionReceipt(context.Background(), common.HexToHash(pendingErr.TxHash))
+	s.Require().NoError(err)
+	s.Require().Equal(types.ReceiptStatusSuccessful, receipt.Status)
+}
+
+func TestNftServiceReusesConnection(t *testing.T) {
+	// dialing an http endpoint is lazy, so no node has to listen here
+	service := NewNftService("http://127.0.0.1:8545", HardhatPrivateKey)
+
+	first, err := service.getClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	second, err := service.getClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	if first != second {
+		t.Fatalf("expected the connection to be reused")
+	}
+
+	if err := service.Close(); err != nil {
+		t.Fatalf("failed to close service: %v", err)
+	}
+	third, err := service.getClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	if third == first {
+		t.Fatalf("expected a new connection after Close")
+	}
+	_ = service.Close()
 }
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ownableABI holds the ownership functions of OpenZeppelin's Ownable
+const ownableABI = `[
+	{"type": "function", "name": "transferOwnership", "stateMutability": "nonpayable",
+		"inputs": [{"name": "newOwner", "type": "address"}],
+		"outputs": []},
+	{"type": "function", "name": "renounceOwnership", "stateMutability": "nonpayable",
+		"inputs": [],
+		"outputs": []}
+]`
+
+// NotOwnerError is returned before an onlyOwner transaction is sent from an account that
+// does not own the contract, the transaction could only revert
+type NotOwnerError struct {
+	Contract string
+	Owner    string
+	Signer   string
+}
+
+func (e *NotOwnerError) Error() string {
+	return fmt.Sprintf("signer %s is not the owner %s of contract %s", e.Signer, e.Owner, e.Contract)
+}
+
+// CheckOwner returns a *NotOwnerError unless the signer owns the contract. Call it before
+// onlyOwner transactions to refuse early instead of paying for a revert.
+func (s *NftService) CheckOwner(ctx context.Context, contractAddr string) error {
+	signer, err := s.getSigner()
+	if err != nil {
+		return err
+	}
+	return s.checkOwner(ctx, contractAddr, signer.Address().Hex())
+}
+
+// checkOwner returns a *NotOwnerError unless account owns the contract
+func (s *NftService) checkOwner(ctx context.Context, contractAddr string, account string) error {
+	owner, err := s.Owner(ctx, contractAddr)
+	if err != nil {
+		return fmt.Errorf("failed to read owner(): %v", err)
+	}
+	if !strings.EqualFold(owner, account) {
+		return &NotOwnerError{Contract: contractAddr, Owner: owner, Signer: common.HexToAddress(account).Hex()}
+	}
+	return nil
+}
+
+// TransferOwnershipParams builds the transferOwnership call handing the contract to newOwner
+func TransferOwnershipParams(contractAddr string, newOwner string) (ContractCallParams, error) {
+	if !common.IsHexAddress(newOwner) {
+		return ContractCallParams{}, fmt.Errorf("invalid new owner address %q", newOwner)
+	}
+	newOwnerAddress := common.HexToAddress(newOwner)
+	if newOwnerAddress == (common.Address{}) {
+		return ContractCallParams{}, fmt.Errorf("the new owner cannot be the zero address, renounce ownership instead")
+	}
+	return ContractCallParams{
+		ContractAddress: contractAddr,
+		ContractABI:     ownableABI,
+		FunctionName:    "transferOwnership",
+		FunctionArgs:    []any{newOwnerAddress},
+	}, nil
+}
+
+// RenounceOwnershipParams builds the renounceOwnership call, after it no one can call the
+// onlyOwner functions of the contract again
+func RenounceOwnershipParams(contractAddr string) ContractCallParams {
+	return ContractCallParams{
+		ContractAddress: contractAddr,
+		ContractABI:     ownableABI,
+		FunctionName:    "renounceOwnership",
+	}
+}
+
+// TransferOwnership hands the contract to newOwner once the signer is confirmed to own it
+func (s *NftService) TransferOwnership(ctx context.Context, contractAddr string, newOwner string) (string, error) {
+	params, err := TransferOwnershipParams(contractAddr, newOwner)
+	if err != nil {
+		return "", err
+	}
+	if err := s.CheckOwner(ctx, contractAddr); err != nil {
+		return "", err
+	}
+	return s.CallContractFunction(ctx, params)
+}
+
+// RenounceOwnership leaves the contract without an owner once the signer is confirmed to own it
+func (s *NftService) RenounceOwnership(ctx context.Context, contractAddr string) (string, error) {
+	if err := s.CheckOwner(ctx, contractAddr); err != nil {
+		return "", err
+	}
+	return s.CallContractFunction(ctx, RenounceOwnershipParams(contractAddr))
+}
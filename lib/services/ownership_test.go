@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func (s *NftServiceTestSuite) TestOwnershipTransfer() {
+	ctx := context.Background()
+	contractAddress := s.deployContract()
+	other := NewNftServiceWithBackend(s.NftService.backend, OtherPrivateKey)
+
+	// only the owner may hand the contract over
+	var notOwner *NotOwnerError
+	_, err := other.TransferOwnership(ctx, contractAddress, OtherAddress)
+	s.Require().ErrorAs(err, &notOwner)
+	s.Require().Equal(HardhatAddress, notOwner.Owner)
+
+	_, err = s.NftService.TransferOwnership(ctx, contractAddress, common.Address{}.Hex())
+	s.Require().ErrorContains(err, "zero address")
+
+	_, err = s.NftService.TransferOwnership(ctx, contractAddress, OtherAddress)
+	s.Require().NoError(err)
+	owner, err := s.NftService.Owner(ctx, contractAddress)
+	s.Require().NoError(err)
+	s.Require().Equal(OtherAddress, owner)
+
+	// the previous owner is refused before setURI is sent
+	err = s.NftService.SetURI(ctx, contractAddress, "https://api.example.com/old-owner/{id}")
+	s.Require().ErrorAs(err, &notOwner)
+	s.Require().NoError(other.SetURI(ctx, contractAddress, "https://api.example.com/new-owner/{id}"))
+
+	_, err = other.RenounceOwnership(ctx, contractAddress)
+	s.Require().NoError(err)
+	owner, err = s.NftService.Owner(ctx, contractAddress)
+	s.Require().NoError(err)
+	s.Require().Equal(common.Address{}.Hex(), owner)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// registryBackupCount is how many backups of the registry are kept, older ones are removed
+const registryBackupCount = 20
+
+// RepairResult describes what RepairRegistry did
+type RepairResult struct {
+	// Healthy is true when the registry parsed and nothing was changed
+	Healthy bool
+	// CorruptPath is where the unreadable registry was moved to
+	CorruptPath string
+	// Backup is the backup the registry was restored from
+	Backup    string
+	Contracts int
+}
+
+// registryBackupDir holds the timestamped backups of the registry at path
+func registryBackupDir(path string) string {
+	return path + ".backups"
+}
+
+// readRegistry reads the registry at path, a missing file is an empty registry
+func readRegistry(path string) ([]DeployedContract, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []DeployedContract{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("读取已部署合约文件失败: %v", err)
+	}
+
+	var deployedContracts DeployedContracts
+	if err := json.Unmarshal(data, &deployedContracts); err != nil {
+		return nil, fmt.Errorf("解析已部署合约文件失败，可运行 registry repair 从备份恢复: %v", err)
+	}
+	if deployedContracts.Contracts == nil {
+		deployedContracts.Contracts = []DeployedContract{}
+	}
+	return deployedContracts.Contracts, nil
+}
+
+// updateRegistry runs the read-modify-write of the registry at path under an exclusive
+// lock, so concurrent instances never lose each other's records. The new version replaces
+// the old one atomically and is backed up.
+func updateRegistry(path string, update func(contracts []DeployedContract) ([]DeployedContract, error)) error {
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("锁定已部署合约文件失败: %v", err)
+	}
+	defer unlock()
+
+	contracts, err := readRegistry(path)
+	if err != nil {
+		return err
+	}
+	contracts, err = update(contracts)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(DeployedContracts{Contracts: contracts}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化合约信息失败: %v", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("保存合约信息失败: %v", err)
+	}
+	if err := backupRegistry(path, data); err != nil {
+		return fmt.Errorf("备份合约信息失败: %v", err)
+	}
+	return nil
+}
+
+// backupRegistry keeps a timestamped copy of every version written to the registry at
+// path, so the newest backup matches the registry, and prunes the oldest backups
+func backupRegistry(path string, data []byte) error {
+	dir := registryBackupDir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s.%s.json", strings.TrimSuffix(filepath.Base(path), ".json"), time.Now().Format("20060102-150405.000000"))
+	if err := writeFileAtomic(filepath.Join(dir, name), data); err != nil {
+		return err
+	}
+
+	backups, err := registryBackups(path)
+	if err != nil {
+		return err
+	}
+	for len(backups) > registryBackupCount {
+		if err := os.Remove(backups[len(backups)-1]); err != nil {
+			return err
+		}
+		backups = backups[:len(backups)-1]
+	}
+	return nil
+}
+
+// registryBackups lists the backups of the registry at path, newest first
+func registryBackups(path string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(registryBackupDir(path), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	// the timestamp in the name sorts in time order
+	slices.Sort(matches)
+	slices.Reverse(matches)
+	return matches, nil
+}
+
+// RepairRegistry restores the registry at path from its newest readable backup when it
+// cannot be parsed. The unreadable file is kept next to it with a .corrupt suffix.
+func RepairRegistry(path string) (RepairResult, error) {
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return RepairResult{}, fmt.Errorf("failed to lock %s: %v", path, err)
+	}
+	defer unlock()
+
+	if _, err := readRegistry(path); err == nil {
+		return RepairResult{Healthy: true}, nil
+	}
+
+	backups, err := registryBackups(path)
+	if err != nil {
+		return RepairResult{}, fmt.Errorf("failed to list backups: %v", err)
+	}
+	for _, backup := range backups {
+		contracts, err := readRegistry(backup)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			return RepairResult{}, fmt.Errorf("failed to read backup %s: %v", backup, err)
+		}
+
+		corruptPath := fmt.Sprintf("%s.%s.corrupt", path, time.Now().Format("20060102-150405"))
+		if err := os.Rename(path, corruptPath); err != nil {
+			return RepairResult{}, fmt.Errorf("failed to move the corrupt registry aside: %v", err)
+		}
+		if err := writeFileAtomic(path, data); err != nil {
+			return RepairResult{}, fmt.Errorf("failed to restore %s: %v", backup, err)
+		}
+		return RepairResult{CorruptPath: corruptPath, Backup: backup, Contracts: len(contracts)}, nil
+	}
+	return RepairResult{}, fmt.Errorf("%s is corrupt and no readable backup was found in %s", path, registryBackupDir(path))
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentRegistryWrites(t *testing.T) {
+	inTempDir(t)
+	compiler := NewContractCompiler("./artifacts")
+
+	// every writer takes the lock, none of them loses another's record
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			require.NoError(t, compiler.SaveDeployedContract(DeployedContract{Address: fmt.Sprintf("0x%040x", i+1)}))
+		}(i)
+	}
+	wg.Wait()
+
+	contracts, err := compiler.GetDeployedContracts()
+	require.NoError(t, err)
+	require.Len(t, contracts, 20)
+
+	backups, err := registryBackups(DeployedContractsPath)
+	require.NoError(t, err)
+	require.Len(t, backups, registryBackupCount)
+}
+
+func TestRepairRegistry(t *testing.T) {
+	inTempDir(t)
+	compiler := NewContractCompiler("./artifacts")
+
+	result, err := RepairRegistry(DeployedContractsPath)
+	require.NoError(t, err)
+	require.True(t, result.Healthy)
+
+	require.NoError(t, compiler.SaveDeployedContract(DeployedContract{Address: "0x0000000000000000000000000000000000000001"}))
+	require.NoError(t, compiler.SaveDeployedContract(DeployedContract{Address: "0x0000000000000000000000000000000000000002"}))
+
+	// a write cut short leaves a file that does not parse
+	require.NoError(t, os.WriteFile(DeployedContractsPath, []byte(`{"contracts": [{"address": "0x00`), 0644))
+	_, err = compiler.GetDeployedContracts()
+	require.ErrorContains(t, err, "registry repair")
+	require.Error(t, compiler.SaveDeployedContract(DeployedContract{Address: "0x0000000000000000000000000000000000000003"}))
+
+	result, err = RepairRegistry(DeployedContractsPath)
+	require.NoError(t, err)
+	require.False(t, result.Healthy)
+	require.Equal(t, 2, result.Contracts)
+	require.FileExists(t, result.CorruptPath)
+
+	contracts, err := compiler.GetDeployedContracts()
+	require.NoError(t, err)
+	require.Len(t, contracts, 2)
+}
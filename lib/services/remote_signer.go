@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// RemoteSigner signs through an external signer that speaks Clef's account_* JSON-RPC
+// API, the key never enters this process
+type RemoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// signTransactionResult is the reply of account_signTransaction
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// NewRemoteSigner connects to the external signer at endpoint and checks it manages
+// address, an empty address picks the first account it lists
+func NewRemoteSigner(ctx context.Context, endpoint string, address string) (*RemoteSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to signer: %v", err)
+	}
+
+	var listed []common.Address
+	if err := client.CallContext(ctx, &listed, "account_list"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to list signer accounts: %v", err)
+	}
+	if len(listed) == 0 {
+		client.Close()
+		return nil, fmt.Errorf("signer has no accounts")
+	}
+
+	signer := &RemoteSigner{client: client, address: listed[0]}
+	if address == "" {
+		return signer, nil
+	}
+	if !common.IsHexAddress(address) {
+		client.Close()
+		return nil, fmt.Errorf("invalid signer address %q", address)
+	}
+	for _, account := range listed {
+		if account == common.HexToAddress(address) {
+			signer.address = account
+			return signer, nil
+		}
+	}
+	client.Close()
+	return nil, fmt.Errorf("signer does not manage %s", address)
+}
+
+func (r *RemoteSigner) Address() common.Address {
+	return r.address
+}
+
+// SignTx asks the external signer to sign tx and checks the signed transaction is the
+// one that was asked for, from this signer's address
+func (r *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	data := hexutil.Bytes(tx.Data())
+	args := apitypes.SendTxArgs{
+		From:    common.NewMixedcaseAddress(r.address),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   &data,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+	}
+
+	var result signTransactionResult
+	if err := r.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("signer failed to sign the transaction: %v", err)
+	}
+	if result.Tx == nil {
+		return nil, fmt.Errorf("signer returned no transaction")
+	}
+
+	// the signer must not change what is sent or who sends it
+	signed := result.Tx
+	txSigner := types.LatestSignerForChainID(chainID)
+	sender, err := types.Sender(txSigner, signed)
+	if err != nil {
+		return nil, fmt.Errorf("signer returned an invalid signature: %v", err)
+	}
+	if sender != r.address {
+		return nil, fmt.Errorf("signer signed with %s instead of %s", sender.Hex(), r.address.Hex())
+	}
+	if txSigner.Hash(signed) != txSigner.Hash(tx) {
+		return nil, fmt.Errorf("signer returned a different transaction than requested")
+	}
+	return signed, nil
+}
+
+// SignTypedData asks the external signer to sign the EIP-712 hash of data
+func (r *RemoteSigner) SignTypedData(ctx context.Context, data apitypes.TypedData) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := r.client.CallContext(ctx, &signature, "account_signTypedData", common.NewMixedcaseAddress(r.address), data); err != nil {
+		return nil, fmt.Errorf("signer failed to sign the typed data: %v", err)
+	}
+	return signature, nil
+}
+
+// Close disconnects from the external signer
+func (r *RemoteSigner) Close() error {
+	r.client.Close()
+	return nil
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/require"
+)
+
+// clefStandIn serves the account_* methods of Clef with a key held in memory
+type clefStandIn struct {
+	key *keySigner
+	// tamper makes the stand-in sign a different nonce than the one asked for
+	tamper bool
+	// approval is how long a human takes to approve each transaction
+	approval time.Duration
+}
+
+func (c *clefStandIn) List() []common.Address {
+	return []common.Address{c.key.Address()}
+}
+
+func (c *clefStandIn) SignTransaction(ctx context.Context, args apitypes.SendTxArgs) (*signTransactionResult, error) {
+	if c.tamper {
+		args.Nonce++
+	}
+	select {
+	case <-time.After(c.approval):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := c.key.SignTx(ctx, tx, (*big.Int)(args.ChainID))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTransactionResult{Raw: raw, Tx: signed}, nil
+}
+
+func (c *clefStandIn) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data apitypes.TypedData) (hexutil.Bytes, error) {
+	return c.key.SignTypedData(ctx, data)
+}
+
+// startClefStandIn serves a clefStandIn signing with privateKey over HTTP
+func startClefStandIn(t *testing.T, privateKey string, tamper bool) string {
+	key, err := crypto.HexToECDSA(privateKey[2:])
+	require.NoError(t, err)
+	return serveClefStandIn(t, &clefStandIn{key: newKeySigner(key), tamper: tamper})
+}
+
+// serveClefStandIn serves standIn over HTTP
+func serveClefStandIn(t *testing.T, standIn *clefStandIn) string {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("account", standIn))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return httpServer.URL
+}
+
+// permitTypedData is a small EIP-712 message to sign
+func permitTypedData() apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "value", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "MyToken",
+			ChainId: math.NewHexOrDecimal256(1337),
+		},
+		Message: apitypes.TypedDataMessage{
+			"owner": HardhatAddress,
+			"value": "1",
+		},
+	}
+}
+
+// recoverTypedData returns the address that produced signature over data
+func recoverTypedData(t *testing.T, data apitypes.TypedData, signature []byte) common.Address {
+	hash, _, err := apitypes.TypedDataAndHash(data)
+	require.NoError(t, err)
+	require.Len(t, signature, crypto.SignatureLength)
+
+	sig := append([]byte{}, signature...)
+	sig[crypto.RecoveryIDOffset] -= 27
+	publicKey, err := crypto.SigToPub(hash, sig)
+	require.NoError(t, err)
+	return crypto.PubkeyToAddress(*publicKey)
+}
+
+func TestRemoteSignerSelectsAccount(t *testing.T) {
+	ctx := context.Background()
+	url := startClefStandIn(t, HardhatPrivateKey, false)
+
+	signer, err := NewRemoteSigner(ctx, url, "")
+	require.NoError(t, err)
+	defer signer.Close()
+	require.Equal(t, common.HexToAddress(HardhatAddress), signer.Address())
+
+	_, err = NewRemoteSigner(ctx, url, OtherAddress)
+	require.ErrorContains(t, err, "does not manage")
+}
+
+func TestRemoteSignerSignsTypedData(t *testing.T) {
+	ctx := context.Background()
+	signer, err := NewRemoteSigner(ctx, startClefStandIn(t, HardhatPrivateKey, false), HardhatAddress)
+	require.NoError(t, err)
+	defer signer.Close()
+
+	signature, err := signer.SignTypedData(ctx, permitTypedData())
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress(HardhatAddress), recoverTypedData(t, permitTypedData(), signature))
+
+	// the local signers produce the same signature
+	local, err := NewPrivateKeySigner(HardhatPrivateKey)
+	require.NoError(t, err)
+	localSignature, err := local.SignTypedData(ctx, permitTypedData())
+	require.NoError(t, err)
+	require.Equal(t, []byte(signature), localSignature)
+}
+
+func TestRemoteSignerRejectsTamperedTransaction(t *testing.T) {
+	ctx := context.Background()
+	signer, err := NewRemoteSigner(ctx, startClefStandIn(t, HardhatPrivateKey, true), "")
+	require.NoError(t, err)
+	defer signer.Close()
+
+	to := common.HexToAddress(OtherAddress)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1337),
+		Nonce:     7,
+		To:        &to,
+		Gas:       21000,
+		GasFeeCap: big.NewInt(2e9),
+		GasTipCap: big.NewInt(1e9),
+		Value:     big.NewInt(1),
+	})
+	_, err = signer.SignTx(ctx, tx, big.NewInt(1337))
+	require.ErrorContains(t, err, "different transaction")
+}
+
+func (s *NftServiceTestSuite) TestSendWithRemoteSigner() {
+	ctx := context.Background()
+	contractAddress := s.deployContract()
+
+	signer, err := NewRemoteSigner(ctx, startClefStandIn(s.T(), HardhatPrivateKey, false), HardhatAddress)
+	s.Require().NoError(err)
+	defer signer.Close()
+
+	service := NewNftServiceWithSigner("", signer)
+	service.backend = &autoCommitBackend{Client: s.Client, sim: s.Backend}
+	s.Require().NoError(service.SetURI(ctx, contractAddress, "https://api.example.com/remote/{id}"))
+
+	uri, err := service.URI(ctx, contractAddress, big.NewInt(1))
+	s.Require().NoError(err)
+	s.Require().Equal("https://api.example.com/remote/{id}", uri)
+}
+
+func (s *NftServiceTestSuite) TestRemoteSignerApprovalOutlastsSendTimeout() {
+	ctx := context.Background()
+	contractAddress := s.deployContract()
+
+	// the approval takes longer than the whole send timeout
+	key, err := crypto.HexToECDSA(HardhatPrivateKey[2:])
+	s.Require().NoError(err)
+	endpoint := serveClefStandIn(s.T(), &clefStandIn{key: newKeySigner(key), approval: 300 * time.Millisecond})
+	signer, err := NewRemoteSigner(ctx, endpoint, HardhatAddress)
+	s.Require().NoError(err)
+
+	defer signer.Close()
+
+	service := NewNftServiceWithSigner("", signer)
+	service.backend = &autoCommitBackend{Client: s.Client, sim: s.Backend}
+	service.SetTimeouts(Timeouts{Send: 200 * time.Millisecond})
+	s.Require().NoError(service.SetURI(ctx, contractAddress, "https://api.example.com/approved/{id}"))
+}
+
+// closingSigner records whether it was closed
+type closingSigner struct {
+	Signer
+	closed bool
+}
+
+func (c *closingSigner) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestCloseClosesSigner(t *testing.T) {
+	key, err := crypto.HexToECDSA(HardhatPrivateKey[2:])
+	require.NoError(t, err)
+	signer := &closingSigner{Signer: newKeySigner(key)}
+
+	service := NewNftServiceWithSigner("", signer)
+	require.NoError(t, service.Close())
+	require.True(t, signer.closed)
+}
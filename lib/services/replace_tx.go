@@ -0,0 +1,403 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ReplacementPriceBump is the percentage by which a replacement has to raise the fees of
+// the transaction it replaces, the default of geth's transaction pool
+const ReplacementPriceBump = 10
+
+// cancelGasLimit is the gas of a plain transfer, all a cancellation needs
+const cancelGasLimit = 21000
+
+// minePollInterval is how often a tracked transaction and its replacements are looked up
+const minePollInterval = time.Second
+
+// TxReplacedError is returned when another transaction at the nonce of a broadcast one was
+// mined instead, a cancellation or a transaction sent elsewhere. Nothing the transaction
+// would have done happened.
+type TxReplacedError struct {
+	TxHash string
+	// ReplacedBy is the mined cancellation, empty when the nonce was used elsewhere
+	ReplacedBy string
+}
+
+func (e *TxReplacedError) Error() string {
+	if e.ReplacedBy != "" {
+		return fmt.Sprintf("transaction %s was cancelled by %s", e.TxHash, e.ReplacedBy)
+	}
+	return fmt.Sprintf("transaction %s was replaced by another transaction at its nonce", e.TxHash)
+}
+
+// SetTxTracker records every broadcast transaction in tracker, nil stops tracking
+func (s *NftService) SetTxTracker(tracker *TxTracker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracker = tracker
+}
+
+// sendTracked records tx and broadcasts it. The record is removed again when the node
+// refuses the transaction, it was never broadcast.
+func (s *NftService) sendTracked(ctx context.Context, cfg serviceSettings, client ChainBackend, label string, from common.Address, tx *types.Transaction, kind TxAttemptKind) error {
+	if cfg.tracker != nil {
+		if err := cfg.tracker.record(label, from, tx, kind); err != nil {
+			return err
+		}
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		if cfg.tracker != nil {
+			if forgetErr := cfg.tracker.forget(tx); forgetErr != nil {
+				return fmt.Errorf("%w (%v)", err, forgetErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// PendingTransactions returns the tracked transactions on the node's chain that are still
+// pending, oldest first. Those mined or replaced since they were sent are settled first.
+func (s *NftService) PendingTransactions(ctx context.Context) (pending []TrackedTx, err error) {
+	cfg := s.settings()
+	if cfg.tracker == nil {
+		return []TrackedTx{}, nil
+	}
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	lookupCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancel()
+	chainID, err := client.ChainID(lookupCtx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkChainID(chainID); err != nil {
+		return nil, err
+	}
+
+	tracked, err := cfg.tracker.Pending(chainID.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	pending = []TrackedTx{}
+	for _, tx := range tracked {
+		tx, _, err = s.refreshTracked(lookupCtx, cfg, client, tx)
+		if err != nil {
+			return nil, err
+		}
+		if tx.State == TrackedPending {
+			pending = append(pending, tx)
+		}
+	}
+	return pending, nil
+}
+
+// SpeedUpTransaction resends the tracked transaction with hash at its nonce, with its fees
+// raised by at least ReplacementPriceBump percent or to the current suggestion when that
+// is higher. A transaction being cancelled gets its cancellation sped up. The hash of the
+// replacement is returned without waiting for it to be mined.
+func (s *NftService) SpeedUpTransaction(ctx context.Context, hash string) (string, error) {
+	return s.replaceTransaction(ctx, hash, AttemptSpeedUp)
+}
+
+// CancelTransaction sends a zero-value transfer from the sender to itself at the nonce of
+// the tracked transaction with hash, priced like a speed-up. Once it is mined the original
+// transaction can no longer be. The hash of the cancellation is returned without waiting.
+func (s *NftService) CancelTransaction(ctx context.Context, hash string) (string, error) {
+	return s.replaceTransaction(ctx, hash, AttemptCancel)
+}
+
+// replaceTransaction signs and broadcasts a replacement of kind for the tracked transaction with hash
+func (s *NftService) replaceTransaction(ctx context.Context, hash string, kind TxAttemptKind) (txHash string, err error) {
+	cfg := s.settings()
+	if cfg.tracker == nil {
+		return "", fmt.Errorf("transactions are not tracked")
+	}
+	if decoded, err := hexutil.Decode(hash); err != nil || len(decoded) != common.HashLength {
+		return "", fmt.Errorf("invalid transaction hash: %s", hash)
+	}
+	tracked, err := cfg.tracker.Find(hash)
+	if err != nil {
+		return "", err
+	}
+	if tracked == nil {
+		return "", fmt.Errorf("transaction %s is not tracked", hash)
+	}
+
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	signer, err := s.getSigner()
+	if err != nil {
+		return "", err
+	}
+	fromAddress := signer.Address()
+	if !strings.EqualFold(tracked.From, fromAddress.Hex()) {
+		return "", fmt.Errorf("transaction %s was sent by %s, the signer is %s", hash, tracked.From, fromAddress.Hex())
+	}
+
+	sendCtx, cancelSend := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelSend()
+
+	chainID, err := client.ChainID(sendCtx)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkChainID(chainID); err != nil {
+		return "", err
+	}
+	if chainID.Uint64() != tracked.ChainID {
+		return "", fmt.Errorf("transaction %s is on chain %d, the node is on chain %s", hash, tracked.ChainID, chainID)
+	}
+
+	// it may have been mined while it was listed
+	updated, _, err := s.refreshTracked(sendCtx, cfg, client, *tracked)
+	if err != nil {
+		return "", err
+	}
+	if updated.State != TrackedPending {
+		return "", fmt.Errorf("transaction %s is already %s", hash, updated.State)
+	}
+
+	latest, _, err := decodeSignedTx(updated.Latest().RawTx)
+	if err != nil {
+		return "", err
+	}
+	suggested, err := suggestFees(sendCtx, client, cfg.feePolicy)
+	if err != nil {
+		return "", err
+	}
+	fees, err := replacementFees(latest, suggested, cfg.feePolicy)
+	if err != nil {
+		return "", err
+	}
+
+	to, value, gasLimit, data := latest.To(), latest.Value(), latest.Gas(), latest.Data()
+	if updated.Cancelling() {
+		kind = AttemptCancel
+	}
+	if kind == AttemptCancel {
+		to, value, gasLimit, data = &fromAddress, big.NewInt(0), cancelGasLimit, nil
+	}
+	replacement := newTransaction(chainID, latest.Nonce(), to, value, gasLimit, data, fees)
+
+	signedTx, err := s.signTx(ctx, cfg, signer, replacement, chainID)
+	if err != nil {
+		return "", err
+	}
+	broadcastCtx, cancelBroadcast := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancelBroadcast()
+	if err := s.sendTracked(broadcastCtx, cfg, client, updated.Label, fromAddress, signedTx, kind); err != nil {
+		return "", err
+	}
+	return signedTx.Hash().Hex(), nil
+}
+
+// replacementFees prices a replacement of tx: the suggested fees, but at least the fees of
+// tx raised by ReplacementPriceBump percent so the node accepts it in place of tx. The
+// replacement keeps the type of tx and has to stay within the caps of policy.
+func replacementFees(tx *types.Transaction, suggested txFees, policy FeePolicy) (txFees, error) {
+	suggestedTip, suggestedFeeCap := suggested.GasTipCap, suggested.GasFeeCap
+	if suggested.GasPrice != nil {
+		suggestedTip, suggestedFeeCap = suggested.GasPrice, suggested.GasPrice
+	}
+
+	if tx.Type() == types.LegacyTxType {
+		gasPrice := maxBig(bumpFee(tx.GasPrice()), suggestedFeeCap)
+		if policy.MaxFeePerGas != nil && gasPrice.Cmp(policy.MaxFeePerGas) > 0 {
+			return txFees{}, fmt.Errorf("a replacement needs a gas price of %s, above the fee cap of %s", gasPrice, policy.MaxFeePerGas)
+		}
+		return txFees{GasPrice: gasPrice}, nil
+	}
+
+	tip := maxBig(bumpFee(tx.GasTipCap()), suggestedTip)
+	feeCap := maxBig(bumpFee(tx.GasFeeCap()), suggestedFeeCap)
+	feeCap = maxBig(feeCap, tip)
+	if policy.MaxPriorityFeePerGas != nil && tip.Cmp(policy.MaxPriorityFeePerGas) > 0 {
+		return txFees{}, fmt.Errorf("a replacement needs a priority fee of %s, above the cap of %s", tip, policy.MaxPriorityFeePerGas)
+	}
+	if policy.MaxFeePerGas != nil && feeCap.Cmp(policy.MaxFeePerGas) > 0 {
+		return txFees{}, fmt.Errorf("a replacement needs a max fee of %s, above the fee cap of %s", feeCap, policy.MaxFeePerGas)
+	}
+	return txFees{GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+// bumpFee raises fee by ReplacementPriceBump percent, rounded up
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+ReplacementPriceBump))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// maxBig returns the larger of a and b, a nil b counts as zero
+func maxBig(a *big.Int, b *big.Int) *big.Int {
+	if b != nil && b.Cmp(a) > 0 {
+		return new(big.Int).Set(b)
+	}
+	return a
+}
+
+// waitTracked waits like bind.WaitMined, but for any attempt at the nonce of tx so a
+// speed-up or cancellation sent meanwhile, from this process or another one, ends the
+// wait. Like bind.WaitMined it keeps trying when a lookup fails.
+func (s *NftService) waitTracked(ctx context.Context, cfg serviceSettings, client ChainBackend, tx *types.Transaction) (*types.Receipt, error) {
+	ticker := time.NewTicker(minePollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := s.trackedReceipt(ctx, cfg, client, tx)
+		var replacedErr *TxReplacedError
+		if err == nil || errors.As(err, &replacedErr) {
+			return receipt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// trackedReceipt looks up the receipt of tx, or of a replacement tracked at its nonce. It
+// returns ethereum.NotFound while none is mined and a TxReplacedError once a cancellation
+// or a transaction sent elsewhere took the nonce.
+func (s *NftService) trackedReceipt(ctx context.Context, cfg serviceSettings, client ChainBackend, tx *types.Transaction) (*types.Receipt, error) {
+	var tracked *TrackedTx
+	if cfg.tracker != nil {
+		var err error
+		if tracked, err = cfg.tracker.Find(tx.Hash().Hex()); err != nil {
+			return nil, err
+		}
+	}
+	if tracked == nil {
+		return client.TransactionReceipt(ctx, tx.Hash())
+	}
+
+	updated, receipt, err := s.refreshTracked(ctx, cfg, client, *tracked)
+	if err != nil {
+		return nil, err
+	}
+	switch updated.State {
+	case TrackedPending:
+		return nil, ethereum.NotFound
+	case TrackedCancelled:
+		return nil, &TxReplacedError{TxHash: tx.Hash().Hex(), ReplacedBy: updated.MinedHash}
+	case TrackedDropped:
+		return nil, &TxReplacedError{TxHash: tx.Hash().Hex()}
+	}
+	return receipt, nil
+}
+
+// trackedKnown reports whether the node knows tx or one of the replacements tracked at its
+// nonce, mined or still in its pool
+func (s *NftService) trackedKnown(ctx context.Context, cfg serviceSettings, client ChainBackend, tx *types.Transaction) (bool, error) {
+	hashes := []string{tx.Hash().Hex()}
+	if cfg.tracker != nil {
+		tracked, err := cfg.tracker.Find(tx.Hash().Hex())
+		if err != nil {
+			return false, err
+		}
+		if tracked != nil {
+			hashes = hashes[:0]
+			for _, attempt := range tracked.Attempts {
+				hashes = append(hashes, attempt.Hash)
+			}
+		}
+	}
+
+	for _, hash := range hashes {
+		_, _, err := client.TransactionByHash(ctx, common.HexToHash(hash))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// refreshTracked looks up the attempts of a tracked transaction and records the outcome
+// once one of them is mined or a transaction sent elsewhere used its nonce. The receipt of
+// the mined attempt is returned with the updated transaction.
+func (s *NftService) refreshTracked(ctx context.Context, cfg serviceSettings, client ChainBackend, tracked TrackedTx) (TrackedTx, *types.Receipt, error) {
+	switch tracked.State {
+	case TrackedMined:
+		receipt, err := client.TransactionReceipt(ctx, common.HexToHash(tracked.MinedHash))
+		return tracked, receipt, err
+	case TrackedCancelled, TrackedDropped:
+		return tracked, nil, nil
+	}
+
+	receipt, attempt, err := minedAttempt(ctx, client, tracked)
+	if err != nil {
+		return tracked, nil, err
+	}
+	if receipt == nil {
+		// the account moved past the nonce, so some transaction used it
+		nonce, err := client.NonceAt(ctx, common.HexToAddress(tracked.From), nil)
+		if err != nil || nonce <= tracked.Nonce {
+			return tracked, nil, err
+		}
+		// one of the attempts may have been mined since it was looked up
+		receipt, attempt, err = minedAttempt(ctx, client, tracked)
+		if err != nil {
+			return tracked, nil, err
+		}
+	}
+
+	switch {
+	case receipt == nil:
+		tracked.State = TrackedDropped
+	case attempt.Kind == AttemptCancel:
+		tracked.State, tracked.MinedHash = TrackedCancelled, attempt.Hash
+	default:
+		tracked.State, tracked.MinedHash = TrackedMined, attempt.Hash
+	}
+	if err := cfg.tracker.settle(tracked.Hash(), tracked.State, tracked.MinedHash); err != nil {
+		return tracked, nil, err
+	}
+	return tracked, receipt, nil
+}
+
+// minedAttempt returns the receipt of the attempt of tracked that was mined, nil while none is
+func minedAttempt(ctx context.Context, client ChainBackend, tracked TrackedTx) (*types.Receipt, TxAttempt, error) {
+	for _, attempt := range slices.Backward(tracked.Attempts) {
+		receipt, err := client.TransactionReceipt(ctx, common.HexToHash(attempt.Hash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, TxAttempt{}, err
+		}
+		return receipt, attempt, nil
+	}
+	return nil, TxAttempt{}, nil
+}
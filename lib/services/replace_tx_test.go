@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendStuck starts a setURI that stays pending because nothing mines the block, and
+// returns the tracked transaction once it was broadcast with the channel of its outcome
+func (s *NftServiceTestSuite) sendStuck(pending *NftService, tracker *TxTracker, contractAddress string) (TrackedTx, chan error, chan string) {
+	errs, hashes := make(chan error, 1), make(chan string, 1)
+	go func() {
+		txHash, err := pending.CallContractFunction(context.Background(), SetURIParams(contractAddress, "https://api.example.com/v2/{id}"))
+		hashes <- txHash
+		errs <- err
+	}()
+
+	var tracked []TrackedTx
+	s.Require().Eventually(func() bool {
+		var err error
+		tracked, err = tracker.Pending(1337)
+		s.Require().NoError(err)
+		return len(tracked) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Require().Equal("setURI", tracked[0].Label)
+	s.Require().Equal(HardhatAddress, tracked[0].From)
+	return tracked[0], errs, hashes
+}
+
+func (s *NftServiceTestSuite) TestSpeedUpPendingTransaction() {
+	contractAddress := s.deployContract()
+	tracker := NewTxTracker(filepath.Join(s.T().TempDir(), DefaultTxTrackerPath))
+
+	// without auto-commit the transaction stays in the pool
+	pending := NewNftServiceWithBackend(s.Client, HardhatPrivateKey)
+	pending.SetTxTracker(tracker)
+	original, errs, hashes := s.sendStuck(pending, tracker, contractAddress)
+
+	listed, err := pending.PendingTransactions(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(listed, 1)
+	s.Require().Equal(original.Hash(), listed[0].Hash())
+
+	// a transaction sent by another account cannot be replaced by this signer
+	other := NewNftServiceWithBackend(s.Client, OtherPrivateKey)
+	other.SetTxTracker(tracker)
+	_, err = other.SpeedUpTransaction(context.Background(), original.Hash())
+	s.Require().ErrorContains(err, "was sent by "+HardhatAddress)
+
+	speedUpHash, err := pending.SpeedUpTransaction(context.Background(), original.Hash())
+	s.Require().NoError(err)
+	tracked, err := tracker.Find(original.Hash())
+	s.Require().NoError(err)
+	s.Require().Len(tracked.Attempts, 2)
+	s.Require().Equal(AttemptSpeedUp, tracked.Latest().Kind)
+	s.Require().Equal(speedUpHash, tracked.Latest().Hash)
+	oldTip, _ := new(big.Int).SetString(tracked.Attempts[0].GasTipCap, 10)
+	newTip, _ := new(big.Int).SetString(tracked.Latest().GasTipCap, 10)
+	s.Require().True(newTip.Cmp(bumpFee(oldTip)) >= 0)
+
+	// the waiting call follows the replacement once it is mined
+	s.Backend.Commit()
+	s.Require().NoError(<-errs)
+	s.Require().Equal(speedUpHash, <-hashes)
+
+	tracked, err = tracker.Find(original.Hash())
+	s.Require().NoError(err)
+	s.Require().Equal(TrackedMined, tracked.State)
+	s.Require().Equal(speedUpHash, tracked.MinedHash)
+	_, err = s.Client.TransactionReceipt(context.Background(), common.HexToHash(original.Hash()))
+	s.Require().ErrorIs(err, ethereum.NotFound)
+
+	uri, err := s.NftService.URI(context.Background(), contractAddress, big.NewInt(0))
+	s.Require().NoError(err)
+	s.Require().Equal("https://api.example.com/v2/{id}", uri)
+
+	listed, err = pending.PendingTransactions(context.Background())
+	s.Require().NoError(err)
+	s.Require().Empty(listed)
+}
+
+func (s *NftServiceTestSuite) TestCancelPendingTransaction() {
+	contractAddress := s.deployContract()
+	tracker := NewTxTracker(filepath.Join(s.T().TempDir(), DefaultTxTrackerPath))
+
+	pending := NewNftServiceWithBackend(s.Client, HardhatPrivateKey)
+	pending.SetTxTracker(tracker)
+	original, errs, _ := s.sendStuck(pending, tracker, contractAddress)
+
+	cancelHash, err := pending.CancelTransaction(context.Background(), original.Hash())
+	s.Require().NoError(err)
+
+	// speeding up a cancellation keeps it a cancellation
+	cancelHash, err = pending.SpeedUpTransaction(context.Background(), original.Hash())
+	s.Require().NoError(err)
+	tracked, err := tracker.Find(original.Hash())
+	s.Require().NoError(err)
+	s.Require().Len(tracked.Attempts, 3)
+	s.Require().Equal(AttemptCancel, tracked.Latest().Kind)
+
+	cancelTx, _, err := s.Client.TransactionByHash(context.Background(), common.HexToHash(cancelHash))
+	s.Require().NoError(err)
+	s.Require().Equal(common.HexToAddress(HardhatAddress), *cancelTx.To())
+	s.Require().Zero(cancelTx.Value().Sign())
+	s.Require().Equal(original.Nonce, cancelTx.Nonce())
+
+	// the waiting call reports that the setURI never ran
+	s.Backend.Commit()
+	err = <-errs
+	var replacedErr *TxReplacedError
+	s.Require().ErrorAs(err, &replacedErr)
+	s.Require().Equal(original.Hash(), replacedErr.TxHash)
+	s.Require().Equal(cancelHash, replacedErr.ReplacedBy)
+	s.Require().Equal(ErrorCodeTxReplaced, ErrorCodeOf(err))
+
+	uri, err := s.NftService.URI(context.Background(), contractAddress, big.NewInt(0))
+	s.Require().NoError(err)
+	s.Require().Equal("https://api.example.com/init/{id}", uri)
+
+	_, err = pending.CancelTransaction(context.Background(), original.Hash())
+	s.Require().ErrorContains(err, "already cancelled")
+}
+
+func TestReplacementFees(t *testing.T) {
+	dynamic := types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1000)})
+
+	// the bump wins over a lower suggestion
+	fees, err := replacementFees(dynamic, txFees{GasTipCap: big.NewInt(50), GasFeeCap: big.NewInt(500)}, FeePolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(110), fees.GasTipCap)
+	assert.Equal(t, big.NewInt(1100), fees.GasFeeCap)
+
+	// a higher suggestion wins over the bump
+	fees, err = replacementFees(dynamic, txFees{GasTipCap: big.NewInt(300), GasFeeCap: big.NewInt(5000)}, FeePolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(300), fees.GasTipCap)
+	assert.Equal(t, big.NewInt(5000), fees.GasFeeCap)
+
+	// a cap below the required bump cannot replace the transaction
+	_, err = replacementFees(dynamic, txFees{GasTipCap: big.NewInt(50), GasFeeCap: big.NewInt(500)}, FeePolicy{MaxFeePerGas: big.NewInt(1050)})
+	assert.ErrorContains(t, err, "above the fee cap of 1050")
+
+	// legacy transactions stay legacy, the bump rounds up
+	legacy := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(101)})
+	fees, err = replacementFees(legacy, txFees{GasPrice: big.NewInt(100)}, FeePolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(112), fees.GasPrice)
+	assert.Nil(t, fees.GasTipCap)
+}
+
+func TestTxTrackerPrunesSettled(t *testing.T) {
+	tracker := NewTxTracker(filepath.Join(t.TempDir(), DefaultTxTrackerPath))
+	from := common.HexToAddress(HardhatAddress)
+	for nonce := range uint64(trackerHistory + 2) {
+		tx := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1337), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2)})
+		require.NoError(t, tracker.record("setURI", from, tx, AttemptOriginal))
+		if nonce > 0 {
+			require.NoError(t, tracker.settle(tx.Hash().Hex(), TrackedMined, tx.Hash().Hex()))
+		}
+	}
+
+	// the pending transaction is always kept, settled ones only up to trackerHistory
+	txs, err := tracker.List()
+	require.NoError(t, err)
+	require.Len(t, txs, trackerHistory+1)
+	assert.Equal(t, TrackedPending, txs[0].State)
+	assert.Equal(t, uint64(2), txs[1].Nonce)
+
+	pending, err := tracker.Pending(1337)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+	pending, err = tracker.Pending(1)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func (s *NftServiceTestSuite) TestResumeFollowsSpeedUp() {
+	contractAddress := s.deployContract()
+	dir := s.T().TempDir()
+	journalPath := filepath.Join(dir, DefaultJournalPath)
+	tracker := NewTxTracker(filepath.Join(dir, DefaultTxTrackerPath))
+	recipients := []string{"0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65"}
+
+	// the batch is broadcast, then the run is cut off while it is stuck
+	pending := NewNftServiceWithBackend(s.Client, HardhatPrivateKey)
+	pending.SetTxTracker(tracker)
+	journal, err := pending.PlanAirdrop(context.Background(), journalPath, contractAddress, recipients, "4", "")
+	s.Require().NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	_, err = pending.RunAirdrop(ctx, journal, nil)
+	var pendingErr *TxPendingError
+	s.Require().ErrorAs(err, &pendingErr)
+
+	// the stuck batch is sped up and the replacement gets mined while the app is down
+	speedUpHash, err := pending.SpeedUpTransaction(context.Background(), pendingErr.TxHash)
+	s.Require().NoError(err)
+	s.Backend.Commit()
+
+	// resuming finds the replacement instead of minting the batch again
+	resumer := NewNftServiceWithBackend(&autoCommitBackend{Client: s.Client, sim: s.Backend}, HardhatPrivateKey)
+	resumer.SetTxTracker(tracker)
+	saved, err := LoadAirdropJournal(journalPath)
+	s.Require().NoError(err)
+	txHashes, err := resumer.RunAirdrop(context.Background(), saved, nil)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{speedUpHash}, txHashes)
+	s.Require().Equal(int64(1), s.balanceOf(contractAddress, recipients[0], 4).Int64())
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ResultVersion is the version of the Result schema. Fields may be added within a
+// version, it changes when a field is renamed, removed or changes meaning.
+const ResultVersion = 1
+
+// Operations reported in Result.Operation
+const (
+	OperationDeploy            = "deploy"
+	OperationImport            = "import"
+	OperationAirdrop           = "airdrop"
+	OperationAirdropBatch      = "airdrop.batch"
+	OperationSetURI            = "set-uri"
+	OperationTransferOwnership = "transfer-ownership"
+	OperationRenounceOwnership = "renounce-ownership"
+	OperationInspect           = "inspect"
+	OperationContractsList     = "contracts.list"
+	OperationJob               = "job"
+	OperationBundlePrepare     = "bundle.prepare"
+	OperationBundleSign        = "bundle.sign"
+	OperationBundleBroadcast   = "bundle.broadcast"
+	OperationRegistryRepair    = "registry.repair"
+	OperationKeystoreNew       = "keystore.new"
+	OperationKeystoreImport    = "keystore.import"
+	OperationServe             = "serve"
+)
+
+// ErrorCode identifies the kind of a failure independent of its message, codes are never
+// renamed within a ResultVersion
+type ErrorCode string
+
+const (
+	// ErrorCodeFailed is any failure without a more specific code
+	ErrorCodeFailed ErrorCode = "failed"
+	// ErrorCodeUsage is an invalid argument, nothing was done
+	ErrorCodeUsage ErrorCode = "usage"
+	// ErrorCodeNotConfirmed is a declined confirmation, nothing was sent
+	ErrorCodeNotConfirmed ErrorCode = "not_confirmed"
+	// ErrorCodeCancelled is a cancellation before anything was broadcast
+	ErrorCodeCancelled ErrorCode = "cancelled"
+	// ErrorCodeNotOwner is a signer refused before an onlyOwner transaction
+	ErrorCodeNotOwner ErrorCode = "not_owner"
+	// ErrorCodeChainMismatch is a node serving another chain than the network profile pins
+	// or than the airdrop journal was planned on
+	ErrorCodeChainMismatch ErrorCode = "chain_mismatch"
+	// ErrorCodeWrongPassphrase is a keystore that could not be unlocked
+	ErrorCodeWrongPassphrase ErrorCode = "wrong_passphrase"
+	// ErrorCodeInsufficientFunds is a signer that cannot pay for the transaction
+	ErrorCodeInsufficientFunds ErrorCode = "insufficient_funds"
+	// ErrorCodeTxPending is a broadcast transaction that was not mined in time, it may still be
+	ErrorCodeTxPending ErrorCode = "tx_pending"
+	// ErrorCodeTxReverted is a transaction mined with a failed status
+	ErrorCodeTxReverted ErrorCode = "tx_reverted"
+	// ErrorCodeTxReplaced is a transaction whose nonce went to a cancellation or another transaction
+	ErrorCodeTxReplaced ErrorCode = "tx_replaced"
+	// ErrorCodeUnfinishedAirdrop is another airdrop recorded in the journal and not finished
+	ErrorCodeUnfinishedAirdrop ErrorCode = "unfinished_airdrop"
+	// ErrorCodeUnauthorized is an API request without the server's token
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+	// ErrorCodeNotFound is an API request for an unknown job or contract
+	ErrorCodeNotFound ErrorCode = "not_found"
+)
+
+// Result is the outcome of one operation, shared by the TUI log and the JSON output of
+// the commands. Only the payload of its Operation is set.
+type Result struct {
+	Version   int       `json:"version"`
+	Operation string    `json:"operation"`
+	OK        bool      `json:"ok"`
+	Time      time.Time `json:"time"`
+	Network   string    `json:"network,omitempty"`
+	ChainID   uint64    `json:"chainId,omitempty"`
+	Contract  string    `json:"contract,omitempty"`
+
+	Deployment *DeploymentOutput `json:"deployment,omitempty"`
+	Batch      *BatchOutput      `json:"batch,omitempty"`
+	Airdrop    *AirdropOutput    `json:"airdrop,omitempty"`
+	URIUpdate  *URIUpdateOutput  `json:"uriUpdate,omitempty"`
+	Ownership  *OwnershipOutput  `json:"ownership,omitempty"`
+	Inspection *InspectionOutput `json:"inspection,omitempty"`
+	Registry   *RegistryOutput   `json:"registry,omitempty"`
+	Bundle     *BundleOutput     `json:"bundle,omitempty"`
+	Repair     *RepairOutput     `json:"repair,omitempty"`
+	Keystore   *KeystoreOutput   `json:"keystore,omitempty"`
+	Server     *ServerOutput     `json:"server,omitempty"`
+
+	Error *ResultError `json:"error,omitempty"`
+}
+
+// DeploymentOutput describes a deployed or imported contract
+type DeploymentOutput struct {
+	Address      string `json:"address"`
+	ContractName string `json:"contractName,omitempty"`
+	TxHash       string `json:"txHash,omitempty"`
+	BlockNumber  uint64 `json:"blockNumber,omitempty"`
+	Deployer     string `json:"deployer,omitempty"`
+	TokenURI     string `json:"tokenUri,omitempty"`
+	BytecodeHash string `json:"bytecodeHash,omitempty"`
+	Imported     bool   `json:"imported"`
+}
+
+// BatchOutput describes one mint batch of an airdrop
+type BatchOutput struct {
+	Index      int    `json:"index"`
+	Total      int    `json:"total"`
+	Recipients int    `json:"recipients"`
+	TokenID    string `json:"tokenId"`
+	TxHash     string `json:"txHash,omitempty"`
+}
+
+// AirdropOutput describes a whole airdrop, TxHashes lists the batches mined by this run
+type AirdropOutput struct {
+	TokenID    string   `json:"tokenId"`
+	URI        string   `json:"uri,omitempty"`
+	Recipients int      `json:"recipients"`
+	Batches    int      `json:"batches"`
+	TxHashes   []string `json:"txHashes"`
+}
+
+// URIUpdateOutput describes a setURI transaction
+type URIUpdateOutput struct {
+	URI    string `json:"uri"`
+	TxHash string `json:"txHash"`
+}
+
+// OwnershipOutput describes an ownership transfer, NewOwner is empty after a renounce
+type OwnershipOutput struct {
+	NewOwner string `json:"newOwner,omitempty"`
+	TxHash   string `json:"txHash"`
+}
+
+// InspectionOutput describes the state of a contract and optionally the balances of an account
+type InspectionOutput struct {
+	Owner     string         `json:"owner"`
+	BatchSize int            `json:"batchSize"`
+	URI       string         `json:"uri"`
+	Account   string         `json:"account,omitempty"`
+	Balances  []TokenBalance `json:"balances,omitempty"`
+}
+
+// TokenBalance is the balance of one token ID, both are decimal strings
+type TokenBalance struct {
+	TokenID string `json:"tokenId"`
+	Balance string `json:"balance"`
+}
+
+// RegistryOutput lists recorded contracts without their ABI
+type RegistryOutput struct {
+	Contracts []RegistryEntry `json:"contracts"`
+}
+
+// RegistryEntry is one contract of the registry, ChainID is 0 while its chain is unknown
+type RegistryEntry struct {
+	Address      string    `json:"address"`
+	ContractName string    `json:"contractName,omitempty"`
+	ChainID      uint64    `json:"chainId"`
+	Network      string    `json:"network,omitempty"`
+	Deployer     string    `json:"deployer,omitempty"`
+	DeployTx     string    `json:"deployTx,omitempty"`
+	DeployBlock  uint64    `json:"deployBlock,omitempty"`
+	DeployTime   time.Time `json:"deployTime"`
+	TokenURI     string    `json:"tokenUri,omitempty"`
+	Imported     bool      `json:"imported"`
+}
+
+// BundleOutput describes a bundle file and the state of its transactions
+type BundleOutput struct {
+	Path         string           `json:"path"`
+	Kind         string           `json:"kind"`
+	From         string           `json:"from"`
+	Transactions []BundleTxOutput `json:"transactions"`
+}
+
+// BundleTxOutput is one transaction of a bundle, TxHash is set once it is signed
+type BundleTxOutput struct {
+	Label           string     `json:"label"`
+	State           BatchState `json:"state"`
+	Nonce           uint64     `json:"nonce"`
+	To              string     `json:"to,omitempty"`
+	TxHash          string     `json:"txHash,omitempty"`
+	BlockNumber     uint64     `json:"blockNumber,omitempty"`
+	ContractAddress string     `json:"contractAddress,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// RepairOutput describes what a registry repair did, Backup is empty when it was healthy
+type RepairOutput struct {
+	Path        string `json:"path"`
+	Healthy     bool   `json:"healthy"`
+	CorruptPath string `json:"corruptPath,omitempty"`
+	Backup      string `json:"backup,omitempty"`
+	Contracts   int    `json:"contracts"`
+}
+
+// KeystoreOutput describes a written keystore file
+type KeystoreOutput struct {
+	Address string `json:"address"`
+	Path    string `json:"path"`
+}
+
+// ServerOutput describes a started API server
+type ServerOutput struct {
+	URL    string `json:"url"`
+	Signer string `json:"signer"`
+}
+
+// ResultError describes why an operation failed. TxHash is set when a transaction was
+// broadcast, it may be pending or reverted.
+type ResultError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	TxHash  string    `json:"txHash,omitempty"`
+}
+
+// newResult starts a successful result of operation
+func newResult(operation string) Result {
+	return Result{Version: ResultVersion, Operation: operation, OK: true, Time: time.Now()}
+}
+
+// NewDeployResult reports a contract recorded by a deployment, or by ImportContract when
+// it is imported
+func NewDeployResult(contract DeployedContract) Result {
+	result := newResult(OperationDeploy)
+	if contract.Imported {
+		result.Operation = OperationImport
+	}
+	result.Network, result.ChainID, result.Contract = contract.Network, contract.ChainID, contract.Address
+	result.Deployment = &DeploymentOutput{
+		Address:      contract.Address,
+		ContractName: contract.ContractName,
+		TxHash:       contract.DeployTx,
+		BlockNumber:  contract.DeployBlock,
+		Deployer:     contract.Deployer,
+		TokenURI:     contract.TokenURI,
+		BytecodeHash: contract.BytecodeHash,
+		Imported:     contract.Imported,
+	}
+	return result
+}
+
+// NewBatchResult reports one batch of the airdrop in journal, failed when status.Err is set
+func NewBatchResult(journal *AirdropJournal, status BatchStatus) Result {
+	result := newResult(OperationAirdropBatch)
+	result.Contract = journal.ContractAddress
+	result.Batch = &BatchOutput{
+		Index:      status.Index,
+		Total:      status.Total,
+		Recipients: status.Recipients,
+		TokenID:    journal.NFTID,
+		TxHash:     status.TxHash,
+	}
+	if status.Err != nil {
+		result.fail(status.Err)
+	}
+	return result
+}
+
+// NewAirdropResult reports the airdrop in journal after RunAirdrop returned txHashes and err
+func NewAirdropResult(journal *AirdropJournal, txHashes []string, err error) Result {
+	result := newResult(OperationAirdrop)
+	result.Contract = journal.ContractAddress
+	recipients := 0
+	for _, batch := range journal.Batches {
+		recipients += len(batch.Recipients)
+	}
+	if txHashes == nil {
+		txHashes = []string{}
+	}
+	result.Airdrop = &AirdropOutput{
+		TokenID:    journal.NFTID,
+		URI:        journal.URI,
+		Recipients: recipients,
+		Batches:    len(journal.Batches),
+		TxHashes:   txHashes,
+	}
+	if err != nil {
+		result.fail(err)
+	}
+	return result
+}
+
+// NewSetURIResult reports a setURI transaction
+func NewSetURIResult(contractAddr string, uri string, txHash string) Result {
+	result := newResult(OperationSetURI)
+	result.Contract = contractAddr
+	result.URIUpdate = &URIUpdateOutput{URI: uri, TxHash: txHash}
+	return result
+}
+
+// NewOwnershipResult reports a transfer to newOwner, or a renounce when newOwner is empty
+func NewOwnershipResult(contractAddr string, newOwner string, txHash string) Result {
+	result := newResult(OperationTransferOwnership)
+	if newOwner == "" {
+		result.Operation = OperationRenounceOwnership
+	}
+	result.Contract = contractAddr
+	result.Ownership = &OwnershipOutput{NewOwner: newOwner, TxHash: txHash}
+	return result
+}
+
+// NewInspectResult reports the state of a contract, account and balances are optional
+// and balances[i] is the balance of ids[i]
+func NewInspectResult(contractAddr string, owner string, batchSize int, uri string, account string, ids []*big.Int, balances []*big.Int) Result {
+	result := newResult(OperationInspect)
+	result.Contract = contractAddr
+	result.Inspection = &InspectionOutput{Owner: owner, BatchSize: batchSize, URI: uri, Account: account}
+	for i, id := range ids {
+		if i < len(balances) {
+			result.Inspection.Balances = append(result.Inspection.Balances, TokenBalance{TokenID: id.String(), Balance: balances[i].String()})
+		}
+	}
+	return result
+}
+
+// NewContractsResult lists contracts of the registry
+func NewContractsResult(contracts []DeployedContract) Result {
+	result := newResult(OperationContractsList)
+	result.Registry = &RegistryOutput{Contracts: make([]RegistryEntry, len(contracts))}
+	for i, contract := range contracts {
+		result.Registry.Contracts[i] = RegistryEntry{
+			Address:      contract.Address,
+			ContractName: contract.ContractName,
+			ChainID:      contract.ChainID,
+			Network:      contract.Network,
+			Deployer:     contract.Deployer,
+			DeployTx:     contract.DeployTx,
+			DeployBlock:  contract.DeployBlock,
+			DeployTime:   contract.DeployTime,
+			TokenURI:     contract.TokenURI,
+			Imported:     contract.Imported,
+		}
+	}
+	return result
+}
+
+// NewBundleResult reports the bundle at path after operation, failed when err is set. A
+// failed broadcast still lists what was mined.
+func NewBundleResult(operation string, path string, bundle *TxBundle, err error) Result {
+	result := newResult(operation)
+	if chainID, ok := new(big.Int).SetString(bundle.ChainID, 10); ok && chainID.IsUint64() {
+		result.ChainID = chainID.Uint64()
+	}
+	result.Bundle = &BundleOutput{Path: path, Kind: bundle.Kind, From: bundle.From, Transactions: make([]BundleTxOutput, len(bundle.Transactions))}
+	for i, btx := range bundle.Transactions {
+		output := BundleTxOutput{
+			Label:           btx.Label,
+			State:           btx.State,
+			TxHash:          btx.TxHash,
+			BlockNumber:     btx.BlockNumber,
+			ContractAddress: btx.ContractAddress,
+			Error:           btx.Error,
+		}
+		if btx.Tx != nil {
+			output.Nonce = btx.Tx.Nonce()
+			if btx.Tx.To() != nil {
+				output.To = btx.Tx.To().Hex()
+			}
+		}
+		result.Bundle.Transactions[i] = output
+	}
+	if err != nil {
+		result.fail(err)
+	}
+	return result
+}
+
+// NewRepairResult reports the repair of the registry at path
+func NewRepairResult(path string, repair RepairResult) Result {
+	result := newResult(OperationRegistryRepair)
+	result.Repair = &RepairOutput{
+		Path:        path,
+		Healthy:     repair.Healthy,
+		CorruptPath: repair.CorruptPath,
+		Backup:      repair.Backup,
+		Contracts:   repair.Contracts,
+	}
+	return result
+}
+
+// NewKeystoreResult reports the keystore file of address written by operation
+func NewKeystoreResult(operation string, address string, path string) Result {
+	result := newResult(operation)
+	result.Keystore = &KeystoreOutput{Address: address, Path: path}
+	return result
+}
+
+// NewServeResult reports an API server listening on url and signing as signer
+func NewServeResult(url string, signer string) Result {
+	result := newResult(OperationServe)
+	result.Server = &ServerOutput{URL: url, Signer: signer}
+	return result
+}
+
+// NewErrorResult reports a failed operation, the code is derived from err
+func NewErrorResult(operation string, err error) Result {
+	result := newResult(operation)
+	result.fail(err)
+	return result
+}
+
+// WithNetwork sets the network the operation ran on unless the result already names one
+func (r Result) WithNetwork(network string, chainID uint64) Result {
+	if r.Network == "" && r.ChainID == 0 {
+		r.Network, r.ChainID = network, chainID
+	}
+	return r
+}
+
+// fail marks the result failed with err
+func (r *Result) fail(err error) {
+	r.OK = false
+	r.Error = &ResultError{Code: ErrorCodeOf(err), Message: err.Error()}
+
+	var pendingErr *TxPendingError
+	var revertedErr *TxRevertedError
+	var replacedErr *TxReplacedError
+	if errors.As(err, &pendingErr) {
+		r.Error.TxHash = pendingErr.TxHash
+	} else if errors.As(err, &revertedErr) {
+		r.Error.TxHash = revertedErr.TxHash
+	} else if errors.As(err, &replacedErr) {
+		r.Error.TxHash = replacedErr.TxHash
+	}
+}
+
+// ErrorCodeOf returns the code of err, ErrorCodeFailed when it is not one of the known
+// failures. The error has to be wrapped with %w for the code to survive.
+func ErrorCodeOf(err error) ErrorCode {
+	var pendingErr *TxPendingError
+	var revertedErr *TxRevertedError
+	var replacedErr *TxReplacedError
+	var notOwnerErr *NotOwnerError
+	var mismatchErr *ChainIDMismatchError
+	var journalChainErr *JournalChainError
+	var unfinishedErr *UnfinishedAirdropError
+	switch {
+	// a pending transaction may wrap context.Canceled but was sent
+	case errors.As(err, &pendingErr):
+		return ErrorCodeTxPending
+	case errors.As(err, &revertedErr):
+		return ErrorCodeTxReverted
+	case errors.As(err, &replacedErr):
+		return ErrorCodeTxReplaced
+	case errors.As(err, &notOwnerErr):
+		return ErrorCodeNotOwner
+	case errors.As(err, &mismatchErr), errors.As(err, &journalChainErr):
+		return ErrorCodeChainMismatch
+	case errors.As(err, &unfinishedErr):
+		return ErrorCodeUnfinishedAirdrop
+	case errors.Is(err, ErrWrongPassphrase):
+		return ErrorCodeWrongPassphrase
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeCancelled
+	// nodes only report it as text
+	case strings.Contains(err.Error(), "insufficient funds"):
+		return ErrorCodeInsufficientFunds
+	}
+	return ErrorCodeFailed
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	pending := &TxPendingError{TxHash: "0xabc", Err: context.Canceled}
+	cases := []struct {
+		err  error
+		code ErrorCode
+	}{
+		{errors.New("boom"), ErrorCodeFailed},
+		{fmt.Errorf("batch 1/2 failed: %w", context.Canceled), ErrorCodeCancelled},
+		// a pending transaction was sent even though it wraps the cancellation
+		{fmt.Errorf("batch 1/2 failed: %w", pending), ErrorCodeTxPending},
+		{&TxRevertedError{TxHash: "0xdef"}, ErrorCodeTxReverted},
+		{fmt.Errorf("batch 1/2 failed: %w", &TxReplacedError{TxHash: "0x123", ReplacedBy: "0x456"}), ErrorCodeTxReplaced},
+		{&NotOwnerError{}, ErrorCodeNotOwner},
+		{&ChainIDMismatchError{Expected: big.NewInt(1), Actual: big.NewInt(2)}, ErrorCodeChainMismatch},
+		{ErrWrongPassphrase, ErrorCodeWrongPassphrase},
+		{&UnfinishedAirdropError{ContractAddress: "0x1", Path: DefaultJournalPath}, ErrorCodeUnfinishedAirdrop},
+		{errors.New("insufficient funds for gas * price + value"), ErrorCodeInsufficientFunds},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.code, ErrorCodeOf(c.err), c.err.Error())
+	}
+
+	result := NewErrorResult(OperationAirdrop, pending)
+	assert.False(t, result.OK)
+	assert.Equal(t, "0xabc", result.Error.TxHash)
+}
+
+func TestResultJSON(t *testing.T) {
+	journal := NewAirdropJournal("", "0x5FbDB2315678afecb367f032d93F642f64180aa3", "7", "", [][]string{{"0x1", "0x2"}, {"0x3"}})
+	status := BatchStatus{Index: 1, Total: 2, Recipients: 2, TxHash: "0xaaa"}
+
+	data, err := json.Marshal(NewBatchResult(journal, status).WithNetwork("hardhat", 31337))
+	require.NoError(t, err)
+	var batch map[string]any
+	require.NoError(t, json.Unmarshal(data, &batch))
+	assert.EqualValues(t, ResultVersion, batch["version"])
+	assert.Equal(t, "airdrop.batch", batch["operation"])
+	assert.Equal(t, true, batch["ok"])
+	assert.Equal(t, "hardhat", batch["network"])
+	assert.EqualValues(t, 31337, batch["chainId"])
+	assert.Equal(t, map[string]any{"index": 1.0, "total": 2.0, "recipients": 2.0, "tokenId": "7", "txHash": "0xaaa"}, batch["batch"])
+	assert.NotContains(t, batch, "error")
+
+	// a failed airdrop still lists what it mined
+	data, err = json.Marshal(NewAirdropResult(journal, []string{"0xaaa"}, &TxRevertedError{TxHash: "0xbbb"}))
+	require.NoError(t, err)
+	var airdrop map[string]any
+	require.NoError(t, json.Unmarshal(data, &airdrop))
+	assert.Equal(t, false, airdrop["ok"])
+	assert.Equal(t, []any{"0xaaa"}, airdrop["airdrop"].(map[string]any)["txHashes"])
+	assert.EqualValues(t, 3, airdrop["airdrop"].(map[string]any)["recipients"])
+	assert.Equal(t, map[string]any{"code": "tx_reverted", "message": "transaction 0xbbb reverted", "txHash": "0xbbb"}, airdrop["error"])
+
+	// an empty registry is an empty list, not a missing one
+	data, err = json.Marshal(NewContractsResult(nil))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"registry":{"contracts":[]}`)
+}
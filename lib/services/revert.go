@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// TxRevertedError is returned when a transaction is mined with a failed status
+type TxRevertedError struct {
+	TxHash      string
+	BlockNumber *big.Int
+	GasUsed     uint64
+	// Reason is the decoded revert reason, empty when it could not be recovered
+	Reason string
+}
+
+func (e *TxRevertedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("transaction %s reverted", e.TxHash)
+	}
+	return fmt.Sprintf("transaction %s reverted: %s", e.TxHash, e.Reason)
+}
+
+// knownErrorsABI holds the custom errors of OpenZeppelin's Ownable and ERC1155 so
+// reverts can be decoded even when the caller only passed an ABI fragment
+const knownErrorsABI = `[
+	{"type": "error", "name": "OwnableUnauthorizedAccount", "inputs": [{"name": "account", "type": "address"}]},
+	{"type": "error", "name": "OwnableInvalidOwner", "inputs": [{"name": "owner", "type": "address"}]},
+	{"type": "error", "name": "ERC1155InsufficientBalance", "inputs": [
+		{"name": "sender", "type": "address"},
+		{"name": "balance", "type": "uint256"},
+		{"name": "needed", "type": "uint256"},
+		{"name": "tokenId", "type": "uint256"}
+	]},
+	{"type": "error", "name": "ERC1155InvalidSender", "inputs": [{"name": "sender", "type": "address"}]},
+	{"type": "error", "name": "ERC1155InvalidReceiver", "inputs": [{"name": "receiver", "type": "address"}]},
+	{"type": "error", "name": "ERC1155MissingApprovalForAll", "inputs": [
+		{"name": "operator", "type": "address"},
+		{"name": "owner", "type": "address"}
+	]},
+	{"type": "error", "name": "ERC1155InvalidApprover", "inputs": [{"name": "approver", "type": "address"}]},
+	{"type": "error", "name": "ERC1155InvalidOperator", "inputs": [{"name": "operator", "type": "address"}]},
+	{"type": "error", "name": "ERC1155InvalidArrayLength", "inputs": [
+		{"name": "idsLength", "type": "uint256"},
+		{"name": "valuesLength", "type": "uint256"}
+	]}
+]`
+
+// DecodeRevertReason turns revert data into a readable message.
+// Error(string) and Panic(uint256) are always understood, custom errors are looked
+// up in contractABI first and then in the OpenZeppelin errors above.
+func DecodeRevertReason(data []byte, contractABI string) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason
+	}
+
+	if len(data) >= 4 {
+		for _, source := range []string{contractABI, knownErrorsABI} {
+			if source == "" {
+				continue
+			}
+			parsedABI, err := abi.JSON(strings.NewReader(source))
+			if err != nil {
+				continue
+			}
+			for _, abiErr := range parsedABI.Errors {
+				if !bytes.Equal(abiErr.ID[:4], data[:4]) {
+					continue
+				}
+				return formatCustomError(abiErr, data[4:])
+			}
+		}
+	}
+
+	return fmt.Sprintf("unknown revert data %s", hexutil.Encode(data))
+}
+
+// formatCustomError renders a custom error as Name(arg=value, ...)
+func formatCustomError(abiErr abi.Error, payload []byte) string {
+	values, err := abiErr.Inputs.Unpack(payload)
+	if err != nil {
+		return abiErr.Name
+	}
+
+	args := make([]string, len(values))
+	for i, value := range values {
+		name := abiErr.Inputs[i].Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		args[i] = fmt.Sprintf("%s=%v", name, value)
+	}
+	return fmt.Sprintf("%s(%s)", abiErr.Name, strings.Join(args, ", "))
+}
+
+// revertDataFromError extracts the revert payload carried by an eth_call error
+func revertDataFromError(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+
+	encoded, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, decodeErr := hexutil.Decode(encoded)
+	if decodeErr != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// checkReceipt turns a failed receipt into a TxRevertedError, replaying msg with eth_call
+// at the mined block to recover the revert reason
+func checkReceipt(ctx context.Context, client ChainBackend, receipt *types.Receipt, msg ethereum.CallMsg, contractABI string) error {
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return nil
+	}
+
+	revertErr := &TxRevertedError{
+		TxHash:      receipt.TxHash.Hex(),
+		BlockNumber: receipt.BlockNumber,
+		GasUsed:     receipt.GasUsed,
+	}
+
+	// the replay gets the node's gas cap so an out-of-gas failure still shows up as one
+	gasLimit := msg.Gas
+	msg.Gas = 0
+	_, err := client.CallContract(ctx, msg, receipt.BlockNumber)
+	if err == nil {
+		revertErr.Reason = fmt.Sprintf("no revert reason on replay, possibly out of gas (used %d of %d)", receipt.GasUsed, gasLimit)
+		return revertErr
+	}
+
+	if data, ok := revertDataFromError(err); ok {
+		revertErr.Reason = DecodeRevertReason(data, contractABI)
+	} else {
+		revertErr.Reason = err.Error()
+	}
+	return revertErr
+}
+
+// toCallMsg rebuilds the call a signed transaction performed
+func toCallMsg(from common.Address, tx *types.Transaction) ethereum.CallMsg {
+	return ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRevertReason(t *testing.T) {
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	addressType, _ := abi.NewType("address", "", nil)
+
+	// Panic(uint256) with the arithmetic overflow code
+	payload, err := abi.Arguments{{Type: uint256Type}}.Pack(big.NewInt(0x11))
+	require.NoError(t, err)
+	data := append(crypto.Keccak256([]byte("Panic(uint256)"))[:4], payload...)
+	require.Contains(t, DecodeRevertReason(data, ""), "overflow")
+
+	// an OpenZeppelin custom error is decoded without the contract ABI
+	account := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	payload, err = abi.Arguments{{Type: addressType}}.Pack(account)
+	require.NoError(t, err)
+	data = append(crypto.Keccak256([]byte("OwnableUnauthorizedAccount(address)"))[:4], payload...)
+	require.Equal(t, "OwnableUnauthorizedAccount(account="+account.Hex()+")", DecodeRevertReason(data, ""))
+
+	// custom errors from the contract ABI
+	contractABI := `[{"type": "error", "name": "SoldOut", "inputs": [{"name": "id", "type": "uint256"}]}]`
+	payload, err = abi.Arguments{{Type: uint256Type}}.Pack(big.NewInt(7))
+	require.NoError(t, err)
+	data = append(crypto.Keccak256([]byte("SoldOut(uint256)"))[:4], payload...)
+	require.Equal(t, "SoldOut(id=7)", DecodeRevertReason(data, contractABI))
+
+	require.Equal(t, "unknown revert data 0xdeadbeef", DecodeRevertReason([]byte{0xde, 0xad, 0xbe, 0xef}, ""))
+	require.Empty(t, DecodeRevertReason(nil, ""))
+}
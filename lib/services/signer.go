@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Signer holds the account NftService sends transactions from. Implementations keep the
+// key in memory (NewPrivateKeySigner, UnlockKeystore) or in a separate process (NewRemoteSigner).
+type Signer interface {
+	// Address returns the account transactions are sent from
+	Address() common.Address
+	// SignTx signs tx for the chain with the given ID
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// SignTypedData signs the EIP-712 hash of data, the signature ends with V 27 or 28
+	SignTypedData(ctx context.Context, data apitypes.TypedData) ([]byte, error)
+}
+
+// ErrWrongPassphrase is returned when a keystore cannot be decrypted with the given passphrase
+var ErrWrongPassphrase = errors.New("wrong keystore passphrase")
+
+// scrypt cost of the keystores written by CreateKeystore and ImportKeystore, tests lower it
+var (
+	keystoreScryptN = keystore.StandardScryptN
+	keystoreScryptP = keystore.StandardScryptP
+)
+
+// keySigner signs with a private key held in memory
+type keySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func newKeySigner(key *ecdsa.PrivateKey) *keySigner {
+	return &keySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (k *keySigner) Address() common.Address {
+	return k.address
+}
+
+func (k *keySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewLondonSigner(chainID), k.key)
+}
+
+func (k *keySigner) SignTypedData(ctx context.Context, data apitypes.TypedData) ([]byte, error) {
+	hash, _, err := apitypes.TypedDataAndHash(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %v", err)
+	}
+	signature, err := crypto.Sign(hash, k.key)
+	if err != nil {
+		return nil, err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// NewPrivateKeySigner creates a signer from a hex private key, with or without 0x prefix
+func NewPrivateKeySigner(privateKey string) (Signer, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	return newKeySigner(key), nil
+}
+
+// UnlockKeystore decrypts a go-ethereum keystore file (scrypt JSON) with passphrase,
+// the key only lives in memory afterwards
+func UnlockKeystore(path string, passphrase string) (Signer, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		if errors.Is(err, keystore.ErrDecrypt) {
+			return nil, ErrWrongPassphrase
+		}
+		return nil, fmt.Errorf("failed to decrypt keystore: %v", err)
+	}
+	return newKeySigner(key.PrivateKey), nil
+}
+
+// CreateKeystore generates a new key and stores it encrypted with passphrase in dir,
+// the returned account holds its address and the path of the keystore file
+func CreateKeystore(dir string, passphrase string) (accounts.Account, error) {
+	account, err := keystore.StoreKey(dir, passphrase, keystoreScryptN, keystoreScryptP)
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("failed to create keystore: %v", err)
+	}
+	return account, nil
+}
+
+// ImportKeystore stores a hex private key encrypted with passphrase in dir, the returned
+// account holds its address and the path of the keystore file
+func ImportKeystore(dir string, privateKey string, passphrase string) (accounts.Account, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("invalid private key: %v", err)
+	}
+
+	ks := keystore.NewKeyStore(dir, keystoreScryptN, keystoreScryptP)
+	account, err := ks.ImportECDSA(key, passphrase)
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("failed to import key: %v", err)
+	}
+	return account, nil
+}
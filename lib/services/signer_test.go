@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// useLightScrypt makes keystores cheap to write and unlock for the duration of a test
+func useLightScrypt(t *testing.T) {
+	n, p := keystoreScryptN, keystoreScryptP
+	keystoreScryptN, keystoreScryptP = keystore.LightScryptN, keystore.LightScryptP
+	t.Cleanup(func() {
+		keystoreScryptN, keystoreScryptP = n, p
+	})
+}
+
+func TestCreateAndUnlockKeystore(t *testing.T) {
+	useLightScrypt(t)
+
+	account, err := CreateKeystore(t.TempDir(), "correct horse")
+	require.NoError(t, err)
+
+	signer, err := UnlockKeystore(account.URL.Path, "correct horse")
+	require.NoError(t, err)
+	require.Equal(t, account.Address, signer.Address())
+
+	_, err = UnlockKeystore(account.URL.Path, "wrong horse")
+	require.ErrorIs(t, err, ErrWrongPassphrase)
+}
+
+func TestImportKeystore(t *testing.T) {
+	useLightScrypt(t)
+
+	account, err := ImportKeystore(t.TempDir(), HardhatPrivateKey, "secret")
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress(HardhatAddress), account.Address)
+
+	signer, err := UnlockKeystore(account.URL.Path, "secret")
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress(HardhatAddress), signer.Address())
+
+	_, err = ImportKeystore(t.TempDir(), "not a key", "secret")
+	require.Error(t, err)
+}
+
+func (s *NftServiceTestSuite) TestSendWithUnlockedKeystore() {
+	useLightScrypt(s.T())
+	contractAddress := s.deployContract()
+
+	account, err := ImportKeystore(s.T().TempDir(), HardhatPrivateKey, "secret")
+	s.Require().NoError(err)
+
+	// no key until the keystore is unlocked
+	service := NewNftServiceWithSigner("", nil)
+	service.backend = &autoCommitBackend{Client: s.Client, sim: s.Backend}
+	_, err = service.SignerAddress()
+	s.Require().Error(err)
+	s.Require().Error(service.SetURI(context.Background(), contractAddress, "https://api.example.com/locked/{id}"))
+
+	signer, err := UnlockKeystore(account.URL.Path, "secret")
+	s.Require().NoError(err)
+	service.SetSigner(signer)
+
+	s.Require().NoError(service.SetURI(context.Background(), contractAddress, "https://api.example.com/unlocked/{id}"))
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// SimulationResult is the expected outcome of one transaction, nothing was signed or sent
+type SimulationResult struct {
+	// Label names the transaction, e.g. "setURI" or "mintToMultple 2/3"
+	Label string
+	// Success is false when the call reverted, RevertReason then says why
+	Success      bool
+	RevertReason string
+	// GasLimit is the limit the transaction would be sent with
+	GasLimit uint64
+	// MaxFee is the most the transaction can cost, GasLimit times the fee cap
+	MaxFee *big.Int
+}
+
+// simulate runs msg with eth_call against pending state and, when it succeeds, estimates
+// the gas limit and the fee the transaction would be sent with
+func (s *NftService) simulate(ctx context.Context, cfg serviceSettings, client ChainBackend, label string, msg ethereum.CallMsg, explicitGas uint64, policy FeePolicy, contractABI string) (SimulationResult, error) {
+	result := SimulationResult{Label: label}
+
+	if _, err := client.PendingCallContract(ctx, msg); err != nil {
+		data, ok := revertDataFromError(err)
+		if !ok {
+			// not a revert, e.g. the node is unreachable
+			return result, fmt.Errorf("failed to simulate %s: %v", label, err)
+		}
+		result.RevertReason = DecodeRevertReason(data, contractABI)
+		if result.RevertReason == "" {
+			result.RevertReason = "reverted without a reason"
+		}
+		return result, nil
+	}
+
+	gasLimit, err := estimateGasLimit(ctx, client, msg, explicitGas, cfg.gasMultiplier)
+	if err != nil {
+		return result, err
+	}
+	fees, err := suggestFees(ctx, client, cfg.feePolicy.merge(policy))
+	if err != nil {
+		return result, err
+	}
+
+	feeCap := fees.GasPrice
+	if feeCap == nil {
+		feeCap = fees.GasFeeCap
+	}
+	result.Success = true
+	result.GasLimit = gasLimit
+	result.MaxFee = new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), feeCap)
+	return result, nil
+}
+
+// SimulateDeploy dry-runs DeployContract from the signer address without signing or sending
+func (s *NftService) SimulateDeploy(ctx context.Context, params DeployContractParams) (result SimulationResult, err error) {
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	signer, err := s.getSigner()
+	if err != nil {
+		return result, err
+	}
+	fromAddress := signer.Address()
+
+	msg, params, err := packDeployment(fromAddress, params)
+	if err != nil {
+		return result, err
+	}
+
+	simCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancel()
+	return s.simulate(simCtx, cfg, client, "deploy", msg, params.GasLimit, FeePolicy{
+		MaxFeePerGas:         params.MaxFeePerGas,
+		MaxPriorityFeePerGas: params.MaxPriorityFeePerGas,
+	}, params.ConstructorABI)
+}
+
+// SimulateContractCall dry-runs CallContractFunction from the signer address without signing or sending
+func (s *NftService) SimulateContractCall(ctx context.Context, label string, params ContractCallParams) (result SimulationResult, err error) {
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	signer, err := s.getSigner()
+	if err != nil {
+		return result, err
+	}
+	fromAddress := signer.Address()
+
+	msg, err := packContractCall(fromAddress, params)
+	if err != nil {
+		return result, err
+	}
+
+	simCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	defer cancel()
+	return s.simulate(simCtx, cfg, client, label, msg, params.GasLimit, FeePolicy{
+		MaxFeePerGas:         params.MaxFeePerGas,
+		MaxPriorityFeePerGas: params.MaxPriorityFeePerGas,
+	}, params.ContractABI)
+}
+
+// SimulateAirdrop dry-runs every transaction RunAirdrop would send for these inputs: setURI
+// when uri is not empty and one mintToMultple per batch. Each one is simulated on its own
+// against the current pending state, nothing is signed, sent or journaled.
+func (s *NftService) SimulateAirdrop(ctx context.Context, contractAddr string, addresses []string, nftID string, uri string) ([]SimulationResult, error) {
+	var results []SimulationResult
+
+	if uri != "" {
+		result, err := s.SimulateContractCall(ctx, "setURI", SetURIParams(contractAddr, uri))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	calls, err := s.mintBatches(ctx, contractAddr, addresses, nftID)
+	if err != nil {
+		return nil, err
+	}
+	for i, params := range calls {
+		result, err := s.SimulateContractCall(ctx, fmt.Sprintf("mintToMultple %d/%d", i+1, len(calls)), params)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// SimulateJournal dry-runs what RunAirdrop would still send for journal: setURI when it is
+// not set yet and one mintToMultple per batch that is not mined. Nothing is signed, sent
+// or written to the journal.
+func (s *NftService) SimulateJournal(ctx context.Context, journal *AirdropJournal) ([]SimulationResult, error) {
+	if err := s.checkJournalChain(ctx, journal); err != nil {
+		return nil, err
+	}
+
+	var results []SimulationResult
+	if !journal.URISet {
+		result, err := s.SimulateContractCall(ctx, "setURI", SetURIParams(journal.ContractAddress, journal.URI))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	for i, batch := range journal.Batches {
+		if batch.State == BatchMined {
+			continue
+		}
+		params, err := mintParams(journal.ContractAddress, batch.Recipients, journal.NFTID)
+		if err != nil {
+			return nil, err
+		}
+		result, err := s.SimulateContractCall(ctx, fmt.Sprintf("mintToMultple %d/%d", i+1, len(journal.Batches)), params)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
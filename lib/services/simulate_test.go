@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func (s *NftServiceTestSuite) TestSimulateAirdrop() {
+	contractAddress := s.deployContract()
+	ctx := context.Background()
+
+	nonce, err := s.Client.PendingNonceAt(ctx, common.HexToAddress(HardhatAddress))
+	s.Require().NoError(err)
+
+	recipients := make([]string, 60)
+	for i := range recipients {
+		recipients[i] = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+	}
+	results, err := s.NftService.SimulateAirdrop(ctx, contractAddress, recipients, "1", "https://api.example.com/dry/{id}")
+	s.Require().NoError(err)
+
+	// setURI plus one mint per batch of at most 50
+	s.Require().Len(results, 3)
+	s.Require().Equal("setURI", results[0].Label)
+	s.Require().Equal("mintToMultple 2/2", results[2].Label)
+	for _, result := range results {
+		s.Require().True(result.Success, result.RevertReason)
+		s.Require().NotZero(result.GasLimit)
+		s.Require().Positive(result.MaxFee.Sign())
+	}
+
+	// nothing was signed or sent
+	after, err := s.Client.PendingNonceAt(ctx, common.HexToAddress(HardhatAddress))
+	s.Require().NoError(err)
+	s.Require().Equal(nonce, after)
+	s.Require().Zero(s.balanceOf(contractAddress, recipients[0], 1).Int64())
+}
+
+func (s *NftServiceTestSuite) TestSimulateJournal() {
+	contractAddress := s.deployContract()
+	ctx := context.Background()
+
+	recipients := make([]string, 60)
+	for i := range recipients {
+		recipients[i] = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+	}
+	journal, err := s.NftService.PlanAirdrop(ctx, "", contractAddress, recipients, "1", "https://api.example.com/dry/{id}")
+	s.Require().NoError(err)
+	s.Require().Len(journal.Batches, 2)
+
+	// an interrupted run set the URI and mined the first batch
+	journal.URISet = true
+	journal.Batches[0].State = BatchMined
+
+	nonce, err := s.Client.PendingNonceAt(ctx, common.HexToAddress(HardhatAddress))
+	s.Require().NoError(err)
+
+	results, err := s.NftService.SimulateJournal(ctx, journal)
+	s.Require().NoError(err)
+	s.Require().Len(results, 1)
+	s.Require().Equal("mintToMultple 2/2", results[0].Label)
+	s.Require().True(results[0].Success, results[0].RevertReason)
+
+	// nothing was signed or sent
+	after, err := s.Client.PendingNonceAt(ctx, common.HexToAddress(HardhatAddress))
+	s.Require().NoError(err)
+	s.Require().Equal(nonce, after)
+	s.Require().Equal(BatchPlanned, journal.Batches[1].State)
+}
+
+func (s *NftServiceTestSuite) TestSimulateReportsRevertReason() {
+	contractAddress := s.deployContract()
+	ctx := context.Background()
+
+	other := NewNftServiceWithBackend(&autoCommitBackend{Client: s.Client, sim: s.Backend}, OtherPrivateKey)
+	result, err := other.SimulateContractCall(ctx, "setURI", SetURIParams(contractAddress, "https://evil.example.com/{id}"))
+	s.Require().NoError(err)
+	s.Require().False(result.Success)
+	s.Require().Contains(result.RevertReason, "OwnableUnauthorizedAccount")
+	s.Require().Contains(result.RevertReason, OtherAddress)
+	s.Require().Zero(result.GasLimit)
+
+	deploy, err := s.NftService.SimulateDeploy(ctx, DeployContractParams{
+		Bytecode:   s.AbiInfo.Bytecode,
+		InitialURI: "https://api.example.com/init/{id}",
+	})
+	s.Require().NoError(err)
+	s.Require().True(deploy.Success, deploy.RevertReason)
+	s.Require().NotZero(deploy.GasLimit)
+}
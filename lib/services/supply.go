@@ -0,0 +1,404 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultSupplyCacheDir is where the per-contract log scan cursors are cached
+const DefaultSupplyCacheDir = "supply_cache"
+
+// supplyScanChunk is the number of blocks requested per eth_getLogs call,
+// halved on errors since many nodes cap the range or the number of results
+const supplyScanChunk = 5000
+
+// supplyConfirmations is how far below the head a block has to be before its logs are
+// cached, newer blocks can still be reorged away and are read again on every scan
+const supplyConfirmations = 12
+
+// transferEventsABI holds the ERC1155 transfer events, mints come from and burns go to the zero address
+const transferEventsABI = `[
+	{"type": "event", "name": "TransferSingle", "anonymous": false, "inputs": [
+		{"name": "operator", "type": "address", "indexed": true},
+		{"name": "from", "type": "address", "indexed": true},
+		{"name": "to", "type": "address", "indexed": true},
+		{"name": "id", "type": "uint256", "indexed": false},
+		{"name": "value", "type": "uint256", "indexed": false}
+	]},
+	{"type": "event", "name": "TransferBatch", "anonymous": false, "inputs": [
+		{"name": "operator", "type": "address", "indexed": true},
+		{"name": "from", "type": "address", "indexed": true},
+		{"name": "to", "type": "address", "indexed": true},
+		{"name": "ids", "type": "uint256[]", "indexed": false},
+		{"name": "values", "type": "uint256[]", "indexed": false}
+	]}
+]`
+
+// TokenSupply is the supply of one token ID
+type TokenSupply struct {
+	TokenID *big.Int
+	Minted  *big.Int
+	Burned  *big.Int
+	// Holders is the number of accounts holding a positive balance
+	Holders int
+}
+
+// Circulating returns minted minus burned
+func (t TokenSupply) Circulating() *big.Int {
+	return new(big.Int).Sub(t.Minted, t.Burned)
+}
+
+// SupplyReport summarizes the transfer logs of a contract up to ScannedTo
+type SupplyReport struct {
+	ContractAddress string
+	ScannedTo       uint64
+	UpdatedAt       time.Time
+	// Tokens are sorted by token ID
+	Tokens []TokenSupply
+	// UniqueHolders counts accounts holding a positive balance of any token ID
+	UniqueHolders int
+}
+
+// supplyCache is the scan state of one contract, amounts are decimal strings
+type supplyCache struct {
+	ContractAddress string `json:"contractAddress"`
+	ChainID         string `json:"chainId"`
+	// NextBlock is the first block not scanned yet
+	NextBlock uint64                       `json:"nextBlock"`
+	Minted    map[string]string            `json:"minted"`
+	Burned    map[string]string            `json:"burned"`
+	Balances  map[string]map[string]string `json:"balances"`
+	UpdatedAt time.Time                    `json:"updatedAt"`
+}
+
+// supplyCachePath returns the cache file of contractAddr in cacheDir
+func supplyCachePath(cacheDir string, contractAddr string) string {
+	return filepath.Join(cacheDir, strings.ToLower(common.HexToAddress(contractAddr).Hex())+".json")
+}
+
+// loadSupplyCache reads the cached scan of contractAddr, a missing file starts a new scan
+func loadSupplyCache(cacheDir string, contractAddr string) (*supplyCache, error) {
+	data, err := os.ReadFile(supplyCachePath(cacheDir, contractAddr))
+	if errors.Is(err, os.ErrNotExist) {
+		return newSupplyCache(contractAddr, "", 0), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read supply cache: %v", err)
+	}
+
+	cache := &supplyCache{}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse supply cache: %v", err)
+	}
+	return cache, nil
+}
+
+func newSupplyCache(contractAddr string, chainID string, fromBlock uint64) *supplyCache {
+	return &supplyCache{
+		ContractAddress: common.HexToAddress(contractAddr).Hex(),
+		ChainID:         chainID,
+		NextBlock:       fromBlock,
+		Minted:          map[string]string{},
+		Burned:          map[string]string{},
+		Balances:        map[string]map[string]string{},
+	}
+}
+
+// save writes the cache atomically so an interrupted write keeps the old cursor
+func (c *supplyCache) save(cacheDir string) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create supply cache directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode supply cache: %v", err)
+	}
+	if err := writeFileAtomic(supplyCachePath(cacheDir, c.ContractAddress), data); err != nil {
+		return fmt.Errorf("failed to write supply cache: %v", err)
+	}
+	return nil
+}
+
+// clone returns a deep copy of the cache
+func (c *supplyCache) clone() *supplyCache {
+	copied := *c
+	copied.Minted = maps.Clone(c.Minted)
+	copied.Burned = maps.Clone(c.Burned)
+	copied.Balances = make(map[string]map[string]string, len(c.Balances))
+	for account, balances := range c.Balances {
+		copied.Balances[account] = maps.Clone(balances)
+	}
+	return &copied
+}
+
+// addAmount adds delta to the decimal amount stored at m[key], dropping zero amounts
+func addAmount(m map[string]string, key string, delta *big.Int) {
+	amount, ok := new(big.Int).SetString(m[key], 10)
+	if !ok {
+		amount = new(big.Int)
+	}
+	amount.Add(amount, delta)
+	if amount.Sign() == 0 {
+		delete(m, key)
+		return
+	}
+	m[key] = amount.String()
+}
+
+// applyTransfer books value of token id moving from -> to
+func (c *supplyCache) applyTransfer(from common.Address, to common.Address, id *big.Int, value *big.Int) {
+	tokenID := id.String()
+	if from == (common.Address{}) {
+		addAmount(c.Minted, tokenID, value)
+	} else {
+		addAmount(c.balancesOf(from), tokenID, new(big.Int).Neg(value))
+	}
+	if to == (common.Address{}) {
+		addAmount(c.Burned, tokenID, value)
+	} else {
+		addAmount(c.balancesOf(to), tokenID, value)
+	}
+}
+
+func (c *supplyCache) balancesOf(account common.Address) map[string]string {
+	key := account.Hex()
+	if c.Balances[key] == nil {
+		c.Balances[key] = map[string]string{}
+	}
+	return c.Balances[key]
+}
+
+// report turns the cached totals into a SupplyReport
+func (c *supplyCache) report() *SupplyReport {
+	report := &SupplyReport{
+		ContractAddress: c.ContractAddress,
+		UpdatedAt:       c.UpdatedAt,
+	}
+	if c.NextBlock > 0 {
+		report.ScannedTo = c.NextBlock - 1
+	}
+
+	holders := map[string]int{}
+	for _, balances := range c.Balances {
+		held := false
+		for tokenID, amount := range balances {
+			if amount != "0" && !strings.HasPrefix(amount, "-") {
+				holders[tokenID]++
+				held = true
+			}
+		}
+		if held {
+			report.UniqueHolders++
+		}
+	}
+
+	tokenIDs := map[string]bool{}
+	for tokenID := range c.Minted {
+		tokenIDs[tokenID] = true
+	}
+	for tokenID := range c.Burned {
+		tokenIDs[tokenID] = true
+	}
+	for tokenID := range tokenIDs {
+		id, _ := new(big.Int).SetString(tokenID, 10)
+		minted, ok := new(big.Int).SetString(c.Minted[tokenID], 10)
+		if !ok {
+			minted = new(big.Int)
+		}
+		burned, ok := new(big.Int).SetString(c.Burned[tokenID], 10)
+		if !ok {
+			burned = new(big.Int)
+		}
+		report.Tokens = append(report.Tokens, TokenSupply{
+			TokenID: id,
+			Minted:  minted,
+			Burned:  burned,
+			Holders: holders[tokenID],
+		})
+	}
+	sort.Slice(report.Tokens, func(i, j int) bool {
+		return report.Tokens[i].TokenID.Cmp(report.Tokens[j].TokenID) < 0
+	})
+	return report
+}
+
+// CachedSupply returns the last scanned supply of contractAddr without touching the node,
+// nil when the contract was never scanned
+func CachedSupply(cacheDir string, contractAddr string) (*SupplyReport, error) {
+	cache, err := loadSupplyCache(cacheDir, contractAddr)
+	if err != nil {
+		return nil, err
+	}
+	if cache.UpdatedAt.IsZero() {
+		return nil, nil
+	}
+	return cache.report(), nil
+}
+
+// ScanSupply scans the TransferSingle and TransferBatch logs of contractAddr and returns
+// the minted, burned and circulating supply per token ID up to the head. The scan resumes
+// from the cursor cached in cacheDir, the first scan starts at deployBlock. Only blocks at
+// least supplyConfirmations deep are cached, the cursor is saved after every chunk of them
+// so an interrupted scan keeps its progress, the newer blocks are read again every time.
+func (s *NftService) ScanSupply(ctx context.Context, cacheDir string, contractAddr string, deployBlock uint64) (report *SupplyReport, err error) {
+	cfg := s.settings()
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			s.markUnhealthy()
+		}
+	}()
+
+	callCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+	chainID, err := client.ChainID(callCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	head, err := client.HeaderByNumber(callCtx, nil)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := loadSupplyCache(cacheDir, contractAddr)
+	if err != nil {
+		return nil, err
+	}
+	// a cache from another chain or from before the deployment is useless, start over
+	if cache.ChainID != chainID.String() || cache.NextBlock < deployBlock {
+		cache = newSupplyCache(contractAddr, chainID.String(), deployBlock)
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(transferEventsABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transfer events ABI: %v", err)
+	}
+
+	latest := head.Number.Uint64()
+	if latest >= supplyConfirmations {
+		err := s.scanTransferLogs(ctx, cfg, client, parsedABI, cache, latest-supplyConfirmations, func() error {
+			cache.UpdatedAt = time.Now()
+			return cache.save(cacheDir)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// nothing confirmed since the last scan
+	if cache.UpdatedAt.IsZero() {
+		cache.UpdatedAt = time.Now()
+		if err := cache.save(cacheDir); err != nil {
+			return nil, err
+		}
+	}
+
+	// the unconfirmed blocks are counted on a copy that is never saved
+	recent := cache.clone()
+	if err := s.scanTransferLogs(ctx, cfg, client, parsedABI, recent, latest, nil); err != nil {
+		return nil, err
+	}
+	return recent.report(), nil
+}
+
+// scanTransferLogs applies the transfer logs from the cursor of cache up to block to,
+// moving the cursor and calling afterChunk after every chunk of blocks
+func (s *NftService) scanTransferLogs(ctx context.Context, cfg serviceSettings, client ChainBackend, parsedABI abi.ABI, cache *supplyCache, to uint64, afterChunk func() error) error {
+	contractAddress := common.HexToAddress(cache.ContractAddress)
+	topics := [][]common.Hash{{
+		parsedABI.Events["TransferSingle"].ID,
+		parsedABI.Events["TransferBatch"].ID,
+	}}
+
+	chunk := uint64(supplyScanChunk)
+	for cache.NextBlock <= to {
+		chunkEnd := min(cache.NextBlock+chunk-1, to)
+
+		callCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Send)
+		logs, err := client.FilterLogs(callCtx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(cache.NextBlock),
+			ToBlock:   new(big.Int).SetUint64(chunkEnd),
+			Addresses: []common.Address{contractAddress},
+			Topics:    topics,
+		})
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && chunk > 1 {
+				chunk /= 2
+				continue
+			}
+			return fmt.Errorf("failed to read logs from block %d: %v", cache.NextBlock, err)
+		}
+		// grow back after a transient error shrank the range
+		chunk = min(chunk*2, supplyScanChunk)
+
+		for _, log := range logs {
+			// the block of a removed log was reorged away
+			if log.Removed {
+				continue
+			}
+			if err := applyTransferLog(cache, parsedABI, log); err != nil {
+				return err
+			}
+		}
+
+		cache.NextBlock = chunkEnd + 1
+		if afterChunk != nil {
+			if err := afterChunk(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyTransferLog decodes a TransferSingle or TransferBatch log into the cache
+func applyTransferLog(cache *supplyCache, parsedABI abi.ABI, log types.Log) error {
+	if len(log.Topics) != 4 {
+		return nil
+	}
+	from := common.BytesToAddress(log.Topics[2].Bytes())
+	to := common.BytesToAddress(log.Topics[3].Bytes())
+
+	switch log.Topics[0] {
+	case parsedABI.Events["TransferSingle"].ID:
+		values, err := parsedABI.Unpack("TransferSingle", log.Data)
+		if err != nil {
+			return fmt.Errorf("failed to decode TransferSingle in tx %s: %v", log.TxHash.Hex(), err)
+		}
+		cache.applyTransfer(from, to, values[0].(*big.Int), values[1].(*big.Int))
+
+	case parsedABI.Events["TransferBatch"].ID:
+		values, err := parsedABI.Unpack("TransferBatch", log.Data)
+		if err != nil {
+			return fmt.Errorf("failed to decode TransferBatch in tx %s: %v", log.TxHash.Hex(), err)
+		}
+		ids, amounts := values[0].([]*big.Int), values[1].([]*big.Int)
+		if len(ids) != len(amounts) {
+			return fmt.Errorf("malformed TransferBatch in tx %s", log.TxHash.Hex())
+		}
+		for i := range ids {
+			cache.applyTransfer(from, to, ids[i], amounts[i])
+		}
+	}
+	return nil
+}
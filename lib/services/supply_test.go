@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// supplyOf returns the supply of tokenID in report
+func supplyOf(report *SupplyReport, tokenID int64) TokenSupply {
+	for _, token := range report.Tokens {
+		if token.TokenID.Int64() == tokenID {
+			return token
+		}
+	}
+	return TokenSupply{}
+}
+
+func (s *NftServiceTestSuite) TestScanSupply() {
+	ctx := context.Background()
+	cacheDir := s.T().TempDir()
+
+	deployed, err := s.NftService.DeployContract(ctx, DeployContractParams{
+		Bytecode:   s.AbiInfo.Bytecode,
+		InitialURI: "https://api.example.com/init/{id}",
+	})
+	s.Require().NoError(err)
+	contractAddress := deployed.ContractAddress
+
+	recipients := []string{
+		"0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65",
+		"0x9965507D1a55bcC2695C58ba16FB37d819B0A4dc",
+		"0x976EA74026E726554dB657fA54763abd0C3a0aa9",
+	}
+	_, err = s.NftService.MintNFTToAddresses(ctx, contractAddress, recipients, "1", nil)
+	s.Require().NoError(err)
+
+	// a TransferBatch mint followed by a transfer that empties one balance
+	_, err = s.NftService.CallContractFunction(ctx, ContractCallParams{
+		ContractAddress: contractAddress,
+		ContractABI:     s.AbiJSON,
+		FunctionName:    "mintBatch",
+		FunctionArgs: []any{
+			common.HexToAddress(HardhatAddress),
+			[]*big.Int{big.NewInt(1), big.NewInt(2)},
+			[]*big.Int{big.NewInt(5), big.NewInt(7)},
+			[]byte{},
+		},
+	})
+	s.Require().NoError(err)
+	_, err = s.NftService.CallContractFunction(ctx, ContractCallParams{
+		ContractAddress: contractAddress,
+		ContractABI:     s.AbiJSON,
+		FunctionName:    "safeTransferFrom",
+		FunctionArgs: []any{
+			common.HexToAddress(HardhatAddress),
+			common.HexToAddress(OtherAddress),
+			big.NewInt(2),
+			big.NewInt(7),
+			[]byte{},
+		},
+	})
+	s.Require().NoError(err)
+
+	report, err := s.NftService.ScanSupply(ctx, cacheDir, contractAddress, deployed.BlockNumber)
+	s.Require().NoError(err)
+	s.Require().Len(report.Tokens, 2)
+
+	token1 := supplyOf(report, 1)
+	s.Require().Equal(int64(8), token1.Minted.Int64())
+	s.Require().Equal(int64(0), token1.Burned.Int64())
+	s.Require().Equal(int64(8), token1.Circulating().Int64())
+	s.Require().Equal(4, token1.Holders)
+
+	token2 := supplyOf(report, 2)
+	s.Require().Equal(int64(7), token2.Minted.Int64())
+	s.Require().Equal(1, token2.Holders)
+
+	s.Require().Equal(5, report.UniqueHolders)
+
+	// the next scan only reads the new blocks and adds to the cached totals
+	_, err = s.NftService.MintNFTToAddresses(ctx, contractAddress, recipients[:1], "2", nil)
+	s.Require().NoError(err)
+
+	rescanned, err := s.NftService.ScanSupply(ctx, cacheDir, contractAddress, deployed.BlockNumber)
+	s.Require().NoError(err)
+	s.Require().Greater(rescanned.ScannedTo, report.ScannedTo)
+	s.Require().Equal(int64(8), supplyOf(rescanned, 1).Minted.Int64())
+	s.Require().Equal(int64(8), supplyOf(rescanned, 2).Minted.Int64())
+	s.Require().Equal(2, supplyOf(rescanned, 2).Holders)
+	s.Require().Equal(5, rescanned.UniqueHolders)
+
+	// the newest blocks are not cached until they are supplyConfirmations deep
+	cached, err := CachedSupply(cacheDir, contractAddress)
+	s.Require().NoError(err)
+	s.Require().Less(cached.ScannedTo, rescanned.ScannedTo)
+	s.Require().Empty(cached.Tokens)
+
+	for range supplyConfirmations {
+		s.Backend.Commit()
+	}
+	confirmed, err := s.NftService.ScanSupply(ctx, cacheDir, contractAddress, deployed.BlockNumber)
+	s.Require().NoError(err)
+	s.Require().Equal(rescanned.ScannedTo+supplyConfirmations, confirmed.ScannedTo)
+
+	cached, err = CachedSupply(cacheDir, contractAddress)
+	s.Require().NoError(err)
+	s.Require().Equal(rescanned.ScannedTo, cached.ScannedTo)
+	s.Require().Equal(rescanned.UniqueHolders, cached.UniqueHolders)
+	s.Require().Equal(int64(8), supplyOf(cached, 2).Minted.Int64())
+}
+
+func TestSupplyCountsBurns(t *testing.T) {
+	holder := common.HexToAddress(OtherAddress)
+	cache := newSupplyCache(HardhatAddress, "1", 0)
+
+	cache.applyTransfer(common.Address{}, holder, big.NewInt(3), big.NewInt(10))
+	cache.applyTransfer(holder, common.Address{}, big.NewInt(3), big.NewInt(4))
+	cache.applyTransfer(holder, common.Address{}, big.NewInt(3), big.NewInt(6))
+
+	report := cache.report()
+	require.Len(t, report.Tokens, 1)
+	require.Equal(t, int64(10), report.Tokens[0].Minted.Int64())
+	require.Equal(t, int64(10), report.Tokens[0].Burned.Int64())
+	require.Equal(t, int64(0), report.Tokens[0].Circulating().Int64())
+	require.Equal(t, 0, report.Tokens[0].Holders)
+	require.Equal(t, 0, report.UniqueHolders)
+}
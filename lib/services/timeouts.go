@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Timeouts bounds how long NftService waits on the node.
+// Each one applies on top of the deadline of the context passed by the caller.
+type Timeouts struct {
+	// Dial bounds connecting to (and health checking) the RPC endpoint
+	Dial time.Duration
+	// Send bounds the RPCs needed to build a transaction, and again its broadcast
+	Send time.Duration
+	// Sign bounds waiting for the signer, a remote signer like Clef may wait for a human
+	// to approve each transaction
+	Sign time.Duration
+	// Mine bounds waiting for a broadcast transaction to be mined
+	Mine time.Duration
+}
+
+// DefaultTimeouts are used until SetTimeouts is called
+var DefaultTimeouts = Timeouts{
+	Dial: 10 * time.Second,
+	Send: 30 * time.Second,
+	Sign: 5 * time.Minute,
+	Mine: 5 * time.Minute,
+}
+
+// withDefaults fills zero durations from DefaultTimeouts
+func (t Timeouts) withDefaults() Timeouts {
+	if t.Dial <= 0 {
+		t.Dial = DefaultTimeouts.Dial
+	}
+	if t.Send <= 0 {
+		t.Send = DefaultTimeouts.Send
+	}
+	if t.Sign <= 0 {
+		t.Sign = DefaultTimeouts.Sign
+	}
+	if t.Mine <= 0 {
+		t.Mine = DefaultTimeouts.Mine
+	}
+	return t
+}
+
+// TxPendingError is returned when waiting for a broadcast transaction stops before it is
+// mined, because the caller cancelled or the mining timeout expired. The transaction
+// was sent and may still be mined later.
+type TxPendingError struct {
+	TxHash string
+	Err    error
+}
+
+func (e *TxPendingError) Error() string {
+	if errors.Is(e.Err, context.Canceled) {
+		return fmt.Sprintf("cancelled, transaction may still be pending: %s", e.TxHash)
+	}
+	return fmt.Sprintf("timed out waiting to be mined, transaction may still be pending: %s", e.TxHash)
+}
+
+func (e *TxPendingError) Unwrap() error {
+	return e.Err
+}
+
+// signTx signs tx within the signing timeout, separate from the send timeout so waiting
+// for an approval never eats into the RPCs around it. Cancelling ctx stops the wait.
+func (s *NftService) signTx(ctx context.Context, cfg serviceSettings, signer Signer, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Sign)
+	defer cancel()
+	return signer.SignTx(signCtx, tx, chainID)
+}
+
+// waitMined waits for tx to be mined within the mining timeout. A tracked transaction
+// also ends the wait when one of its replacements is mined.
+func (s *NftService) waitMined(ctx context.Context, cfg serviceSettings, client ChainBackend, tx *types.Transaction) (*types.Receipt, error) {
+	mineCtx, cancel := context.WithTimeout(ctx, cfg.timeouts.Mine)
+	defer cancel()
+
+	var receipt *types.Receipt
+	var err error
+	if cfg.tracker != nil {
+		receipt, err = s.waitTracked(mineCtx, cfg, client, tx)
+	} else {
+		receipt, err = bind.WaitMined(mineCtx, client, tx)
+	}
+	if err != nil {
+		if mineCtx.Err() != nil {
+			return nil, &TxPendingError{TxHash: tx.Hash().Hex(), Err: mineCtx.Err()}
+		}
+		return nil, err
+	}
+	return receipt, nil
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultTxTrackerPath is where the app records the transactions it broadcasts
+const DefaultTxTrackerPath = "pending_transactions.json"
+
+// trackerHistory is how many settled transactions are kept, older ones are removed
+const trackerHistory = 100
+
+// TrackedTxState is how far a tracked transaction got
+type TrackedTxState string
+
+const (
+	// TrackedPending transactions were broadcast and none of their attempts is mined yet
+	TrackedPending TrackedTxState = "pending"
+	// TrackedMined transactions had the original or a speed-up mined
+	TrackedMined TrackedTxState = "mined"
+	// TrackedCancelled transactions had their cancellation mined, the call never ran
+	TrackedCancelled TrackedTxState = "cancelled"
+	// TrackedDropped transactions lost their nonce to a transaction sent elsewhere
+	TrackedDropped TrackedTxState = "dropped"
+)
+
+// TxAttemptKind tells the original transaction from its replacements
+type TxAttemptKind string
+
+const (
+	AttemptOriginal TxAttemptKind = "original"
+	AttemptSpeedUp  TxAttemptKind = "speed-up"
+	AttemptCancel   TxAttemptKind = "cancel"
+)
+
+// TxAttempt is one signed transaction at the nonce of a tracked transaction. Fees are
+// decimal wei, GasPrice for legacy transactions and the two caps for dynamic-fee ones.
+type TxAttempt struct {
+	Hash      string        `json:"hash"`
+	Kind      TxAttemptKind `json:"kind"`
+	GasPrice  string        `json:"gasPrice,omitempty"`
+	GasTipCap string        `json:"gasTipCap,omitempty"`
+	GasFeeCap string        `json:"gasFeeCap,omitempty"`
+	// RawTx is the signed transaction, a replacement copies its call from the latest attempt
+	RawTx  string    `json:"rawTx"`
+	SentAt time.Time `json:"sentAt"`
+}
+
+// TrackedTx is a broadcast transaction with every replacement sent at its nonce
+type TrackedTx struct {
+	ChainID uint64 `json:"chainId"`
+	From    string `json:"from"`
+	Nonce   uint64 `json:"nonce"`
+	// Label names the transaction, e.g. "deploy" or "mintToMultple"
+	Label string         `json:"label"`
+	State TrackedTxState `json:"state"`
+	// MinedHash is the attempt that was mined, the cancellation for TrackedCancelled
+	MinedHash string      `json:"minedHash,omitempty"`
+	Attempts  []TxAttempt `json:"attempts"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// Hash returns the hash of the original transaction
+func (t TrackedTx) Hash() string {
+	return t.Attempts[0].Hash
+}
+
+// Latest returns the attempt sent last, the one the node keeps in its pool
+func (t TrackedTx) Latest() TxAttempt {
+	return t.Attempts[len(t.Attempts)-1]
+}
+
+// Cancelling reports whether the latest attempt is a cancellation
+func (t TrackedTx) Cancelling() bool {
+	return t.Latest().Kind == AttemptCancel
+}
+
+// has reports whether one of the attempts has hash
+func (t TrackedTx) has(hash string) bool {
+	return slices.ContainsFunc(t.Attempts, func(attempt TxAttempt) bool {
+		return strings.EqualFold(attempt.Hash, hash)
+	})
+}
+
+// trackedTxs is the layout of the tracker file
+type trackedTxs struct {
+	Transactions []TrackedTx `json:"transactions"`
+}
+
+// TxTracker records every transaction the service broadcasts with its nonce and fees in a
+// file shared by the TUI, the commands and the server, so a stuck transaction can be sped
+// up or cancelled from any of them and a wait in one follows a replacement sent by another
+type TxTracker struct {
+	path string
+}
+
+// NewTxTracker creates a tracker saving to path
+func NewTxTracker(path string) *TxTracker {
+	return &TxTracker{path: path}
+}
+
+// List returns every tracked transaction, oldest first
+func (t *TxTracker) List() ([]TrackedTx, error) {
+	return t.read()
+}
+
+// Pending returns the transactions on chainID that are not settled yet, oldest first.
+// A chainID of 0 returns those of every chain.
+func (t *TxTracker) Pending(chainID uint64) ([]TrackedTx, error) {
+	txs, err := t.read()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(txs, func(tx TrackedTx) bool {
+		return tx.State != TrackedPending || (chainID != 0 && tx.ChainID != chainID)
+	}), nil
+}
+
+// Find returns the transaction with an attempt of hash, nil when it is not tracked
+func (t *TxTracker) Find(hash string) (*TrackedTx, error) {
+	txs, err := t.read()
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range txs {
+		if tx.has(hash) {
+			return &tx, nil
+		}
+	}
+	return nil, nil
+}
+
+// record adds tx as an attempt sent from from, a new pending transaction unless it
+// replaces one. A transaction recorded before is left as it is.
+func (t *TxTracker) record(label string, from common.Address, tx *types.Transaction, kind TxAttemptKind) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	attempt := TxAttempt{
+		Hash:   tx.Hash().Hex(),
+		Kind:   kind,
+		RawTx:  hexutil.Encode(raw),
+		SentAt: now,
+	}
+	if tx.Type() == types.LegacyTxType {
+		attempt.GasPrice = tx.GasPrice().String()
+	} else {
+		attempt.GasTipCap, attempt.GasFeeCap = tx.GasTipCap().String(), tx.GasFeeCap().String()
+	}
+
+	return t.update(func(txs []TrackedTx) ([]TrackedTx, error) {
+		for i := range txs {
+			if txs[i].has(attempt.Hash) {
+				return txs, nil
+			}
+			if txs[i].State == TrackedPending && txs[i].ChainID == tx.ChainId().Uint64() &&
+				strings.EqualFold(txs[i].From, from.Hex()) && txs[i].Nonce == tx.Nonce() {
+				txs[i].Attempts = append(txs[i].Attempts, attempt)
+				txs[i].UpdatedAt = now
+				return txs, nil
+			}
+		}
+		return append(txs, TrackedTx{
+			ChainID:   tx.ChainId().Uint64(),
+			From:      from.Hex(),
+			Nonce:     tx.Nonce(),
+			Label:     label,
+			State:     TrackedPending,
+			Attempts:  []TxAttempt{attempt},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}), nil
+	})
+}
+
+// forget removes the attempt of tx after the node refused it, and the transaction when it
+// was its only attempt
+func (t *TxTracker) forget(tx *types.Transaction) error {
+	hash := tx.Hash().Hex()
+	return t.update(func(txs []TrackedTx) ([]TrackedTx, error) {
+		for i := range txs {
+			if !txs[i].has(hash) {
+				continue
+			}
+			txs[i].Attempts = slices.DeleteFunc(txs[i].Attempts, func(attempt TxAttempt) bool {
+				return strings.EqualFold(attempt.Hash, hash)
+			})
+			if len(txs[i].Attempts) == 0 {
+				return slices.Delete(txs, i, i+1), nil
+			}
+			return txs, nil
+		}
+		return txs, nil
+	})
+}
+
+// settle records how the transaction with an attempt of hash ended
+func (t *TxTracker) settle(hash string, state TrackedTxState, minedHash string) error {
+	return t.update(func(txs []TrackedTx) ([]TrackedTx, error) {
+		for i := range txs {
+			if txs[i].has(hash) {
+				txs[i].State, txs[i].MinedHash, txs[i].UpdatedAt = state, minedHash, time.Now()
+				break
+			}
+		}
+		return txs, nil
+	})
+}
+
+// read reads the tracker file, a missing file tracks nothing
+func (t *TxTracker) read() ([]TrackedTx, error) {
+	data, err := os.ReadFile(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []TrackedTx{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read tracked transactions: %v", err)
+	}
+
+	var tracked trackedTxs
+	if err := json.Unmarshal(data, &tracked); err != nil {
+		return nil, fmt.Errorf("failed to parse tracked transactions: %v", err)
+	}
+	if tracked.Transactions == nil {
+		tracked.Transactions = []TrackedTx{}
+	}
+	return tracked.Transactions, nil
+}
+
+// update runs the read-modify-write of the tracker file under an exclusive lock like the
+// registry, so the TUI and a command running at the same time never lose each other's
+// records. Only the newest trackerHistory settled transactions are kept.
+func (t *TxTracker) update(modify func(txs []TrackedTx) ([]TrackedTx, error)) error {
+	unlock, err := lockFile(t.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock tracked transactions: %v", err)
+	}
+	defer unlock()
+
+	txs, err := t.read()
+	if err != nil {
+		return err
+	}
+	txs, err = modify(txs)
+	if err != nil {
+		return err
+	}
+
+	settled := 0
+	for i := len(txs) - 1; i >= 0; i-- {
+		if txs[i].State == TrackedPending {
+			continue
+		}
+		if settled++; settled > trackerHistory {
+			txs = slices.Delete(txs, i, i+1)
+		}
+	}
+
+	data, err := json.MarshalIndent(trackedTxs{Transactions: txs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tracked transactions: %v", err)
+	}
+	if err := writeFileAtomic(t.path, data); err != nil {
+		return fmt.Errorf("failed to write tracked transactions: %v", err)
+	}
+	return nil
+}
@@ -1,11 +1,14 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
 )
 
 // Logger represents a custom logger that writes to both file and stdout
@@ -44,6 +47,17 @@ func (l *Logger) Log(level, message string) {
 	l.logger.Printf("[%s] %s", level, message)
 }
 
+// LogResult writes result as one JSON line with the RESULT level, the same schema the
+// commands print with --output json
+func (l *Logger) LogResult(result services.Result) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		l.Log("ERROR", fmt.Sprintf("failed to encode result: %v", err))
+		return
+	}
+	l.Log("RESULT", string(data))
+}
+
 // Close closes the log file
 func (l *Logger) Close() error {
 	return l.file.Close()
@@ -1,13 +1,20 @@
 package types
 
-import "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+import (
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
 
 type ErrorMsg struct {
 	Err error
+	// Result is the failed operation for the log, nil when the error is not one
+	Result *services.Result
 }
 
 type SuccessMsg struct {
 	Message string
+	// Result is the completed operation for the log, nil when the message is not one
+	Result *services.Result
 }
 
 type ChangePageMsg struct {
@@ -32,6 +32,14 @@ type State struct {
 	TokenURI              string
 	NFTID                 string
 	SendNFTStat           bool
+	// DryRun simulates transactions instead of signing and sending them
+	DryRun bool
+	// Network is the name of the active network profile and ChainID the chain it pins,
+	// Mainnet marks profiles that move real funds and Explorer links their transactions
+	Network  string
+	ChainID  uint64
+	Mainnet  bool
+	Explorer string
 }
 
 var GlobalState = &State{}
@@ -41,4 +49,6 @@ var GlobalState = &State{}
 type ContractChoice struct {
 	Address    string
 	DeployTime string
+	// Label is the address with the contract name and chain marker shown in the list
+	Label string
 }
@@ -21,7 +21,7 @@ func SelectContractView(choices []types.ContractChoice, cursor int) string {
 			if cursor == i {
 				cursorChar = ">"
 			}
-			s += fmt.Sprintf("%s %s\n", cursorChar, choice.Address)
+			s += fmt.Sprintf("%s %s\n", cursorChar, choice.Label)
 			s += fmt.Sprintf("  部署时间: %s\n\n", choice.DeployTime)
 		}
 	}
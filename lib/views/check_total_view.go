@@ -10,7 +10,7 @@ import (
 )
 
 // CheckTotalView 渲染查看 NFT 总量页面
-func CheckTotalView(contracts []services.DeployedContract) string {
+func CheckTotalView(contracts []services.DeployedContract, reports []*services.SupplyReport, errs []error, scanning bool) string {
 	var sb strings.Builder
 
 	sb.WriteString(constant.DeployedContractPageTitle + "\n")
@@ -21,14 +21,46 @@ func CheckTotalView(contracts []services.DeployedContract) string {
 	} else {
 		for i, contract := range contracts {
 			sb.WriteString(fmt.Sprintf("合约 #%d:\n", i+1))
-			sb.WriteString(fmt.Sprintf("地址: %s\n", contract.Address))
+			sb.WriteString(fmt.Sprintf("地址: %s\n", ContractLabel(contract)))
 			sb.WriteString(fmt.Sprintf("部署时间: %s\n", contract.DeployTime.Format(time.RFC3339)))
+			sb.WriteString(supplySummary(reports[i], errs[i]))
 			sb.WriteString(string(constant.Separator) + "\n")
 		}
 	}
 
+	if scanning {
+		sb.WriteString("\n正在扫描链上转账记录，按 ESC 取消\n")
+		return sb.String()
+	}
+
+	sb.WriteString("\n按 Enter 扫描链上转账记录并刷新统计")
 	sb.WriteString("\n" + constant.BackToPrevious)
 	sb.WriteString("\n" + constant.ExitMessage + "\n")
 
 	return sb.String()
 }
+
+// supplySummary renders the supply of one contract
+func supplySummary(report *services.SupplyReport, err error) string {
+	var sb strings.Builder
+
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("统计失败: %v\n", err))
+	}
+	if report == nil {
+		sb.WriteString("尚未统计\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("统计至区块: %d（%s）\n", report.ScannedTo, report.UpdatedAt.Format("2006-01-02 15:04:05")))
+	sb.WriteString(fmt.Sprintf("持有人数: %d\n", report.UniqueHolders))
+	if len(report.Tokens) == 0 {
+		sb.WriteString("暂无铸造记录\n")
+		return sb.String()
+	}
+	for _, token := range report.Tokens {
+		sb.WriteString(fmt.Sprintf("  NFT #%s  铸造: %s  销毁: %s  流通: %s  持有人: %d\n",
+			token.TokenID, token.Minted, token.Burned, token.Circulating(), token.Holders))
+	}
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+)
+
+// ContractAdminView renders the contract admin page
+func ContractAdminView(model *models.ContractAdminModel, sending bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(constant.ContractAdminPageTitle + "\n")
+	sb.WriteString(string(constant.Separator) + "\n\n")
+	if types.GlobalState.DryRun {
+		sb.WriteString(constant.DryRunBanner + "\n\n")
+	}
+
+	if model.InputMode == constant.ContractSelectMode {
+		if len(model.Contracts) == 0 {
+			sb.WriteString(constant.NoDeployedContract + "\n")
+		} else {
+			sb.WriteString("选择要管理的合约\n\n")
+			for i, contract := range model.Contracts {
+				cursor := constant.CursorInactive
+				if model.Cursor == i {
+					cursor = constant.CursorActive
+				}
+				sb.WriteString(fmt.Sprintf("%s %s\n", cursor, ContractLabel(contract)))
+			}
+		}
+		sb.WriteString("\n" + constant.BackToMenuMessage + "\n")
+		sb.WriteString(constant.ExitMessage + "\n")
+		return sb.String()
+	}
+
+	// 所有权概览
+	sb.WriteString(fmt.Sprintf("合约地址: %s\n", model.ContractAddress))
+	if model.Owner != "" {
+		sb.WriteString(fmt.Sprintf("Owner: %s\n", model.Owner))
+		sb.WriteString(fmt.Sprintf("当前签名账户: %s\n", model.Signer))
+		if model.IsOwner() {
+			sb.WriteString("✔ 当前签名账户是 owner\n")
+		} else {
+			sb.WriteString("✘ 当前签名账户不是 owner，无法转移或放弃所有权\n")
+		}
+	}
+	sb.WriteString("\n")
+
+	switch model.InputMode {
+	case constant.AdminActionMode:
+		for i, choice := range constant.AdminActionChoices {
+			cursor := constant.CursorInactive
+			if model.ActionCursor == i {
+				cursor = constant.CursorActive
+			}
+			sb.WriteString(fmt.Sprintf("%s %s\n", cursor, choice))
+		}
+	case constant.NewOwnerInputMode:
+		sb.WriteString(constant.NewOwnerPrompt + "\n")
+		sb.WriteString(fmt.Sprintf("> %s%s\n", model.NewOwner, constant.InputCursor))
+	case constant.NewOwnerConfirmMode:
+		sb.WriteString(fmt.Sprintf("新 owner: %s\n", model.NewOwner))
+		sb.WriteString(constant.NewOwnerConfirmPrompt + "\n")
+		sb.WriteString(fmt.Sprintf("> %s%s\n", model.NewOwnerConfirm, constant.InputCursor))
+	case constant.RenounceConfirmMode:
+		sb.WriteString(fmt.Sprintf(constant.RenouncePrompt, constant.RenouncePhrase) + "\n")
+		sb.WriteString(fmt.Sprintf("> %s%s\n", model.Phrase, constant.InputCursor))
+	}
+
+	if len(model.Simulation) > 0 {
+		sb.WriteString("\n" + simulationReport(model.Simulation))
+	}
+
+	if sending {
+		sb.WriteString("\n" + constant.CancelInFlight + "\n")
+		return sb.String()
+	}
+	sb.WriteString("\n" + constant.BackToPrevious + "\n")
+	sb.WriteString(constant.ExitMessage + "\n")
+	return sb.String()
+}
@@ -0,0 +1,159 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+)
+
+// ContractCallView renders the call contract function page
+func ContractCallView(model *models.ContractCallModel, calling bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(constant.ContractCallPageTitle + "\n")
+	sb.WriteString(string(constant.Separator) + "\n\n")
+	if types.GlobalState.DryRun {
+		sb.WriteString(constant.DryRunBanner + "\n\n")
+	}
+
+	switch model.InputMode {
+	case constant.ContractSelectMode:
+		if len(model.Contracts) == 0 {
+			sb.WriteString(constant.NoDeployedContract + "\n")
+		} else {
+			sb.WriteString("选择要调用的合约\n\n")
+			for i, contract := range model.Contracts {
+				cursor := constant.CursorInactive
+				if model.Cursor == i {
+					cursor = constant.CursorActive
+				}
+				sb.WriteString(fmt.Sprintf("%s %s\n", cursor, ContractLabel(contract)))
+			}
+		}
+		sb.WriteString("\n" + constant.BackToMenuMessage + "\n")
+		sb.WriteString(constant.ExitMessage + "\n")
+		return sb.String()
+
+	case constant.FunctionSelectMode:
+		sb.WriteString(fmt.Sprintf("合约地址: %s\n\n", model.ContractAddress))
+		writeFunctionList(&sb, "只读函数：", model.Reads, 0, model.FunctionCursor)
+		writeFunctionList(&sb, "写入函数（会发送交易）：", model.Writes, len(model.Reads), model.FunctionCursor)
+		sb.WriteString(constant.EnterToContinue + "\n")
+		sb.WriteString(constant.BackToPrevious + "\n")
+		sb.WriteString(constant.ExitMessage + "\n")
+		return sb.String()
+	}
+
+	// 参数输入表单，每个参数一个输入框
+	method := model.SelectedFunction()
+	sb.WriteString(fmt.Sprintf("合约地址: %s\n", model.ContractAddress))
+	sb.WriteString(fmt.Sprintf("函数: %s\n\n", method.Sig))
+
+	if len(method.Inputs) == 0 && !method.IsPayable() {
+		sb.WriteString("该函数没有参数\n")
+	}
+	for i, input := range method.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("参数 %d", i+1)
+		}
+		sb.WriteString(inputField(fmt.Sprintf("%s (%s)", name, input.Type), model.Args[i], model.ArgIndex == i))
+		if model.ArgIndex == i {
+			if hint := inputHint(input.Type); hint != "" {
+				sb.WriteString("    " + hint + "\n")
+			}
+		}
+	}
+	if method.IsPayable() {
+		sb.WriteString(inputField("发送 ETH 数量 (wei，可选)", model.Value, model.ArgIndex == len(model.Args)))
+	}
+	sb.WriteString("\n")
+
+	// 调用结果
+	if len(model.Outputs) > 0 {
+		sb.WriteString("返回值：\n")
+		for _, output := range model.Outputs {
+			sb.WriteString(output + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	if model.TxHash != "" {
+		sb.WriteString(fmt.Sprintf("交易已上链，交易哈希: %s\n\n", txLink(model.TxHash)))
+	}
+	sb.WriteString(simulationReport(model.Simulation))
+
+	if calling {
+		sb.WriteString(constant.CancelInFlight + "\n")
+		return sb.String()
+	}
+	sb.WriteString("按 ↑/↓ 切换输入框，在最后一个输入框按 Enter 调用\n")
+	sb.WriteString(constant.BackToPrevious + "\n")
+	sb.WriteString(constant.ExitMessage + "\n")
+
+	return sb.String()
+}
+
+// writeFunctionList renders one section of the function list, offset is the cursor index of its first function
+func writeFunctionList(sb *strings.Builder, title string, methods []abi.Method, offset int, functionCursor int) {
+	if len(methods) == 0 {
+		return
+	}
+	sb.WriteString(title + "\n")
+	for i, method := range methods {
+		cursor := constant.CursorInactive
+		if functionCursor == offset+i {
+			cursor = constant.CursorActive
+		}
+		line := method.Sig
+		if len(method.Outputs) > 0 {
+			line += " → " + outputTypes(method)
+		}
+		if method.IsPayable() {
+			line += " [payable]"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s\n", cursor, line))
+	}
+	sb.WriteString("\n")
+}
+
+// outputTypes renders the return types of method
+func outputTypes(method abi.Method) string {
+	names := make([]string, len(method.Outputs))
+	for i, output := range method.Outputs {
+		names[i] = output.Type.String()
+	}
+	return "(" + strings.Join(names, ",") + ")"
+}
+
+// inputField renders one labeled text input, the active one with a cursor
+func inputField(label string, value string, active bool) string {
+	cursor := constant.CursorInactive
+	if active {
+		cursor = constant.CursorActive
+		value += string(constant.InputCursor)
+	}
+	return fmt.Sprintf("%s %s: %s\n", cursor, label, value)
+}
+
+// inputHint explains how to type a value of type t
+func inputHint(t abi.Type) string {
+	switch t.T {
+	case abi.AddressTy:
+		return "0x 开头的地址"
+	case abi.IntTy, abi.UintTy:
+		return "十进制或 0x 开头的十六进制整数"
+	case abi.BoolTy:
+		return "true 或 false"
+	case abi.BytesTy, abi.FixedBytesTy:
+		return "0x 开头的十六进制字节"
+	case abi.SliceTy, abi.ArrayTy:
+		return "JSON 数组，例如 [1, 2] 或 [\"0x...\", \"0x...\"]"
+	case abi.TupleTy:
+		return "按字段顺序的 JSON 数组，例如 [\"0x...\", 1]"
+	}
+	return ""
+}
@@ -2,6 +2,7 @@ package views
 
 import (
 	"fmt"
+	"strings"
 
 	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
 	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
@@ -26,8 +27,11 @@ func DeployView(deployChoices []string, deployCursor int) string {
 }
 
 // DeployContractView renders the deploy contract page
-func DeployContractView(model *models.DeployContractModel) string {
+func DeployContractView(model *models.DeployContractModel, deploying bool) string {
 	s := constant.DeployContractPageTitle + "\n" + string(constant.Separator) + "\n\n"
+	if types.GlobalState.DryRun {
+		s += constant.DryRunBanner + "\n\n"
+	}
 
 	if model.IsSelectingContract {
 		if len(model.AvailableContracts) == 0 {
@@ -45,13 +49,14 @@ func DeployContractView(model *models.DeployContractModel) string {
 			}
 		}
 	} else {
-		s += constant.URIPrompt + "\n"
-		s += fmt.Sprintf("> %s", model.URI)
-		if len(model.URI) == 0 {
-			s += string(constant.InputCursor)
-		}
+		s += constructorForm(model)
 	}
-	if types.GlobalState.DeployStat {
+	if len(model.Simulation) > 0 {
+		s += "\n\n" + strings.TrimRight(simulationReport(model.Simulation), "\n")
+	}
+	if deploying {
+		s += "\n\n" + constant.CancelInFlight + "\n"
+	} else if types.GlobalState.DeployStat {
 		s += "\n\n" + constant.BackToMenuMessage + "\n"
 	} else {
 		s += "\n\n" + constant.BackToPrevious + "\n"
@@ -61,3 +66,38 @@ func DeployContractView(model *models.DeployContractModel) string {
 
 	return s
 }
+
+// constructorForm renders one input per constructor parameter of the selected contract
+func constructorForm(model *models.DeployContractModel) string {
+	var sb strings.Builder
+
+	contract := model.AvailableContracts[model.SelectedContract]
+	sb.WriteString(fmt.Sprintf("合约: %s\n", contract.ContractName))
+	if len(model.Constructor.Inputs) == 0 && !model.Constructor.IsPayable() {
+		sb.WriteString("构造函数没有参数\n")
+		return sb.String()
+	}
+	sb.WriteString("请输入构造函数参数：\n\n")
+
+	for i, input := range model.Constructor.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("参数 %d", i+1)
+		}
+		label := fmt.Sprintf("%s (%s)", name, input.Type)
+		if def := model.ArgDefault(i); def != "" {
+			label += fmt.Sprintf("，留空使用部署者地址 %s", def)
+		}
+		sb.WriteString(inputField(label, model.Args[i], model.ArgIndex == i))
+		if model.ArgIndex == i {
+			if hint := inputHint(input.Type); hint != "" {
+				sb.WriteString("    " + hint + "\n")
+			}
+		}
+	}
+	if model.Constructor.IsPayable() {
+		sb.WriteString(inputField("发送 ETH 数量 (wei，可选)", model.Value, model.ArgIndex == len(model.Args)))
+	}
+	sb.WriteString("\n按 ↑/↓ 切换输入框，在最后一个输入框按 Enter 部署")
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// ImportContractView renders the import contract page, imported is nil until a contract
+// was imported
+func ImportContractView(address string, imported *services.DeployedContract, importing bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(constant.ImportContractPageTitle + "\n")
+	sb.WriteString(string(constant.Separator) + "\n\n")
+
+	sb.WriteString(constant.ImportAddressPrompt + "\n")
+	sb.WriteString(fmt.Sprintf("> %s%s\n", address, constant.InputCursor))
+
+	if imported != nil {
+		sb.WriteString("\n")
+		sb.WriteString(fmt.Sprintf("合约地址: %s\n", imported.Address))
+		sb.WriteString(fmt.Sprintf("合约名称: %s\n", imported.ContractName))
+		sb.WriteString(fmt.Sprintf("网络: %s（chain ID %d）\n", imported.Network, imported.ChainID))
+		if imported.Owner != "" {
+			sb.WriteString(fmt.Sprintf("Owner: %s\n", imported.Owner))
+		}
+		if imported.TokenURI != "" {
+			sb.WriteString(fmt.Sprintf("TokenURI: %s\n", imported.TokenURI))
+		}
+	}
+
+	if importing {
+		sb.WriteString("\n按 ESC 取消\n")
+		return sb.String()
+	}
+	sb.WriteString("\n" + constant.BackToPrevious + "\n")
+	sb.WriteString(constant.ExitMessage + "\n")
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
+)
+
+// InspectView renders the inspect contract page
+func InspectView(model *models.InspectModel) string {
+	var sb strings.Builder
+
+	sb.WriteString(constant.InspectPageTitle + "\n")
+	sb.WriteString(string(constant.Separator) + "\n\n")
+
+	if model.InputMode == constant.ContractSelectMode {
+		if len(model.Contracts) == 0 {
+			sb.WriteString(constant.NoDeployedContract + "\n")
+		} else {
+			sb.WriteString("选择要查询的合约\n\n")
+			for i, contract := range model.Contracts {
+				cursor := constant.CursorInactive
+				if model.Cursor == i {
+					cursor = constant.CursorActive
+				}
+				sb.WriteString(fmt.Sprintf("%s %s\n", cursor, ContractLabel(contract)))
+			}
+		}
+		sb.WriteString("\n" + constant.BackToMenuMessage + "\n")
+		sb.WriteString(constant.ExitMessage + "\n")
+		return sb.String()
+	}
+
+	// 合约概览
+	sb.WriteString(fmt.Sprintf("合约地址: %s\n", model.ContractAddress))
+	if model.Owner != "" {
+		sb.WriteString(fmt.Sprintf("Owner: %s\n", model.Owner))
+		sb.WriteString(fmt.Sprintf("单次空投上限: %d\n", model.BatchSize))
+		sb.WriteString(fmt.Sprintf("URI: %s\n", model.URI))
+	}
+	sb.WriteString("\n")
+
+	// 输入框，当前输入框带光标
+	fields := []struct {
+		mode  string
+		label string
+		value string
+	}{
+		{constant.AccountInputMode, "持有人地址", model.Account},
+		{constant.TokenIDsInputMode, "NFT 编号（逗号分隔）", model.TokenIDs},
+		{constant.OperatorInputMode, "授权地址（可选）", model.Operator},
+	}
+	for _, field := range fields {
+		cursor := constant.CursorInactive
+		value := field.value
+		if field.mode == model.InputMode {
+			cursor = constant.CursorActive
+			value += string(constant.InputCursor)
+		}
+		sb.WriteString(fmt.Sprintf("%s %s: %s\n", cursor, field.label, value))
+	}
+	sb.WriteString("\n")
+
+	// 查询结果
+	if len(model.Balances) > 0 {
+		sb.WriteString("查询结果：\n")
+		for i, id := range model.QueriedIDs {
+			sb.WriteString(fmt.Sprintf("NFT #%s 余额: %s\n", id, model.Balances[i]))
+		}
+		if model.Approved != nil {
+			approved := "否"
+			if *model.Approved {
+				approved = "是"
+			}
+			sb.WriteString(fmt.Sprintf("%s 已授权 %s: %s\n", model.Account, model.Operator, approved))
+		}
+		sb.WriteString("\n")
+	}
+
+	if model.InputMode == constant.OperatorInputMode {
+		sb.WriteString("按 Enter 查询\n")
+	} else {
+		sb.WriteString(constant.EnterToContinue + "\n")
+	}
+	sb.WriteString(constant.BackToPrevious + "\n")
+	sb.WriteString(constant.ExitMessage + "\n")
+
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
+)
+
+// NetworkView renders the network switcher page
+func NetworkView(networks []services.NetworkProfile, cursor int, networksPath string, verifying bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(constant.NetworkPageTitle + "\n")
+	sb.WriteString(string(constant.Separator) + "\n\n")
+
+	if len(networks) == 0 {
+		sb.WriteString(fmt.Sprintf(constant.NoNetworksFile, networksPath) + "\n")
+	}
+	for i, network := range networks {
+		cursorChar := constant.CursorInactive
+		if cursor == i {
+			cursorChar = constant.CursorActive
+		}
+		line := fmt.Sprintf("%s %s  chain ID %s  %s", cursorChar, network.Name, chainIDLabel(network.ChainID), network.RPCURL)
+		if network.Mainnet {
+			line += "  [主网]"
+		}
+		if network.Name == types.GlobalState.Network {
+			line += "  (当前)"
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	if verifying {
+		sb.WriteString("\n" + constant.CancelInFlight + "\n")
+		return sb.String()
+	}
+	sb.WriteString("\n" + constant.BackToMenuMessage + "\n")
+	sb.WriteString(constant.ExitMessage + "\n")
+	return sb.String()
+}
+
+// NetworkHeader names the active network above every page, empty before one is chosen
+func NetworkHeader() string {
+	if types.GlobalState.Network == "" {
+		return ""
+	}
+	header := fmt.Sprintf(constant.NetworkBanner, types.GlobalState.Network, chainIDLabel(types.GlobalState.ChainID)) + "\n"
+	if types.GlobalState.Mainnet {
+		header += constant.MainnetBanner + "\n"
+	}
+	return header + "\n"
+}
+
+// chainIDLabel formats the chain ID a profile pins, 0 pins none
+func chainIDLabel(chainID uint64) string {
+	if chainID == 0 {
+		return constant.ChainIDUnpinned
+	}
+	return strconv.FormatUint(chainID, 10)
+}
+
+// ContractLabel names a registry entry in contract lists: its address, the artifact it
+// was built from, whether it was imported and a marker when its chain is not known yet
+func ContractLabel(contract services.DeployedContract) string {
+	label := contract.Address
+	if contract.ContractName != "" {
+		label += "  " + contract.ContractName
+	}
+	if contract.Imported {
+		label += "  " + constant.ImportedMarker
+	}
+	if contract.ChainID == 0 {
+		label += "  " + constant.UnassignedChain
+	}
+	return label
+}
+
+// MainnetConfirmView asks for the name of the mainnet network before a transaction is sent
+func MainnetConfirmView(network string, typed string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(constant.MainnetConfirmPrompt, network) + "\n")
+	sb.WriteString(fmt.Sprintf("> %s%s\n\n", typed, constant.InputCursor))
+	sb.WriteString(constant.BackToPrevious + "\n")
+	return sb.String()
+}
+
+// txLink returns the transaction hash followed by its explorer link on the active network
+func txLink(txHash string) string {
+	url := services.NetworkProfile{Explorer: types.GlobalState.Explorer}.TxURL(txHash)
+	if url == "" {
+		return txHash
+	}
+	return txHash + "  " + url
+}
+
+// TxLinks returns every hash with its explorer link on the active network, one per line
+func TxLinks(txHashes []string) string {
+	lines := make([]string, len(txHashes))
+	for i, txHash := range txHashes {
+		lines[i] = txLink(txHash)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,93 @@
+package views
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/models"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// PendingTxView renders the pending transactions page
+func PendingTxView(model *models.PendingTxModel, sending bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(constant.PendingTxPageTitle + "\n")
+	sb.WriteString(string(constant.Separator) + "\n\n")
+
+	if model.InputMode == constant.TxSelectMode {
+		if len(model.Transactions) == 0 {
+			sb.WriteString(constant.NoPendingTx + "\n")
+		} else {
+			sb.WriteString("选择要加速或取消的交易\n\n")
+			for i, tx := range model.Transactions {
+				cursor := constant.CursorInactive
+				if model.Cursor == i {
+					cursor = constant.CursorActive
+				}
+				sb.WriteString(fmt.Sprintf("%s %s\n", cursor, pendingTxLabel(tx)))
+			}
+		}
+		sb.WriteString("\n" + constant.PendingTxRefreshHint + "\n")
+		sb.WriteString(constant.BackToMenuMessage + "\n")
+		sb.WriteString(constant.ExitMessage + "\n")
+		return sb.String()
+	}
+
+	// 所选交易及其每次发送
+	tx := model.Selected
+	sb.WriteString(fmt.Sprintf("交易: %s（nonce %d）\n", tx.Label, tx.Nonce))
+	sb.WriteString(fmt.Sprintf("发送账户: %s\n", tx.From))
+	for _, attempt := range tx.Attempts {
+		sb.WriteString(fmt.Sprintf("  %-8s %s  %s  %s\n", attempt.Kind, attemptFee(attempt), attempt.SentAt.Format("15:04:05"), txLink(attempt.Hash)))
+	}
+	sb.WriteString("\n" + fmt.Sprintf(constant.PendingTxHelp, services.ReplacementPriceBump) + "\n\n")
+
+	for i, choice := range constant.PendingTxActionChoices {
+		cursor := constant.CursorInactive
+		if model.ActionCursor == i {
+			cursor = constant.CursorActive
+		}
+		sb.WriteString(fmt.Sprintf("%s %s\n", cursor, choice))
+	}
+
+	if sending {
+		sb.WriteString("\n" + constant.CancelInFlight + "\n")
+		return sb.String()
+	}
+	sb.WriteString("\n" + constant.BackToPrevious + "\n")
+	sb.WriteString(constant.ExitMessage + "\n")
+	return sb.String()
+}
+
+// pendingTxLabel shows what a pending transaction does, its nonce, latest fee and age
+func pendingTxLabel(tx services.TrackedTx) string {
+	label := fmt.Sprintf("%s  nonce %d  %s  已等待 %s  %s", tx.Label, tx.Nonce, attemptFee(tx.Latest()), time.Since(tx.CreatedAt).Round(time.Second), tx.Hash())
+	if tx.Cancelling() {
+		label += "  [取消中]"
+	} else if len(tx.Attempts) > 1 {
+		label += fmt.Sprintf("  [已加速 %d 次]", len(tx.Attempts)-1)
+	}
+	return label
+}
+
+// attemptFee shows the max fee and tip of an attempt in gwei, or its gas price
+func attemptFee(attempt services.TxAttempt) string {
+	if attempt.GasPrice != "" {
+		return fmt.Sprintf("gas price %s gwei", gwei(attempt.GasPrice))
+	}
+	return fmt.Sprintf("max fee %s gwei / tip %s gwei", gwei(attempt.GasFeeCap), gwei(attempt.GasTipCap))
+}
+
+// gwei converts a decimal wei amount to gwei
+func gwei(wei string) string {
+	amount, ok := new(big.Float).SetString(wei)
+	if !ok {
+		return wei
+	}
+	return new(big.Float).Quo(amount, big.NewFloat(params.GWei)).Text('f', -1)
+}
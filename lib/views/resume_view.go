@@ -0,0 +1,67 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// ResumeAirdropView renders the prompt for an airdrop left unfinished by an earlier run
+func ResumeAirdropView(journal *services.AirdropJournal, batches []services.BatchStatus, simulation []services.SimulationResult, dryRun bool, sending bool, done bool) string {
+	var sb strings.Builder
+	if dryRun {
+		sb.WriteString(constant.DryRunBanner + "\n")
+	}
+
+	sb.WriteString("\n=== " + constant.ResumeAirdropPageTitle + " ===\n\n")
+	if journal.ChainID != 0 {
+		sb.WriteString(fmt.Sprintf("Chain ID: %d\n", journal.ChainID))
+	}
+	sb.WriteString(fmt.Sprintf("合约地址: %s\n", journal.ContractAddress))
+	sb.WriteString(fmt.Sprintf("NFT 编号: %s\n", journal.NFTID))
+	if journal.URI != "" {
+		sb.WriteString(fmt.Sprintf("URI: %s\n", journal.URI))
+	}
+	if !journal.URISet && journal.URITxHash != "" {
+		sb.WriteString(fmt.Sprintf("URI 设置待确认: %s\n", journal.URITxHash))
+	}
+	sb.WriteString(fmt.Sprintf("开始时间: %s\n\n", journal.CreatedAt.Format("2006-01-02 15:04:05")))
+
+	// 显示记录中每一批的状态
+	for i, batch := range journal.Batches {
+		line := fmt.Sprintf("第 %d/%d 批（%d 个地址）", i+1, len(journal.Batches), len(batch.Recipients))
+		switch batch.State {
+		case services.BatchMined:
+			line += "已完成: " + batch.TxHash
+		case services.BatchSigned:
+			line += "待确认: " + batch.TxHash
+		case services.BatchFailed:
+			line += "失败: " + batch.Error
+		default:
+			line += "未发送"
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(batchProgress(batches))
+	sb.WriteString(simulationReport(simulation))
+
+	if sending {
+		sb.WriteString(constant.CancelInFlight + "\n")
+		return sb.String()
+	}
+
+	if !done && dryRun {
+		sb.WriteString("按 Enter 模拟未完成的批次\n")
+		sb.WriteString("按 D 放弃该空投\n")
+	} else if !done {
+		sb.WriteString("按 Enter 从未完成的批次继续（待确认的交易会先在链上核对）\n")
+		sb.WriteString("按 D 放弃该空投\n")
+	}
+	sb.WriteString(constant.BackToMenuMessage + "\n")
+
+	return sb.String()
+}
@@ -0,0 +1,46 @@
+package views
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
+)
+
+// simulationReport renders the expected outcome, gas and fee of every simulated transaction
+func simulationReport(results []services.SimulationResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	total := new(big.Int)
+	reverted := 0
+	sb.WriteString("模拟结果：\n")
+	for _, result := range results {
+		if !result.Success {
+			reverted++
+			sb.WriteString(fmt.Sprintf("❌ %s: 将会回滚，原因: %s\n", result.Label, result.RevertReason))
+			continue
+		}
+		total.Add(total, result.MaxFee)
+		sb.WriteString(fmt.Sprintf("✅ %s: gas %d，最高手续费 %s ETH\n", result.Label, result.GasLimit, formatEther(result.MaxFee)))
+	}
+
+	if reverted > 0 {
+		sb.WriteString(fmt.Sprintf("共 %d 笔交易，其中 %d 笔会失败\n\n", len(results), reverted))
+	} else {
+		sb.WriteString(fmt.Sprintf("共 %d 笔交易全部成功，最高手续费合计 %s ETH\n\n", len(results), formatEther(total)))
+	}
+	return sb.String()
+}
+
+// formatEther formats a wei amount as ETH
+func formatEther(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	eth := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18))
+	return eth.Text('f', 8)
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 
+	constant "github.com/web3-smart-wallet/smart-contract-cli/lib/constant"
+	"github.com/web3-smart-wallet/smart-contract-cli/lib/services"
 	"github.com/web3-smart-wallet/smart-contract-cli/lib/types"
 )
 
@@ -30,8 +32,11 @@ func UploadView(filePath string, errorMsg string) string {
 }
 
 // ConfirmView renders the confirmation page
-func ConfirmView(addresses []string) string {
+func ConfirmView(addresses []string, estimating bool, setURIGas uint64, mintGas []uint64, estimateErr string, batches []services.BatchStatus, simulation []services.SimulationResult, dryRun bool, sending bool) string {
 	var sb strings.Builder
+	if dryRun {
+		sb.WriteString(constant.DryRunBanner + "\n")
+	}
 	if types.GlobalState.SendNFTStat {
 		sb.WriteString(fmt.Sprintf("NFT发送时间: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
 		sb.WriteString("=== 确认发送 NFT ===\n\n")
@@ -53,12 +58,64 @@ func ConfirmView(addresses []string) string {
 		sb.WriteString("\n")
 	}
 
-	sb.WriteString("按 Enter 确认发送\n")
+	// 显示预估的 gas limit
+	if estimating {
+		sb.WriteString(constant.EstimatingGas + "\n\n")
+	} else if estimateErr != "" {
+		sb.WriteString(fmt.Sprintf("Gas 预估失败: %s\n\n", estimateErr))
+	} else if setURIGas > 0 || len(mintGas) > 0 {
+		sb.WriteString("预估 Gas Limit：\n")
+		sb.WriteString(fmt.Sprintf("setURI: %d\n", setURIGas))
+		if len(mintGas) == 1 {
+			sb.WriteString(fmt.Sprintf("mintToMultple: %d\n\n", mintGas[0]))
+		} else {
+			sb.WriteString(fmt.Sprintf("mintToMultple: 分 %d 批发送\n", len(mintGas)))
+			for i, gas := range mintGas {
+				sb.WriteString(fmt.Sprintf("  第 %d 批: %d\n", i+1, gas))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	// 显示每一批的发送状态
+	sb.WriteString(batchProgress(batches))
+	sb.WriteString(simulationReport(simulation))
+
+	if sending {
+		sb.WriteString(constant.CancelInFlight + "\n")
+		return sb.String()
+	}
+
+	if dryRun {
+		sb.WriteString("按 Enter 模拟发送\n")
+	} else {
+		sb.WriteString("按 Enter 确认发送\n")
+	}
+	sb.WriteString(constant.DryRunToggle + "\n")
 	sb.WriteString("按 ESC 取消操作\n")
 
 	return sb.String()
 }
 
+// batchProgress renders the status of every airdrop batch sent so far
+func batchProgress(batches []services.BatchStatus) string {
+	if len(batches) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("发送进度：\n")
+	for _, batch := range batches {
+		if batch.Err != nil {
+			sb.WriteString(fmt.Sprintf("第 %d/%d 批（%d 个地址）失败\n", batch.Index, batch.Total, batch.Recipients))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("第 %d/%d 批（%d 个地址）成功: %s\n", batch.Index, batch.Total, batch.Recipients, txLink(batch.TxHash)))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a